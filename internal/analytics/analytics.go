@@ -0,0 +1,329 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+
+	GroupByNone     = ""
+	GroupByExercise = "exercise"
+	GroupByMuscle   = "muscle"
+)
+
+// VolumeQuery describes how training volume is bucketed. Timezone decides
+// which local day, week or month a workout falls into.
+type VolumeQuery struct {
+	UserID   int64
+	Period   string
+	GroupBy  string
+	Timezone string
+	From     *time.Time
+	To       *time.Time
+}
+
+type VolumeBucket struct {
+	Period string  `json:"period"`
+	Group  string  `json:"group,omitempty"`
+	Volume float64 `json:"volume"`
+	Sets   int     `json:"sets"`
+	Reps   int     `json:"reps"`
+}
+
+type Summary struct {
+	TotalWorkouts  int        `json:"total_workouts"`
+	TotalMinutes   int        `json:"total_minutes"`
+	TotalCalories  int        `json:"total_calories"`
+	TotalVolume    float64    `json:"total_volume"`
+	CurrentStreak  int        `json:"current_streak"`
+	LongestStreak  int        `json:"longest_streak"`
+	FirstWorkoutAt *time.Time `json:"first_workout_at"`
+	LastWorkoutAt  *time.Time `json:"last_workout_at"`
+}
+
+type ProgressionPoint struct {
+	Period           string  `json:"period"`
+	MaxWeight        float64 `json:"max_weight"`
+	BestEstimated1RM float64 `json:"best_estimated_1rm"`
+	Volume           float64 `json:"volume"`
+	Sets             int     `json:"sets"`
+	Reps             int     `json:"reps"`
+}
+
+// Only completed workouts count towards any of the statistics.
+type Store interface {
+	Volume(ctx context.Context, query VolumeQuery) ([]VolumeBucket, error)
+	Summary(ctx context.Context, userID int64, timezone string) (*Summary, error)
+	ExerciseProgression(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64, period, timezone string) ([]ProgressionPoint, error)
+	ForOrganization(organizationID *int64) Store
+}
+
+type PostgresStore struct {
+	db             *sql.DB
+	queryTimeout   time.Duration
+	organizationID *int64
+}
+
+func NewPostgresStore(db *sql.DB, queryTimeout time.Duration) *PostgresStore {
+	return &PostgresStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store whose statistics only count the workouts of
+// the organization, or of the personal space when organizationID is nil.
+func (pg *PostgresStore) ForOrganization(organizationID *int64) Store {
+	return &PostgresStore{
+		db:             pg.db,
+		queryTimeout:   pg.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+// entryExerciseKey is models.NormalizeExerciseName of the entry's catalog
+// name or, for entries without one, of the name it was logged under. Volume
+// groups by it and progression matches on it, so spellings of an exercise
+// add up.
+const entryExerciseKey = `lower(btrim(regexp_replace(COALESCE(x.name, e.exercise_name), '\s+', ' ', 'g')))`
+
+func IsValidPeriod(period string) bool {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	}
+	return false
+}
+
+func (pg *PostgresStore) Volume(ctx context.Context, q VolumeQuery) ([]VolumeBucket, error) {
+	ctx, cancel := store.WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	var groupExpr, groupJoin string
+	switch q.GroupBy {
+	case GroupByNone:
+		groupExpr = "''"
+	case GroupByExercise:
+		groupExpr = entryExerciseKey
+	case GroupByMuscle:
+		// an exercise counts towards each of its primary muscles
+		groupExpr = "m.muscle"
+		groupJoin = "CROSS JOIN LATERAL unnest(COALESCE(NULLIF(x.primary_muscles, '{}'), ARRAY['other'])) AS m(muscle)"
+	default:
+		return nil, fmt.Errorf("unknown volume grouping %q", q.GroupBy)
+	}
+	if !IsValidPeriod(q.Period) {
+		return nil, fmt.Errorf("unknown period %q", q.Period)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			date_trunc($2, w.created_at AT TIME ZONE $3) AS bucket,
+			%s AS grp,
+			COALESCE(SUM(s.reps * s.weight), 0)::float8,
+			COUNT(s.id),
+			COALESCE(SUM(s.reps), 0)
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		LEFT JOIN exercises x ON x.id = e.exercise_id
+		%s
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $6
+		AND ($4::timestamptz IS NULL OR w.created_at >= $4)
+		AND ($5::timestamptz IS NULL OR w.created_at < $5)
+		GROUP BY bucket, grp
+		ORDER BY bucket, grp
+	`, groupExpr, groupJoin)
+
+	rows, err := pg.db.QueryContext(ctx, query, q.UserID, q.Period, q.Timezone, q.From, q.To, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []VolumeBucket{}
+	for rows.Next() {
+		var (
+			bucket VolumeBucket
+			start  time.Time
+		)
+		if err := rows.Scan(&start, &bucket.Group, &bucket.Volume, &bucket.Sets, &bucket.Reps); err != nil {
+			return nil, err
+		}
+		bucket.Period = start.Format(time.DateOnly)
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+func (pg *PostgresStore) Summary(ctx context.Context, userID int64, timezone string) (*Summary, error) {
+	ctx, cancel := store.WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	summary := &Summary{}
+
+	totals := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(duration_minutes), 0),
+			COALESCE(SUM(calories_burned), 0),
+			MIN(created_at),
+			MAX(created_at)
+		FROM workouts
+		WHERE user_id = $1 AND status = 'completed'
+		AND organization_id IS NOT DISTINCT FROM $2
+	`
+	if err := pg.db.QueryRowContext(ctx, totals, userID, pg.organizationID).Scan(
+		&summary.TotalWorkouts,
+		&summary.TotalMinutes,
+		&summary.TotalCalories,
+		&summary.FirstWorkoutAt,
+		&summary.LastWorkoutAt,
+	); err != nil {
+		return nil, err
+	}
+
+	volume := `
+		SELECT COALESCE(SUM(s.reps * s.weight), 0)::float8
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $2
+	`
+	if err := pg.db.QueryRowContext(ctx, volume, userID, pg.organizationID).Scan(&summary.TotalVolume); err != nil {
+		return nil, err
+	}
+
+	days := `
+		SELECT DISTINCT (created_at AT TIME ZONE $2)::date AS day
+		FROM workouts
+		WHERE user_id = $1 AND status = 'completed'
+		AND organization_id IS NOT DISTINCT FROM $3
+		ORDER BY day
+	`
+	rows, err := pg.db.QueryContext(ctx, days, userID, timezone, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trainingDays := []time.Time{}
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		trainingDays = append(trainingDays, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	summary.CurrentStreak, summary.LongestStreak = Streaks(trainingDays, time.Now().In(loc))
+
+	return summary, nil
+}
+
+func (pg *PostgresStore) ExerciseProgression(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64, period, timezone string) ([]ProgressionPoint, error) {
+	ctx, cancel := store.WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	if !IsValidPeriod(period) {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+
+	query := `
+		SELECT
+			date_trunc($2, w.created_at AT TIME ZONE $3) AS bucket,
+			COALESCE(MAX(s.weight), 0)::float8,
+			COALESCE(MAX(
+				CASE WHEN s.reps = 1 THEN s.weight ELSE s.weight * (1 + s.reps / 30.0) END
+			), 0)::float8,
+			COALESCE(SUM(s.reps * s.weight), 0)::float8,
+			COUNT(s.id),
+			COALESCE(SUM(s.reps), 0)
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		LEFT JOIN exercises x ON x.id = e.exercise_id
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $6
+		AND (e.exercise_id = $4 OR ` + entryExerciseKey + ` = $5)
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+	rows, err := pg.db.QueryContext(ctx, query, userID, period, timezone, exerciseID, exerciseKey, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []ProgressionPoint{}
+	for rows.Next() {
+		var (
+			point ProgressionPoint
+			start time.Time
+		)
+		if err := rows.Scan(
+			&start,
+			&point.MaxWeight,
+			&point.BestEstimated1RM,
+			&point.Volume,
+			&point.Sets,
+			&point.Reps,
+		); err != nil {
+			return nil, err
+		}
+		point.Period = start.Format(time.DateOnly)
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+// Streaks returns the current and longest runs of consecutive training days.
+// days must be sorted and unique. The current streak is still alive if the
+// last training day was today or yesterday.
+func Streaks(days []time.Time, today time.Time) (current, longest int) {
+	run := 0
+	var previous time.Time
+	for i, day := range days {
+		if i > 0 && sameDay(previous.AddDate(0, 0, 1), day) {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		previous = day
+	}
+
+	if len(days) == 0 {
+		return 0, 0
+	}
+	last := days[len(days)-1]
+	if sameDay(last, today) || sameDay(last.AddDate(0, 0, 1), today) {
+		current = run
+	}
+	return current, longest
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,267 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+// MemoryStore computes the statistics from the workouts of an in-memory
+// store instead of in SQL.
+type MemoryStore struct {
+	db             *store.MemoryDB
+	organizationID *int64
+}
+
+func NewMemoryStore(db *store.MemoryDB) *MemoryStore {
+	return &MemoryStore{
+		db: db,
+	}
+}
+
+// ForOrganization returns a store whose statistics only count the workouts of
+// the organization, or of the personal space when organizationID is nil.
+func (ms *MemoryStore) ForOrganization(organizationID *int64) Store {
+	return &MemoryStore{
+		db:             ms.db,
+		organizationID: organizationID,
+	}
+}
+
+// loggedSet is a set that wasn't failed, with what it needs to be grouped.
+type loggedSet struct {
+	workout *models.Workout
+	entry   *models.WorkoutEntry
+	set     *models.WorkoutSet
+}
+
+func (ms *MemoryStore) sets(workouts []*models.Workout) []loggedSet {
+	sets := []loggedSet{}
+	for _, w := range workouts {
+		for i := range w.Entries {
+			entry := &w.Entries[i]
+			for j := range entry.Sets {
+				if !entry.Sets[j].Failed {
+					sets = append(sets, loggedSet{workout: w, entry: entry, set: &entry.Sets[j]})
+				}
+			}
+		}
+	}
+	return sets
+}
+
+func (ms *MemoryStore) Volume(ctx context.Context, q VolumeQuery) ([]VolumeBucket, error) {
+	if q.GroupBy != GroupByNone && q.GroupBy != GroupByExercise && q.GroupBy != GroupByMuscle {
+		return nil, fmt.Errorf("unknown volume grouping %q", q.GroupBy)
+	}
+	if !IsValidPeriod(q.Period) {
+		return nil, fmt.Errorf("unknown period %q", q.Period)
+	}
+	loc, err := time.LoadLocation(q.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := []*models.Workout{}
+	for _, w := range ms.db.CompletedWorkouts(q.UserID, ms.organizationID) {
+		if (q.From == nil || !w.CreatedAt.Before(*q.From)) && (q.To == nil || w.CreatedAt.Before(*q.To)) {
+			workouts = append(workouts, w)
+		}
+	}
+
+	type key struct {
+		bucket time.Time
+		group  string
+	}
+	totals := map[key]*VolumeBucket{}
+	for _, s := range ms.sets(workouts) {
+		bucket := truncate(s.workout.CreatedAt.In(loc), q.Period)
+		for _, group := range ms.groups(s.entry, q.GroupBy) {
+			k := key{bucket, group}
+			total, ok := totals[k]
+			if !ok {
+				total = &VolumeBucket{Period: bucket.Format(time.DateOnly), Group: group}
+				totals[k] = total
+			}
+			addSet(&total.Volume, &total.Sets, &total.Reps, s.set)
+		}
+	}
+
+	keys := make([]key, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].bucket.Equal(keys[j].bucket) {
+			return keys[i].bucket.Before(keys[j].bucket)
+		}
+		return keys[i].group < keys[j].group
+	})
+
+	buckets := make([]VolumeBucket, 0, len(keys))
+	for _, k := range keys {
+		bucket := *totals[k]
+		bucket.Volume = round(bucket.Volume)
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// groups returns what the entry's sets count towards: the exercise, each of
+// its primary muscles, or a single unnamed group.
+func (ms *MemoryStore) groups(entry *models.WorkoutEntry, groupBy string) []string {
+	var exercise *models.Exercise
+	if entry.ExerciseID != nil {
+		exercise = ms.db.Exercise(*entry.ExerciseID)
+	}
+
+	switch groupBy {
+	case GroupByExercise:
+		return []string{ms.exerciseKey(entry, exercise)}
+	case GroupByMuscle:
+		if exercise != nil && len(exercise.PrimaryMuscles) > 0 {
+			return exercise.PrimaryMuscles
+		}
+		return []string{"other"}
+	}
+	return []string{""}
+}
+
+// exerciseKey is the normalized name of the entry's catalog exercise or, for
+// entries without one, of the name it was logged under.
+func (ms *MemoryStore) exerciseKey(entry *models.WorkoutEntry, exercise *models.Exercise) string {
+	if exercise != nil {
+		return models.NormalizeExerciseName(exercise.Name)
+	}
+	return models.NormalizeExerciseName(entry.ExerciseName)
+}
+
+func (ms *MemoryStore) Summary(ctx context.Context, userID int64, timezone string) (*Summary, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	workouts := ms.db.CompletedWorkouts(userID, ms.organizationID)
+	summary := &Summary{}
+
+	var sets, reps int
+	trainingDays := []time.Time{}
+	for _, w := range workouts {
+		summary.TotalWorkouts++
+		summary.TotalMinutes += w.DurationMinutes
+		summary.TotalCalories += w.CaloriesBurned
+
+		day := truncate(w.CreatedAt.In(loc), PeriodDay)
+		if len(trainingDays) == 0 || !trainingDays[len(trainingDays)-1].Equal(day) {
+			trainingDays = append(trainingDays, day)
+		}
+	}
+	for _, s := range ms.sets(workouts) {
+		addSet(&summary.TotalVolume, &sets, &reps, s.set)
+	}
+	summary.TotalVolume = round(summary.TotalVolume)
+
+	if len(workouts) > 0 {
+		first, last := workouts[0].CreatedAt, workouts[len(workouts)-1].CreatedAt
+		summary.FirstWorkoutAt, summary.LastWorkoutAt = &first, &last
+	}
+
+	summary.CurrentStreak, summary.LongestStreak = Streaks(trainingDays, time.Now().In(loc))
+	return summary, nil
+}
+
+func (ms *MemoryStore) ExerciseProgression(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64, period, timezone string) ([]ProgressionPoint, error) {
+	if !IsValidPeriod(period) {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := map[time.Time]*ProgressionPoint{}
+	for _, s := range ms.sets(ms.db.CompletedWorkouts(userID, ms.organizationID)) {
+		var exercise *models.Exercise
+		if s.entry.ExerciseID != nil {
+			exercise = ms.db.Exercise(*s.entry.ExerciseID)
+		}
+		matchesID := exerciseID != nil && s.entry.ExerciseID != nil && *s.entry.ExerciseID == *exerciseID
+		if !matchesID && ms.exerciseKey(s.entry, exercise) != exerciseKey {
+			continue
+		}
+
+		bucket := truncate(s.workout.CreatedAt.In(loc), period)
+		point, ok := totals[bucket]
+		if !ok {
+			point = &ProgressionPoint{Period: bucket.Format(time.DateOnly)}
+			totals[bucket] = point
+		}
+		addSet(&point.Volume, &point.Sets, &point.Reps, s.set)
+
+		if s.set.Weight == nil {
+			continue
+		}
+		point.MaxWeight = max(point.MaxWeight, *s.set.Weight)
+		if s.set.Reps != nil {
+			estimate := *s.set.Weight
+			if *s.set.Reps != 1 {
+				estimate = *s.set.Weight * (1 + float64(*s.set.Reps)/30)
+			}
+			point.BestEstimated1RM = max(point.BestEstimated1RM, estimate)
+		}
+	}
+
+	buckets := make([]time.Time, 0, len(totals))
+	for bucket := range totals {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Before(buckets[j])
+	})
+
+	points := make([]ProgressionPoint, 0, len(buckets))
+	for _, bucket := range buckets {
+		point := *totals[bucket]
+		point.Volume = round(point.Volume)
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+// addSet adds the set to the running volume, set and rep totals. Sets
+// without reps or weight add no volume, like NULL does in SUM.
+func addSet(volume *float64, sets, reps *int, set *models.WorkoutSet) {
+	*sets++
+	if set.Reps != nil {
+		*reps += *set.Reps
+	}
+	if set.Reps != nil && set.Weight != nil {
+		*volume += float64(*set.Reps) * *set.Weight
+	}
+}
+
+// truncate is date_trunc on a local time: the start of its day, ISO week or
+// month, as a date.
+func truncate(t time.Time, period string) time.Time {
+	year, month, day := t.Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	switch period {
+	case PeriodWeek:
+		// weeks start on Monday
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case PeriodMonth:
+		return date.AddDate(0, 0, 1-day)
+	}
+	return date
+}
+
+// round keeps two decimals; weights have two, so any more is float noise.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
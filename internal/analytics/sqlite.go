@@ -0,0 +1,277 @@
+package analytics
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"modernc.org/sqlite"
+)
+
+// locations caches time.LoadLocation for date_trunc, which runs once per row.
+var locations sync.Map
+
+func init() {
+	// stands in for Postgres' date_trunc(field, timestamptz, zone), which
+	// needs time zones SQLite doesn't have. It returns the start of the
+	// period as a date.
+	err := sqlite.RegisterDeterministicScalarFunction("date_trunc", 3, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		period, _ := args[0].(string)
+		timezone, _ := args[2].(string)
+		if !IsValidPeriod(period) {
+			return nil, fmt.Errorf("unknown period %q", period)
+		}
+
+		var t time.Time
+		if err := store.SQLiteTime(&t).Scan(args[1]); err != nil {
+			return nil, err
+		}
+
+		loc, ok := locations.Load(timezone)
+		if !ok {
+			l, err := time.LoadLocation(timezone)
+			if err != nil {
+				return nil, err
+			}
+			loc, _ = locations.LoadOrStore(timezone, l)
+		}
+
+		return truncate(t.In(loc.(*time.Location)), period).Format(time.DateOnly), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// SQLite's regular expressions can't replace, so the exercise key is
+	// computed in Go
+	err = sqlite.RegisterDeterministicScalarFunction("normalize_exercise_name", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		name, _ := args[0].(string)
+		return models.NormalizeExerciseName(name), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sqliteEntryExerciseKey is entryExerciseKey for SQLite.
+const sqliteEntryExerciseKey = `normalize_exercise_name(COALESCE(x.name, e.exercise_name))`
+
+type SQLiteStore struct {
+	db             *sql.DB
+	queryTimeout   time.Duration
+	organizationID *int64
+}
+
+func NewSQLiteStore(db *sql.DB, queryTimeout time.Duration) *SQLiteStore {
+	return &SQLiteStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store whose statistics only count the workouts of
+// the organization, or of the personal space when organizationID is nil.
+func (s *SQLiteStore) ForOrganization(organizationID *int64) Store {
+	return &SQLiteStore{
+		db:             s.db,
+		queryTimeout:   s.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+func (s *SQLiteStore) Volume(ctx context.Context, q VolumeQuery) ([]VolumeBucket, error) {
+	ctx, cancel := store.WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var groupExpr, groupJoin string
+	switch q.GroupBy {
+	case GroupByNone:
+		groupExpr = "''"
+	case GroupByExercise:
+		groupExpr = sqliteEntryExerciseKey
+	case GroupByMuscle:
+		// an exercise counts towards each of its primary muscles
+		groupExpr = "m.value"
+		groupJoin = `CROSS JOIN json_each(COALESCE(NULLIF(x.primary_muscles, '[]'), '["other"]')) AS m`
+	default:
+		return nil, fmt.Errorf("unknown volume grouping %q", q.GroupBy)
+	}
+	if !IsValidPeriod(q.Period) {
+		return nil, fmt.Errorf("unknown period %q", q.Period)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			date_trunc($2, w.created_at, $3) AS bucket,
+			%s AS grp,
+			COALESCE(SUM(s.reps * s.weight), 0),
+			COUNT(s.id),
+			COALESCE(SUM(s.reps), 0)
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		LEFT JOIN exercises x ON x.id = e.exercise_id
+		%s
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $6
+		AND ($4 IS NULL OR w.created_at >= $4)
+		AND ($5 IS NULL OR w.created_at < $5)
+		GROUP BY bucket, grp
+		ORDER BY bucket, grp
+	`, groupExpr, groupJoin)
+
+	rows, err := s.db.QueryContext(ctx, query, q.UserID, q.Period, q.Timezone, q.From, q.To, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []VolumeBucket{}
+	for rows.Next() {
+		var bucket VolumeBucket
+		if err := rows.Scan(&bucket.Period, &bucket.Group, &bucket.Volume, &bucket.Sets, &bucket.Reps); err != nil {
+			return nil, err
+		}
+		bucket.Volume = round(bucket.Volume)
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, rows.Err()
+}
+
+func (s *SQLiteStore) Summary(ctx context.Context, userID int64, timezone string) (*Summary, error) {
+	ctx, cancel := store.WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	summary := &Summary{}
+
+	totals := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(duration_minutes), 0),
+			COALESCE(SUM(calories_burned), 0),
+			MIN(created_at),
+			MAX(created_at)
+		FROM workouts
+		WHERE user_id = $1 AND status = 'completed'
+		AND organization_id IS NOT DISTINCT FROM $2
+	`
+	if err := s.db.QueryRowContext(ctx, totals, userID, s.organizationID).Scan(
+		&summary.TotalWorkouts,
+		&summary.TotalMinutes,
+		&summary.TotalCalories,
+		store.SQLiteTime(&summary.FirstWorkoutAt),
+		store.SQLiteTime(&summary.LastWorkoutAt),
+	); err != nil {
+		return nil, err
+	}
+
+	volume := `
+		SELECT COALESCE(SUM(s.reps * s.weight), 0)
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $2
+	`
+	if err := s.db.QueryRowContext(ctx, volume, userID, s.organizationID).Scan(&summary.TotalVolume); err != nil {
+		return nil, err
+	}
+	summary.TotalVolume = round(summary.TotalVolume)
+
+	days := `
+		SELECT DISTINCT date_trunc('day', created_at, $2) AS day
+		FROM workouts
+		WHERE user_id = $1 AND status = 'completed'
+		AND organization_id IS NOT DISTINCT FROM $3
+		ORDER BY day
+	`
+	rows, err := s.db.QueryContext(ctx, days, userID, timezone, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trainingDays := []time.Time{}
+	for rows.Next() {
+		var day string
+		if err := rows.Scan(&day); err != nil {
+			return nil, err
+		}
+		parsed, err := time.Parse(time.DateOnly, day)
+		if err != nil {
+			return nil, err
+		}
+		trainingDays = append(trainingDays, parsed)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	summary.CurrentStreak, summary.LongestStreak = Streaks(trainingDays, time.Now().In(loc))
+
+	return summary, nil
+}
+
+func (s *SQLiteStore) ExerciseProgression(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64, period, timezone string) ([]ProgressionPoint, error) {
+	ctx, cancel := store.WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if !IsValidPeriod(period) {
+		return nil, fmt.Errorf("unknown period %q", period)
+	}
+
+	query := `
+		SELECT
+			date_trunc($2, w.created_at, $3) AS bucket,
+			COALESCE(MAX(s.weight), 0),
+			COALESCE(MAX(
+				CASE WHEN s.reps = 1 THEN s.weight ELSE s.weight * (1 + s.reps / 30.0) END
+			), 0),
+			COALESCE(SUM(s.reps * s.weight), 0),
+			COUNT(s.id),
+			COALESCE(SUM(s.reps), 0)
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		INNER JOIN workout_sets s ON s.workout_entry_id = e.id AND NOT s.failed
+		LEFT JOIN exercises x ON x.id = e.exercise_id
+		WHERE w.user_id = $1 AND w.status = 'completed'
+		AND w.organization_id IS NOT DISTINCT FROM $6
+		AND (e.exercise_id = $4 OR ` + sqliteEntryExerciseKey + ` = $5)
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, period, timezone, exerciseID, exerciseKey, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []ProgressionPoint{}
+	for rows.Next() {
+		var point ProgressionPoint
+		if err := rows.Scan(
+			&point.Period,
+			&point.MaxWeight,
+			&point.BestEstimated1RM,
+			&point.Volume,
+			&point.Sets,
+			&point.Reps,
+		); err != nil {
+			return nil, err
+		}
+		point.Volume = round(point.Volume)
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 200
+)
+
+type AdminHandler struct {
+	userStore  store.UserStore
+	tokenStore store.TokenStore
+	adminStore store.AdminStore
+	auditor    *audit.Auditor
+	logger     *slog.Logger
+}
+
+func NewAdminHandler(userStore store.UserStore, tokenStore store.TokenStore, adminStore store.AdminStore, auditor *audit.Auditor, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{
+		userStore:  userStore,
+		tokenStore: tokenStore,
+		adminStore: adminStore,
+		auditor:    auditor,
+		logger:     logger,
+	}
+}
+
+func (ah *AdminHandler) HandleListUsers(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := ah.readPage(w, r)
+	if !ok {
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	users, err := ah.userStore.ListUsers(r.Context(), limit+1, (page-1)*limit)
+	if err != nil {
+		ah.logger.Error("failed to list users", "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+	hasMore := len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.User{
+			"users": users,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		ah.logger.Error("failed to write success response for admin list users", "error", err)
+	}
+}
+
+// HandleUpdateUserDisabled disables or re-enables an account. Disabling
+// also signs the user out everywhere.
+func (ah *AdminHandler) HandleUpdateUserDisabled(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUser(r)
+
+	var req struct {
+		Disabled *bool `json:"disabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Disabled == nil {
+		ah.writeBadRequest(w, "Invalid request payload. Please provide disabled as true or false.")
+		return
+	}
+
+	user, ok := ah.readUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == admin.ID {
+		ah.writeBadRequest(w, "You can't disable your own account.")
+		return
+	}
+
+	user.Disabled = *req.Disabled
+	if err := ah.userStore.UpdateDisabled(r.Context(), user); err != nil {
+		ah.logger.Error("failed to update disabled flag in store", "user_id", user.ID, "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+
+	action := models.AdminActionEnableUser
+	if user.Disabled {
+		action = models.AdminActionDisableUser
+		if err := ah.revokeSessions(r.Context(), user.ID); err != nil {
+			ah.logger.Error("failed to revoke tokens of disabled user", "user_id", user.ID, "error", err)
+			ah.writeServerError(w, err)
+			return
+		}
+	}
+	ah.record(r.Context(), admin, action, "user", user.ID, nil)
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.User{
+			"user": user,
+		},
+	}); err != nil {
+		ah.logger.Error("failed to write success response for admin disable user", "user_id", user.ID, "error", err)
+	}
+}
+
+func (ah *AdminHandler) HandleUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUser(r)
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !models.IsValidRole(req.Role) {
+		ah.writeBadRequest(w, "Invalid role. Must be one of user, coach or admin.")
+		return
+	}
+
+	user, ok := ah.readUser(w, r)
+	if !ok {
+		return
+	}
+	if user.ID == admin.ID {
+		ah.writeBadRequest(w, "You can't change your own role.")
+		return
+	}
+
+	previousRole := user.Role
+	user.Role = req.Role
+	if err := ah.userStore.UpdateRole(r.Context(), user); err != nil {
+		ah.logger.Error("failed to update role in store", "user_id", user.ID, "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+	ah.record(r.Context(), admin, models.AdminActionChangeRole, "user", user.ID, map[string]any{
+		"from": previousRole,
+		"to":   user.Role,
+	})
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.User{
+			"user": user,
+		},
+	}); err != nil {
+		ah.logger.Error("failed to write success response for admin change role", "user_id", user.ID, "error", err)
+	}
+}
+
+// HandleRevokeUserTokens signs the user out of every session.
+func (ah *AdminHandler) HandleRevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUser(r)
+
+	user, ok := ah.readUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ah.revokeSessions(r.Context(), user.ID); err != nil {
+		ah.logger.Error("failed to revoke user tokens", "user_id", user.ID, "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+	ah.record(r.Context(), admin, models.AdminActionRevokeTokens, "user", user.ID, nil)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *AdminHandler) HandleDeleteWorkout(w http.ResponseWriter, r *http.Request) {
+	admin := middleware.GetUser(r)
+
+	workoutID, ok := readWorkoutIDParam(w, r, ah.logger)
+	if !ok {
+		return
+	}
+
+	if err := ah.adminStore.DeleteWorkout(r.Context(), workoutID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The workout you are trying to delete could not be found.",
+			})
+			return
+		}
+
+		ah.logger.Error("failed to execute admin workout deletion in store", "workout_id", workoutID, "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+	ah.record(r.Context(), admin, models.AdminActionDeleteWorkout, "workout", workoutID, nil)
+	ah.auditor.Record(r, &audit.Event{
+		ActorID:    &admin.ID,
+		Action:     audit.ActionWorkoutDeleted,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workoutID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *AdminHandler) HandleListActions(w http.ResponseWriter, r *http.Request) {
+	page, limit, ok := ah.readPage(w, r)
+	if !ok {
+		return
+	}
+
+	actions, err := ah.adminStore.ListActions(r.Context(), limit+1, (page-1)*limit)
+	if err != nil {
+		ah.logger.Error("failed to list admin actions", "error", err)
+		ah.writeServerError(w, err)
+		return
+	}
+	hasMore := len(actions) > limit
+	if hasMore {
+		actions = actions[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.AdminAction{
+			"actions": actions,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		ah.logger.Error("failed to write success response for admin list actions", "error", err)
+	}
+}
+
+// record writes an entry to the admin log. The action has already happened
+// by then, so a failure is only logged.
+func (ah *AdminHandler) record(ctx context.Context, admin *models.User, action, targetType string, targetID int64, details map[string]any) {
+	entry := &models.AdminAction{
+		AdminID:    &admin.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+	}
+	// the action already happened, so log it even if the client went away
+	if err := ah.adminStore.RecordAction(context.WithoutCancel(ctx), entry); err != nil {
+		ah.logger.Error("failed to record admin action", "admin_id", admin.ID, "action", action, "target_id", targetID, "error", err)
+		return
+	}
+	ah.logger.Info("admin action", "admin_id", admin.ID, "action", action, "target_type", targetType, "target_id", targetID)
+}
+
+func (ah *AdminHandler) revokeSessions(ctx context.Context, userID int64) error {
+	for _, scope := range []string{tokens.ScopeAuth, tokens.ScopeRefresh} {
+		if err := ah.tokenStore.DeleteAllTokensForUser(ctx, userID, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ah *AdminHandler) readUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	userID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ah.writeBadRequest(w, "Invalid user ID. Please provide a valid numeric identifier.")
+		return nil, false
+	}
+
+	user, err := ah.userStore.GetUserByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested user could not be found.",
+			})
+			return nil, false
+		}
+
+		ah.logger.Error("failed to fetch user by id", "user_id", userID, "error", err)
+		ah.writeServerError(w, err)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (ah *AdminHandler) readPage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	page, limit, err := readPageParams(r, defaultAdminPageSize, maxAdminPageSize)
+	if err != nil {
+		ah.writeBadRequest(w, fmt.Sprintf("Invalid query parameters: %s.", err))
+		return 0, 0, false
+	}
+	return page, limit, true
+}
+
+func (ah *AdminHandler) writeBadRequest(w http.ResponseWriter, message string) {
+	utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+		"status":  "fail",
+		"message": message,
+	})
+}
+
+func (ah *AdminHandler) writeServerError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to complete the admin request due to a server error. Please try again later.",
+	})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+type AuditHandler struct {
+	auditStore audit.Store
+	logger     *slog.Logger
+}
+
+func NewAuditHandler(auditStore audit.Store, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditStore: auditStore,
+		logger:     logger,
+	}
+}
+
+// HandleListMyEvents returns the events the current user caused.
+func (ah *AuditHandler) HandleListMyEvents(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	ah.listEvents(w, r, audit.Filter{ActorID: &currentUser.ID})
+}
+
+// HandleListEvents lets admins query the whole log by actor, action and
+// target.
+func (ah *AuditHandler) HandleListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := audit.Filter{
+		Action:     q.Get("action"),
+		TargetType: q.Get("target_type"),
+	}
+	for param, dst := range map[string]**int64{
+		"actor_id":  &filter.ActorID,
+		"target_id": &filter.TargetID,
+	} {
+		value := q.Get(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": fmt.Sprintf("Invalid query parameters: %s must be a numeric identifier.", param),
+			})
+			return
+		}
+		*dst = &id
+	}
+
+	ah.listEvents(w, r, filter)
+}
+
+func (ah *AuditHandler) listEvents(w http.ResponseWriter, r *http.Request, filter audit.Filter) {
+	page, limit, err := readPageParams(r, defaultAuditPageSize, maxAuditPageSize)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Invalid query parameters: %s.", err),
+		})
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	filter.Limit = limit + 1
+	filter.Offset = (page - 1) * limit
+	events, err := ah.auditStore.List(r.Context(), filter)
+	if err != nil {
+		ah.logger.Error("failed to list audit events", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the audit log due to a server error. Please try again later.",
+		})
+		return
+	}
+	hasMore := len(events) > limit
+	if hasMore {
+		events = events[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*audit.Event{
+			"events": events,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		ah.logger.Error("failed to write success response for list audit events", "error", err)
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/mailer"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+type CoachHandler struct {
+	coachStore store.CoachStore
+	userStore  store.UserStore
+	mailer     mailer.Mailer
+	logger     *slog.Logger
+}
+
+func NewCoachHandler(coachStore store.CoachStore, userStore store.UserStore, mailer mailer.Mailer, logger *slog.Logger) *CoachHandler {
+	return &CoachHandler{
+		coachStore: coachStore,
+		userStore:  userStore,
+		mailer:     mailer,
+		logger:     logger,
+	}
+}
+
+// HandleInviteAthlete lets a coach ask an athlete, by username, for access
+// to their log. Nothing is shared until the athlete accepts.
+func (ch *CoachHandler) HandleInviteAthlete(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please provide the username of the athlete.",
+		})
+		return
+	}
+
+	athlete, err := ch.userStore.GetUserByUsername(r.Context(), req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested user could not be found.",
+			})
+			return
+		}
+
+		ch.logger.Error("failed to fetch athlete by username", "username", req.Username, "error", err)
+		ch.writeServerError(w, err)
+		return
+	}
+	if athlete.ID == currentUser.ID {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "You can't coach yourself.",
+		})
+		return
+	}
+
+	link := &models.CoachLink{
+		CoachID:         currentUser.ID,
+		CoachUsername:   currentUser.Username,
+		AthleteID:       athlete.ID,
+		AthleteUsername: athlete.Username,
+	}
+	if err := ch.coachStore.CreateInvitation(r.Context(), link); err != nil {
+		if errors.Is(err, store.ErrCoachLinkExists) {
+			utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+				"status":  "fail",
+				"message": "You have already invited or are already coaching this athlete.",
+			})
+			return
+		}
+
+		ch.logger.Error("failed to create coach invitation", "coach_id", currentUser.ID, "athlete_id", athlete.ID, "error", err)
+		ch.writeServerError(w, err)
+		return
+	}
+
+	if err := ch.mailer.Send(mailer.Message{
+		To:      athlete.Email,
+		Subject: "You have a coaching invitation",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n%s would like to coach you. Once you accept, they can see and edit your workouts and plan new ones for you. You can end this at any time.",
+			athlete.Username,
+			currentUser.Username,
+		),
+	}); err != nil {
+		// the invitation is still visible under /coaching/coaches
+		ch.logger.Error("failed to send coaching invitation email", "link_id", link.ID, "error", err)
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.CoachLink{
+			"link": link,
+		},
+	}); err != nil {
+		ch.logger.Error("failed to write success response for coach invitation", "link_id", link.ID, "error", err)
+		return
+	}
+	ch.logger.Info("coach invitation created", "link_id", link.ID, "coach_id", currentUser.ID, "athlete_id", athlete.ID)
+}
+
+func (ch *CoachHandler) HandleListAthletes(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	ch.listLinks(w, r, "athletes", currentUser.ID, ch.coachStore.ListAthletes)
+}
+
+func (ch *CoachHandler) HandleListCoaches(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	ch.listLinks(w, r, "coaches", currentUser.ID, ch.coachStore.ListCoaches)
+}
+
+func (ch *CoachHandler) listLinks(w http.ResponseWriter, r *http.Request, key string, userID int64, list func(ctx context.Context, userID int64) ([]*models.CoachLink, error)) {
+	links, err := list(r.Context(), userID)
+	if err != nil {
+		ch.logger.Error("failed to list "+key, "user_id", userID, "error", err)
+		ch.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.CoachLink{
+			key: links,
+		},
+	}); err != nil {
+		ch.logger.Error("failed to write success response for list "+key, "user_id", userID, "error", err)
+	}
+}
+
+// HandleAcceptInvitation is called by the athlete to grant the coach access.
+func (ch *CoachHandler) HandleAcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	linkID, ok := ch.readLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	link, err := ch.coachStore.AcceptInvitation(r.Context(), linkID, currentUser.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The invitation could not be found or has already been accepted.",
+			})
+			return
+		}
+
+		ch.logger.Error("failed to accept coach invitation", "link_id", linkID, "athlete_id", currentUser.ID, "error", err)
+		ch.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.CoachLink{
+			"link": link,
+		},
+	}); err != nil {
+		ch.logger.Error("failed to write success response for accept invitation", "link_id", linkID, "error", err)
+		return
+	}
+	ch.logger.Info("coach invitation accepted", "link_id", linkID, "coach_id", link.CoachID, "athlete_id", link.AthleteID)
+}
+
+// HandleDeleteLink ends a coaching relationship or drops an invitation.
+// Either side may do it, and the coach loses access immediately.
+func (ch *CoachHandler) HandleDeleteLink(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	linkID, ok := ch.readLinkID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := ch.coachStore.DeleteLink(r.Context(), linkID, currentUser.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The coaching link could not be found.",
+			})
+			return
+		}
+
+		ch.logger.Error("failed to delete coach link", "link_id", linkID, "user_id", currentUser.ID, "error", err)
+		ch.writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	ch.logger.Info("coach link removed", "link_id", linkID, "removed_by", currentUser.ID)
+}
+
+func (ch *CoachHandler) readLinkID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	linkID, err := utils.ReadIDParam(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid link ID. Please provide a valid numeric identifier.",
+		})
+		return 0, false
+	}
+	return linkID, true
+}
+
+func (ch *CoachHandler) writeServerError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to process the coaching request due to a server error. Please try again later.",
+	})
+}
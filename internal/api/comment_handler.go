@@ -0,0 +1,236 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultCommentPageSize = 20
+	maxCommentPageSize     = 100
+	maxCommentLength       = 2000
+)
+
+type CommentHandler struct {
+	commentStore store.CommentStore
+	workoutStore store.WorkoutStore
+	logger       *slog.Logger
+}
+
+func NewCommentHandler(commentStore store.CommentStore, workoutStore store.WorkoutStore, logger *slog.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentStore: commentStore,
+		workoutStore: workoutStore,
+		logger:       logger,
+	}
+}
+
+func (ch *CommentHandler) HandleListComments(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, ok := readWorkoutIDParam(w, r, ch.logger)
+	if !ok {
+		return
+	}
+
+	page, limit, err := readPageParams(r, defaultCommentPageSize, maxCommentPageSize)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Invalid query parameters: %s.", err),
+		})
+		return
+	}
+
+	if _, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, ch.workoutStore), ch.logger, workoutID, currentUser, "view"); !ok {
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	comments, err := ch.commentStore.ListComments(r.Context(), workoutID, limit+1, (page-1)*limit)
+	if err != nil {
+		ch.logger.Error("failed to list comments", "workout_id", workoutID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch comments due to a server error. Please try again later.",
+		})
+		return
+	}
+	hasMore := len(comments) > limit
+	if hasMore {
+		comments = comments[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.Comment{
+			"comments": comments,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		ch.logger.Error("failed to write success response for list comments", "workout_id", workoutID, "error", err)
+	}
+}
+
+func (ch *CommentHandler) HandleCreateComment(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, ok := readWorkoutIDParam(w, r, ch.logger)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ch.logger.Warn("failed to decode comment create request", "workout_id", workoutID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	body := strings.TrimSpace(req.Body)
+	if body == "" || utf8.RuneCountInString(body) > maxCommentLength {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Comment body is required and must be at most %d characters.", maxCommentLength),
+		})
+		return
+	}
+
+	if _, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, ch.workoutStore), ch.logger, workoutID, currentUser, "comment on"); !ok {
+		return
+	}
+
+	comment := &models.Comment{
+		WorkoutID: workoutID,
+		UserID:    currentUser.ID,
+		Body:      body,
+	}
+	if err := ch.commentStore.CreateComment(r.Context(), comment); err != nil {
+		ch.logger.Error("failed to execute comment creation in store", "workout_id", workoutID, "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to post the comment due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Comment{
+			"comment": comment,
+		},
+	}); err != nil {
+		ch.logger.Error("failed to write success response for create comment", "comment_id", comment.ID, "error", err)
+		return
+	}
+	ch.logger.Info("comment created successfully", "comment_id", comment.ID, "workout_id", workoutID)
+}
+
+// HandleDeleteComment lets the author remove their comment and the workout
+// owner moderate any comment on their workout.
+func (ch *CommentHandler) HandleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, ok := readWorkoutIDParam(w, r, ch.logger)
+	if !ok {
+		return
+	}
+
+	commentID, err := strconv.ParseInt(chi.URLParam(r, "commentID"), 10, 64)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid comment ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	ownerID, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, ch.workoutStore), ch.logger, workoutID, currentUser, "delete a comment on")
+	if !ok {
+		return
+	}
+
+	comment, err := ch.commentStore.GetCommentByID(r.Context(), commentID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ch.logger.Error("failed to fetch comment for delete", "comment_id", commentID, "error", err)
+		ch.writeDeleteError(w, err)
+		return
+	}
+	if err != nil || comment.WorkoutID != workoutID {
+		utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+			"status":  "fail",
+			"message": "The comment you are trying to delete could not be found.",
+		})
+		return
+	}
+
+	if comment.UserID != currentUser.ID && ownerID != currentUser.ID {
+		ch.logger.Warn("unauthorized attempt to delete a comment", "comment_id", commentID, "user_id", currentUser.ID)
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "You are not authorized to delete this comment.",
+		})
+		return
+	}
+
+	if err := ch.commentStore.DeleteCommentByID(r.Context(), commentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The comment you are trying to delete could not be found.",
+			})
+			return
+		}
+
+		ch.logger.Error("failed to execute comment deletion in store", "comment_id", commentID, "error", err)
+		ch.writeDeleteError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	ch.logger.Info("comment deleted successfully", "comment_id", commentID, "workout_id", workoutID, "deleted_by", currentUser.ID)
+}
+
+func (ch *CommentHandler) writeDeleteError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to delete the comment due to a server error. Please try again later.",
+	})
+}
+
+// readWorkoutIDParam parses the workout id URL parameter and writes the
+// error response itself when it is invalid.
+func readWorkoutIDParam(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (int64, bool) {
+	workoutID, err := utils.ReadIDParam(r)
+	if err != nil {
+		logger.Warn("failed to read or parse workout id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid workout ID. Please provide a valid numeric identifier.",
+		})
+		return 0, false
+	}
+	return workoutID, true
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+const (
+	defaultExercisePageSize = 25
+	maxExercisePageSize     = 100
+)
+
+type ExerciseHandler struct {
+	exerciseStore store.ExerciseStore
+	logger        *slog.Logger
+}
+
+func NewExerciseHandler(exerciseStore store.ExerciseStore, logger *slog.Logger) *ExerciseHandler {
+	return &ExerciseHandler{
+		exerciseStore: exerciseStore,
+		logger:        logger,
+	}
+}
+
+func (eh *ExerciseHandler) HandleSearchExercises(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := store.ExerciseFilter{
+		Query:     q.Get("q"),
+		Muscle:    q.Get("muscle"),
+		Equipment: q.Get("equipment"),
+		Limit:     defaultExercisePageSize,
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxExercisePageSize {
+			eh.logger.Warn("invalid exercise search limit", "limit", limit)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": fmt.Sprintf("Invalid query parameters: limit must be between 1 and %d.", maxExercisePageSize),
+			})
+			return
+		}
+		filter.Limit = n
+	}
+
+	exercises, err := scopedExercises(r, eh.exerciseStore).SearchExercises(r.Context(), filter)
+	if err != nil {
+		eh.logger.Error("failed to search exercises", "query", filter.Query, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to search exercises due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.Exercise{
+			"exercises": exercises,
+		},
+	}); err != nil {
+		eh.logger.Error("failed to write success response for search exercises", "error", err)
+	}
+}
+
+// HandleCreateExercise adds a custom exercise to the organization the
+// request acts in. The shared catalog itself only changes through the seed.
+func (eh *ExerciseHandler) HandleCreateExercise(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	membership := middleware.GetMembership(r)
+	if membership == nil || !membership.CanManageExercises() {
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "Only owners, admins and coaches of an organization can add exercises. Select the organization with the " + middleware.OrganizationHeader + " header.",
+		})
+		return
+	}
+
+	exercise := &models.Exercise{}
+	if err := json.NewDecoder(r.Body).Decode(exercise); err != nil {
+		eh.logger.Warn("failed to decode exercise create request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+	exercise.Name = strings.Join(strings.Fields(exercise.Name), " ")
+	if exercise.Name == "" || len(exercise.Name) > 255 {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Exercise name is required and must be at most 255 characters.",
+		})
+		return
+	}
+
+	if err := scopedExercises(r, eh.exerciseStore).CreateExercise(r.Context(), exercise); err != nil {
+		if errors.Is(err, store.ErrDuplicateExercise) {
+			utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+				"status":  "fail",
+				"message": "This organization already has an exercise with that name.",
+			})
+			return
+		}
+
+		eh.logger.Error("failed to create exercise", "organization_id", membership.Organization.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to create the exercise due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Exercise{
+			"exercise": exercise,
+		},
+	}); err != nil {
+		eh.logger.Error("failed to write success response for create exercise", "exercise_id", exercise.ID, "error", err)
+		return
+	}
+	eh.logger.Info("exercise created", "exercise_id", exercise.ID, "organization_id", membership.Organization.ID)
+}
+
+// exerciseRef points at the exercise fields of a workout or template entry.
+type exerciseRef struct {
+	id   **int64
+	name *string
+}
+
+// resolveExerciseRefs links entries to the exercise catalog. Explicit ids
+// must exist; free-text names are matched against names and aliases and
+// kept as free text when nothing matches.
+func resolveExerciseRefs(ctx context.Context, exerciseStore store.ExerciseStore, refs []exerciseRef) error {
+	names := []string{}
+	for _, ref := range refs {
+		if *ref.id == nil {
+			names = append(names, *ref.name)
+			continue
+		}
+
+		exercise, err := exerciseStore.GetExerciseByID(ctx, **ref.id)
+		if err != nil {
+			return err
+		}
+		if *ref.name == "" {
+			*ref.name = exercise.Name
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	ids, err := exerciseStore.ResolveExerciseIDs(ctx, names)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if *ref.id != nil {
+			continue
+		}
+		if id, ok := ids[models.NormalizeExerciseName(*ref.name)]; ok {
+			*ref.id = &id
+		}
+	}
+	return nil
+}
+
+func writeResolveExercisesError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Warn("entry references an unknown exercise", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "One of the entries references an exercise_id that does not exist.",
+		})
+		return
+	}
+
+	logger.Error("failed to resolve entry exercises", "error", err)
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to save due to a server error. Please try again later.",
+	})
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultFollowPageSize = 20
+	maxFollowPageSize     = 100
+	defaultFeedPageSize   = 20
+	maxFeedPageSize       = 50
+)
+
+type FollowHandler struct {
+	followStore  store.FollowStore
+	userStore    store.UserStore
+	workoutStore store.WorkoutStore
+	logger       *slog.Logger
+}
+
+func NewFollowHandler(followStore store.FollowStore, userStore store.UserStore, workoutStore store.WorkoutStore, logger *slog.Logger) *FollowHandler {
+	return &FollowHandler{
+		followStore:  followStore,
+		userStore:    userStore,
+		workoutStore: workoutStore,
+		logger:       logger,
+	}
+}
+
+func (fh *FollowHandler) HandleFollow(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	profile, ok := fh.readUser(w, r)
+	if !ok {
+		return
+	}
+
+	if profile.ID == currentUser.ID {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "You can't follow yourself.",
+		})
+		return
+	}
+
+	if err := fh.followStore.Follow(r.Context(), currentUser.ID, profile.ID); err != nil {
+		fh.logger.Error("failed to execute follow in store", "follower_id", currentUser.ID, "followee_id", profile.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to follow the user due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	fh.logger.Info("user followed", "follower_id", currentUser.ID, "followee_id", profile.ID)
+}
+
+func (fh *FollowHandler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	profile, ok := fh.readUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := fh.followStore.Unfollow(r.Context(), currentUser.ID, profile.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "You are not following this user.",
+			})
+			return
+		}
+
+		fh.logger.Error("failed to execute unfollow in store", "follower_id", currentUser.ID, "followee_id", profile.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to unfollow the user due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	fh.logger.Info("user unfollowed", "follower_id", currentUser.ID, "followee_id", profile.ID)
+}
+
+func (fh *FollowHandler) HandleListFollowers(w http.ResponseWriter, r *http.Request) {
+	fh.listFollows(w, r, "followers", fh.followStore.ListFollowers)
+}
+
+func (fh *FollowHandler) HandleListFollowing(w http.ResponseWriter, r *http.Request) {
+	fh.listFollows(w, r, "following", fh.followStore.ListFollowing)
+}
+
+func (fh *FollowHandler) listFollows(w http.ResponseWriter, r *http.Request, key string, list func(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error)) {
+	profile, ok := fh.readUser(w, r)
+	if !ok {
+		return
+	}
+
+	page, limit, err := readPageParams(r, defaultFollowPageSize, maxFollowPageSize)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Invalid query parameters: %s.", err),
+		})
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	profiles, err := list(r.Context(), profile.ID, limit+1, (page-1)*limit)
+	if err != nil {
+		fh.logger.Error("failed to list "+key, "user_id", profile.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch " + key + " due to a server error. Please try again later.",
+		})
+		return
+	}
+	hasMore := len(profiles) > limit
+	if hasMore {
+		profiles = profiles[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.PublicProfile{
+			key: profiles,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		fh.logger.Error("failed to write success response for list "+key, "user_id", profile.ID, "error", err)
+	}
+}
+
+// HandleGetFeed returns the newest workouts of the users the current user
+// follows.
+func (fh *FollowHandler) HandleGetFeed(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	q := r.URL.Query()
+
+	limit := defaultFeedPageSize
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxFeedPageSize {
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": fmt.Sprintf("Invalid query parameters: limit must be between 1 and %d.", maxFeedPageSize),
+			})
+			return
+		}
+		limit = n
+	}
+
+	workouts, nextCursor, err := scopedWorkouts(r, fh.workoutStore).ListFeed(r.Context(), currentUser.ID, q.Get("cursor"), limit)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			fh.logger.Warn("invalid feed cursor", "user_id", currentUser.ID, "error", err)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "Invalid cursor. Please use the cursor returned by the previous page.",
+			})
+			return
+		}
+
+		fh.logger.Error("failed to list feed", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the feed due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.Workout{
+			"workouts": workouts,
+		},
+		"metadata": map[string]any{
+			"limit":       limit,
+			"next_cursor": nextCursor,
+			"has_more":    nextCursor != "",
+		},
+	}); err != nil {
+		fh.logger.Error("failed to write success response for feed", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+// readUser loads the user named by the username URL parameter and writes
+// the error response itself when that fails.
+func (fh *FollowHandler) readUser(w http.ResponseWriter, r *http.Request) (*models.PublicProfile, bool) {
+	username := chi.URLParam(r, "username")
+
+	profile, err := fh.userStore.GetPublicProfile(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			fh.logger.Warn("user not found for username", "username", username)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested user could not be found.",
+			})
+			return nil, false
+		}
+
+		fh.logger.Error("failed to fetch user by username", "username", username, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the user due to a server error. Please try again later.",
+		})
+		return nil, false
+	}
+
+	return profile, true
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+var slugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+type OrganizationHandler struct {
+	organizationStore store.OrganizationStore
+	userStore         store.UserStore
+	logger            *slog.Logger
+}
+
+func NewOrganizationHandler(organizationStore store.OrganizationStore, userStore store.UserStore, logger *slog.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationStore: organizationStore,
+		userStore:         userStore,
+		logger:            logger,
+	}
+}
+
+// HandleCreateOrganization creates an organization owned by the current
+// user.
+func (oh *OrganizationHandler) HandleCreateOrganization(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	var req struct {
+		Name string `json:"name"`
+		Slug string `json:"slug"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		oh.writeBadRequest(w, "Invalid request payload. Please ensure all fields are correctly provided.")
+		return
+	}
+
+	organization := &models.Organization{
+		Name: strings.TrimSpace(req.Name),
+		Slug: req.Slug,
+	}
+	if organization.Name == "" || len(organization.Name) > 255 {
+		oh.writeBadRequest(w, "Organization name is required and must be at most 255 characters.")
+		return
+	}
+	if len(organization.Slug) > 50 || !slugRegex.MatchString(organization.Slug) {
+		oh.writeBadRequest(w, "Slug must be at most 50 lowercase letters, digits and single dashes.")
+		return
+	}
+
+	if err := oh.organizationStore.CreateOrganization(r.Context(), organization, currentUser.ID); err != nil {
+		if errors.Is(err, store.ErrDuplicateSlug) {
+			utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+				"status":  "fail",
+				"message": "An organization with this slug already exists.",
+			})
+			return
+		}
+
+		oh.logger.Error("failed to create organization", "slug", organization.Slug, "user_id", currentUser.ID, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Membership{
+			"membership": {Organization: *organization, Role: models.OrganizationRoleOwner},
+		},
+	}); err != nil {
+		oh.logger.Error("failed to write success response for create organization", "organization_id", organization.ID, "error", err)
+		return
+	}
+	oh.logger.Info("organization created", "organization_id", organization.ID, "owner_id", currentUser.ID)
+}
+
+// HandleListOrganizations lists the organizations the current user belongs
+// to, with their role in each.
+func (oh *OrganizationHandler) HandleListOrganizations(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	memberships, err := oh.organizationStore.ListMemberships(r.Context(), currentUser.ID)
+	if err != nil {
+		oh.logger.Error("failed to list organizations", "user_id", currentUser.ID, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.Membership{
+			"organizations": memberships,
+		},
+	}); err != nil {
+		oh.logger.Error("failed to write success response for list organizations", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (oh *OrganizationHandler) HandleListMembers(w http.ResponseWriter, r *http.Request) {
+	membership, ok := oh.readMembership(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := oh.organizationStore.ListMembers(r.Context(), membership.Organization.ID)
+	if err != nil {
+		oh.logger.Error("failed to list organization members", "organization_id", membership.Organization.ID, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.OrganizationMember{
+			"members": members,
+		},
+	}); err != nil {
+		oh.logger.Error("failed to write success response for list members", "organization_id", membership.Organization.ID, "error", err)
+	}
+}
+
+// HandleAddMember lets owners and admins add an existing user by username.
+// Ownership can't be handed out this way.
+func (oh *OrganizationHandler) HandleAddMember(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	membership, ok := oh.readMembership(w, r)
+	if !ok {
+		return
+	}
+	if !oh.requireManager(w, membership) {
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		oh.writeBadRequest(w, "Invalid request payload. Please ensure all fields are correctly provided.")
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.OrganizationRoleMember
+	}
+	if !models.IsValidOrganizationRole(req.Role) || req.Role == models.OrganizationRoleOwner {
+		oh.writeBadRequest(w, "Invalid role. Must be one of admin, coach or member.")
+		return
+	}
+
+	user, err := oh.userStore.GetUserByUsername(r.Context(), req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested user could not be found.",
+			})
+			return
+		}
+
+		oh.logger.Error("failed to fetch user by username", "username", req.Username, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: membership.Organization.ID,
+		UserID:         user.ID,
+		Username:       user.Username,
+		Role:           req.Role,
+	}
+	if err := oh.organizationStore.AddMember(r.Context(), member); err != nil {
+		if errors.Is(err, store.ErrAlreadyMember) {
+			utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+				"status":  "fail",
+				"message": "The user is already a member of this organization.",
+			})
+			return
+		}
+
+		oh.logger.Error("failed to add organization member", "organization_id", member.OrganizationID, "user_id", user.ID, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.OrganizationMember{
+			"member": member,
+		},
+	}); err != nil {
+		oh.logger.Error("failed to write success response for add member", "organization_id", member.OrganizationID, "error", err)
+		return
+	}
+	oh.logger.Info("organization member added", "organization_id", member.OrganizationID, "user_id", user.ID, "role", member.Role)
+}
+
+// HandleRemoveMember lets owners and admins remove members and anyone but
+// the owner leave. Their workouts stay with the organization.
+func (oh *OrganizationHandler) HandleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	membership, ok := oh.readMembership(w, r)
+	if !ok {
+		return
+	}
+
+	username := chi.URLParam(r, "username")
+	if username != currentUser.Username && !oh.requireManager(w, membership) {
+		return
+	}
+
+	user, err := oh.userStore.GetUserByUsername(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			oh.writeMemberNotFound(w)
+			return
+		}
+
+		oh.logger.Error("failed to fetch user by username", "username", username, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	if err := oh.organizationStore.RemoveMember(r.Context(), membership.Organization.ID, user.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			oh.writeMemberNotFound(w)
+			return
+		}
+
+		oh.logger.Error("failed to remove organization member", "organization_id", membership.Organization.ID, "user_id", user.ID, "error", err)
+		oh.writeServerError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	oh.logger.Info("organization member removed", "organization_id", membership.Organization.ID, "user_id", user.ID, "removed_by", currentUser.ID)
+}
+
+// readMembership loads the current user's membership of the organization
+// named by the slug URL parameter. Non-members get a 404.
+func (oh *OrganizationHandler) readMembership(w http.ResponseWriter, r *http.Request) (*models.Membership, bool) {
+	currentUser := middleware.GetUser(r)
+	slug := chi.URLParam(r, "slug")
+
+	membership, err := oh.organizationStore.GetMembership(r.Context(), slug, currentUser.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested organization could not be found.",
+			})
+			return nil, false
+		}
+
+		oh.logger.Error("failed to fetch organization membership", "slug", slug, "user_id", currentUser.ID, "error", err)
+		oh.writeServerError(w, err)
+		return nil, false
+	}
+
+	return membership, true
+}
+
+func (oh *OrganizationHandler) requireManager(w http.ResponseWriter, membership *models.Membership) bool {
+	if !membership.CanManageMembers() {
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "Only owners and admins can manage the members of an organization.",
+		})
+		return false
+	}
+	return true
+}
+
+func (oh *OrganizationHandler) writeMemberNotFound(w http.ResponseWriter) {
+	utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+		"status":  "fail",
+		"message": "No removable member with this username was found. Owners can't be removed.",
+	})
+}
+
+func (oh *OrganizationHandler) writeBadRequest(w http.ResponseWriter, message string) {
+	utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+		"status":  "fail",
+		"message": message,
+	})
+}
+
+func (oh *OrganizationHandler) writeServerError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to process the organization request due to a server error. Please try again later.",
+	})
+}
+
+// scopedWorkouts restricts the store to the organization the request acts
+// in. Handlers touching workouts go through it so nothing is read or written
+// across organizations. The exceptions are programs, which are personal and
+// use the unscoped store, and admins, who delete through the admin store.
+func scopedWorkouts(r *http.Request, workoutStore store.WorkoutStore) store.WorkoutStore {
+	return workoutStore.ForOrganization(middleware.OrganizationID(r))
+}
+
+func scopedTemplates(r *http.Request, templateStore store.TemplateStore) store.TemplateStore {
+	return templateStore.ForOrganization(middleware.OrganizationID(r))
+}
+
+func scopedExercises(r *http.Request, exerciseStore store.ExerciseStore) store.ExerciseStore {
+	return exerciseStore.ForOrganization(middleware.OrganizationID(r))
+}
+
+func scopedRecords(r *http.Request, recordStore store.RecordStore) store.RecordStore {
+	return recordStore.ForOrganization(middleware.OrganizationID(r))
+}
+
+func scopedAnalytics(r *http.Request, analyticsStore analytics.Store) analytics.Store {
+	return analyticsStore.ForOrganization(middleware.OrganizationID(r))
+}
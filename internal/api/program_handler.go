@@ -0,0 +1,676 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/programs"
+	"github.com/agkmw/workout-service/internal/progression"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+const defaultDeloadPercentage = 60
+
+type programRequest struct {
+	Name             string                  `json:"name"`
+	Description      string                  `json:"description"`
+	Weeks            int                     `json:"weeks"`
+	DeloadWeeks      []int                   `json:"deload_weeks"`
+	DeloadPercentage *float64                `json:"deload_percentage"`
+	IsPublic         bool                    `json:"is_public"`
+	Sessions         []models.ProgramSession `json:"sessions"`
+}
+
+// todaySession describes where an enrollment stands on the user's current
+// day. Session is nil on rest days and outside the program.
+type todaySession struct {
+	Date     string                  `json:"date"`
+	Week     int                     `json:"week"`
+	Day      int                     `json:"day"`
+	InRange  bool                    `json:"in_range"`
+	Deload   bool                    `json:"deload"`
+	Session  *models.ProgramSession  `json:"session"`
+	Template *models.WorkoutTemplate `json:"template,omitempty"`
+}
+
+// ProgramHandler serves training programs. Programs are personal: their
+// templates and the workouts started from them always live in the personal
+// space, whichever organization the request names.
+type ProgramHandler struct {
+	programStore  store.ProgramStore
+	templateStore store.TemplateStore
+	workoutStore  store.WorkoutStore
+	recordStore   store.RecordStore
+	auditor       *audit.Auditor
+	logger        *slog.Logger
+}
+
+func NewProgramHandler(programStore store.ProgramStore, templateStore store.TemplateStore, workoutStore store.WorkoutStore, recordStore store.RecordStore, auditor *audit.Auditor, logger *slog.Logger) *ProgramHandler {
+	return &ProgramHandler{
+		programStore:  programStore,
+		templateStore: templateStore,
+		workoutStore:  workoutStore,
+		recordStore:   recordStore,
+		auditor:       auditor,
+		logger:        logger,
+	}
+}
+
+func (ph *ProgramHandler) HandleCreateProgram(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	req := &programRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		ph.logger.Warn("failed to decode program create request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	if err := validateProgramRequest(req); err != nil {
+		ph.logger.Warn("invalid program request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid program: " + err.Error() + ".",
+		})
+		return
+	}
+
+	// sessions may only schedule the creator's own templates
+	checked := map[int64]bool{}
+	for _, session := range req.Sessions {
+		if checked[session.TemplateID] {
+			continue
+		}
+		if _, err := ph.templateStore.GetTemplateByID(r.Context(), session.TemplateID, currentUser.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ph.logger.Warn("program references unknown template", "template_id", session.TemplateID)
+				utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+					"status":  "fail",
+					"message": fmt.Sprintf("Invalid program: template %d could not be found.", session.TemplateID),
+				})
+				return
+			}
+
+			ph.logger.Error("failed to fetch template for program", "template_id", session.TemplateID, "error", err)
+			utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+				"status":  "error",
+				"message": "Failed to create the program due to a server error. Please try again later.",
+			})
+			return
+		}
+		checked[session.TemplateID] = true
+	}
+
+	program := &models.Program{
+		UserID:           currentUser.ID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Weeks:            req.Weeks,
+		DeloadWeeks:      req.DeloadWeeks,
+		DeloadPercentage: defaultDeloadPercentage,
+		IsPublic:         req.IsPublic,
+		Sessions:         req.Sessions,
+	}
+	if req.DeloadPercentage != nil {
+		program.DeloadPercentage = *req.DeloadPercentage
+	}
+	if program.DeloadWeeks == nil {
+		program.DeloadWeeks = []int{}
+	}
+	if program.Sessions == nil {
+		program.Sessions = []models.ProgramSession{}
+	}
+
+	if err := ph.programStore.CreateProgram(r.Context(), program); err != nil {
+		ph.logger.Error("failed to execute program creation in store", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to create the program due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Program{
+			"program": program,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for create program", "program_id", program.ID, "error", err)
+		return
+	}
+	ph.logger.Info("program created successfully", "program_id", program.ID)
+}
+
+func (ph *ProgramHandler) HandleListPrograms(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	list, err := ph.programStore.ListPrograms(r.Context(), currentUser.ID)
+	if err != nil {
+		ph.logger.Error("failed to list programs", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch programs due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.Program{
+			"programs": list,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for list programs", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (ph *ProgramHandler) HandleGetProgramByID(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	programID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ph.logger.Warn("failed to read or parse program id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid program ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	program, ok := ph.readProgram(r.Context(), w, programID, currentUser)
+	if !ok {
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Program{
+			"program": program,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for get program", "program_id", program.ID, "error", err)
+	}
+}
+
+func (ph *ProgramHandler) HandleDeleteProgramByID(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	programID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ph.logger.Warn("failed to read or parse program id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid program ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	if err := ph.programStore.DeleteProgramByID(r.Context(), programID, currentUser.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ph.logger.Warn("attempted to delete a program that does not exist", "program_id", programID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The program you are trying to delete could not be found.",
+			})
+			return
+		}
+
+		ph.logger.Error("failed to execute program deletion in store", "program_id", programID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to delete the program due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	ph.logger.Info("program deleted successfully", "program_id", programID)
+}
+
+// HandleEnroll enrolls the current user in a program. start_date defaults to
+// today in the user's timezone.
+func (ph *ProgramHandler) HandleEnroll(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	programID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ph.logger.Warn("failed to read or parse program id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid program ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	program, ok := ph.readProgram(r.Context(), w, programID, currentUser)
+	if !ok {
+		return
+	}
+
+	var enrollRequest struct {
+		StartDate string `json:"start_date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&enrollRequest); err != nil {
+		ph.logger.Warn("failed to decode enrollment request", "program_id", program.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	startDate := userToday(currentUser)
+	if enrollRequest.StartDate != "" {
+		startDate, err = time.Parse(time.DateOnly, enrollRequest.StartDate)
+		if err != nil {
+			ph.logger.Warn("invalid enrollment start date", "start_date", enrollRequest.StartDate)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "Invalid start_date. Must be a date (YYYY-MM-DD).",
+			})
+			return
+		}
+	}
+
+	enrollment := &models.ProgramEnrollment{
+		UserID:    currentUser.ID,
+		ProgramID: program.ID,
+		StartDate: startDate,
+	}
+	if err := ph.programStore.CreateEnrollment(r.Context(), enrollment); err != nil {
+		ph.logger.Error("failed to execute enrollment creation in store", "program_id", program.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to enroll in the program due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.ProgramEnrollment{
+			"enrollment": enrollment,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for enroll", "enrollment_id", enrollment.ID, "error", err)
+		return
+	}
+	ph.logger.Info("enrolled in program successfully", "program_id", program.ID, "enrollment_id", enrollment.ID)
+}
+
+func (ph *ProgramHandler) HandleListEnrollments(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	enrollments, err := ph.programStore.ListEnrollments(r.Context(), currentUser.ID)
+	if err != nil {
+		ph.logger.Error("failed to list enrollments", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch enrollments due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.ProgramEnrollment{
+			"enrollments": enrollments,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for list enrollments", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (ph *ProgramHandler) HandleCancelEnrollment(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	enrollmentID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ph.logger.Warn("failed to read or parse enrollment id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid enrollment ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	if err := ph.programStore.CancelEnrollment(r.Context(), enrollmentID, currentUser.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ph.logger.Warn("attempted to cancel an enrollment that does not exist or is not active", "enrollment_id", enrollmentID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "No active enrollment with this ID could be found.",
+			})
+			return
+		}
+
+		ph.logger.Error("failed to execute enrollment cancellation in store", "enrollment_id", enrollmentID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to cancel the enrollment due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	ph.logger.Info("enrollment cancelled successfully", "enrollment_id", enrollmentID)
+}
+
+// HandleGetToday returns the session scheduled for the user's current day.
+func (ph *ProgramHandler) HandleGetToday(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	enrollment, program, ok := ph.readEnrollment(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	today := ph.today(program, enrollment, currentUser)
+	if today.Session != nil {
+		template, err := ph.templateStore.GetTemplateByID(r.Context(), today.Session.TemplateID, program.UserID)
+		if err != nil {
+			ph.logger.Error("failed to fetch template for program session", "session_id", today.Session.ID, "error", err)
+			utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+				"status":  "error",
+				"message": "Failed to fetch today's session due to a server error. Please try again later.",
+			})
+			return
+		}
+		today.Template = template
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*todaySession{
+			"today": today,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for get today", "enrollment_id", enrollment.ID, "error", err)
+	}
+}
+
+// HandleStartToday creates a planned workout for today's session. Weights
+// progress like a template start, then follow the session's 1RM percentage
+// and the program's deload scaling. The workout stays linked to the session
+// so completing it counts towards adherence.
+func (ph *ProgramHandler) HandleStartToday(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	enrollment, program, ok := ph.readEnrollment(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	if enrollment.Status != models.EnrollmentActive {
+		ph.logger.Warn("attempted to start a session of an inactive enrollment", "enrollment_id", enrollment.ID)
+		utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+			"status":  "fail",
+			"message": "The enrollment has been cancelled.",
+		})
+		return
+	}
+
+	today := ph.today(program, enrollment, currentUser)
+	if today.Session == nil {
+		ph.logger.Warn("no session scheduled for today", "enrollment_id", enrollment.ID, "week", today.Week, "day", today.Day)
+		utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+			"status":  "fail",
+			"message": "No session is scheduled for today.",
+		})
+		return
+	}
+	session := today.Session
+
+	template, err := ph.templateStore.GetTemplateByID(r.Context(), session.TemplateID, program.UserID)
+	if err != nil {
+		ph.logger.Error("failed to fetch template for program session", "session_id", session.ID, "error", err)
+		ph.writeStartError(w, err)
+		return
+	}
+
+	last, err := ph.workoutStore.GetLastCompletedFromTemplate(r.Context(), currentUser.ID, template.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ph.logger.Error("failed to fetch last workout for template", "template_id", template.ID, "error", err)
+		ph.writeStartError(w, err)
+		return
+	}
+
+	workout := progression.Instantiate(template, last, true)
+	workout.UserID = currentUser.ID
+	workout.EnrollmentID = &enrollment.ID
+	workout.ProgramSessionID = &session.ID
+
+	oneRepMaxes := map[int]float64{}
+	if session.IntensityPercentage != nil {
+		for i, entry := range workout.Entries {
+			best, err := ph.recordStore.GetBestEstimated1RM(r.Context(), currentUser.ID, models.NormalizeExerciseName(entry.ExerciseName), entry.ExerciseID)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				ph.logger.Error("failed to fetch estimated 1RM", "exercise", entry.ExerciseName, "error", err)
+				ph.writeStartError(w, err)
+				return
+			}
+			oneRepMaxes[i] = best
+		}
+	}
+	programs.Prescribe(workout, program, session, oneRepMaxes)
+
+	if err := ph.workoutStore.CreateWorkout(r.Context(), workout); err != nil {
+		ph.logger.Error("failed to create workout for program session", "session_id", session.ID, "error", err)
+		ph.writeStartError(w, err)
+		return
+	}
+	ph.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &currentUser.ID,
+		Action:     audit.ActionWorkoutCreated,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workout.ID,
+	}, nil, workout)
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Workout{
+			"workout": workout,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for start session", "session_id", session.ID, "error", err)
+		return
+	}
+	ph.logger.Info("workout started from program session", "session_id", session.ID, "workout_id", workout.ID)
+}
+
+func (ph *ProgramHandler) HandleGetAdherence(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	enrollment, program, ok := ph.readEnrollment(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	workouts, err := ph.workoutStore.ListEnrollmentWorkouts(r.Context(), enrollment.ID)
+	if err != nil {
+		ph.logger.Error("failed to list enrollment workouts", "enrollment_id", enrollment.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to compute adherence due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	adherence := programs.ComputeAdherence(program, enrollment.StartDate, userToday(currentUser), workouts)
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*programs.Adherence{
+			"adherence": adherence,
+		},
+	}); err != nil {
+		ph.logger.Error("failed to write success response for adherence", "enrollment_id", enrollment.ID, "error", err)
+	}
+}
+
+func (ph *ProgramHandler) today(program *models.Program, enrollment *models.ProgramEnrollment, user *models.User) *todaySession {
+	now := userToday(user)
+	week, day, inRange := programs.Position(program, enrollment.StartDate, now)
+
+	today := &todaySession{
+		Date:    now.Format(time.DateOnly),
+		Week:    week,
+		Day:     day,
+		InRange: inRange,
+	}
+	if inRange {
+		today.Deload = program.IsDeloadWeek(week)
+		today.Session = program.Session(week, day)
+	}
+	return today
+}
+
+// readProgram loads a program visible to the user and writes the error
+// response itself when that fails.
+func (ph *ProgramHandler) readProgram(ctx context.Context, w http.ResponseWriter, programID int64, user *models.User) (*models.Program, bool) {
+	program, err := ph.programStore.GetProgramByID(ctx, programID, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ph.logger.Warn("program not found for given id", "program_id", programID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested program could not be found.",
+			})
+			return nil, false
+		}
+
+		ph.logger.Error("failed to fetch program by id", "program_id", programID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the program due to a server error. Please try again later.",
+		})
+		return nil, false
+	}
+
+	return program, true
+}
+
+// readEnrollment loads the user's enrollment named by the id URL parameter
+// together with its program.
+func (ph *ProgramHandler) readEnrollment(w http.ResponseWriter, r *http.Request, user *models.User) (*models.ProgramEnrollment, *models.Program, bool) {
+	enrollmentID, err := utils.ReadIDParam(r)
+	if err != nil {
+		ph.logger.Warn("failed to read or parse enrollment id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid enrollment ID. Please provide a valid numeric identifier.",
+		})
+		return nil, nil, false
+	}
+
+	enrollment, err := ph.programStore.GetEnrollmentByID(r.Context(), enrollmentID, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ph.logger.Warn("enrollment not found for given id", "enrollment_id", enrollmentID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested enrollment could not be found.",
+			})
+			return nil, nil, false
+		}
+
+		ph.logger.Error("failed to fetch enrollment by id", "enrollment_id", enrollmentID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the enrollment due to a server error. Please try again later.",
+		})
+		return nil, nil, false
+	}
+
+	program, ok := ph.readProgram(r.Context(), w, enrollment.ProgramID, user)
+	if !ok {
+		return nil, nil, false
+	}
+	return enrollment, program, true
+}
+
+func (ph *ProgramHandler) writeStartError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to start the session due to a server error. Please try again later.",
+	})
+}
+
+// userToday returns the current date in the user's timezone.
+func userToday(user *models.User) time.Time {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil || user.Timezone == "" {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func validateProgramRequest(req *programRequest) error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 255 {
+		return errors.New("name can't be greater than 255 characters")
+	}
+	if req.Weeks < 1 || req.Weeks > 104 {
+		return errors.New("weeks must be between 1 and 104")
+	}
+	if req.DeloadPercentage != nil && (*req.DeloadPercentage <= 0 || *req.DeloadPercentage > 100) {
+		return errors.New("deload_percentage must be above 0 and at most 100")
+	}
+
+	deloads := map[int]bool{}
+	for _, week := range req.DeloadWeeks {
+		if week < 1 || week > req.Weeks {
+			return errors.New("deload_weeks must fall within the program's weeks")
+		}
+		if deloads[week] {
+			return errors.New("deload_weeks can't contain duplicates")
+		}
+		deloads[week] = true
+	}
+
+	type slot struct{ week, day int }
+	scheduled := map[slot]bool{}
+	for _, session := range req.Sessions {
+		if session.Week < 1 || session.Week > req.Weeks {
+			return errors.New("every session's week must fall within the program's weeks")
+		}
+		if session.Day < 1 || session.Day > programs.DaysPerWeek {
+			return errors.New("every session's day must be between 1 and 7")
+		}
+		if session.TemplateID == 0 {
+			return errors.New("every session needs a template_id")
+		}
+		if p := session.IntensityPercentage; p != nil && (*p <= 0 || *p > 100) {
+			return errors.New("intensity_percentage must be above 0 and at most 100")
+		}
+		key := slot{session.Week, session.Day}
+		if scheduled[key] {
+			return errors.New("only one session can be scheduled per day")
+		}
+		scheduled[key] = true
+	}
+
+	return nil
+}
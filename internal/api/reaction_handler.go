@@ -0,0 +1,148 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type ReactionHandler struct {
+	reactionStore store.ReactionStore
+	workoutStore  store.WorkoutStore
+	logger        *slog.Logger
+}
+
+func NewReactionHandler(reactionStore store.ReactionStore, workoutStore store.WorkoutStore, logger *slog.Logger) *ReactionHandler {
+	return &ReactionHandler{
+		reactionStore: reactionStore,
+		workoutStore:  workoutStore,
+		logger:        logger,
+	}
+}
+
+func (rh *ReactionHandler) HandleListReactions(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, ok := readWorkoutIDParam(w, r, rh.logger)
+	if !ok {
+		return
+	}
+
+	if _, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, rh.workoutStore), rh.logger, workoutID, currentUser, "view"); !ok {
+		return
+	}
+
+	reactions, err := rh.reactionStore.ListReactions(r.Context(), workoutID)
+	if err != nil {
+		rh.logger.Error("failed to list reactions", "workout_id", workoutID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch reactions due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.Reaction{
+			"reactions": reactions,
+		},
+	}); err != nil {
+		rh.logger.Error("failed to write success response for list reactions", "workout_id", workoutID, "error", err)
+	}
+}
+
+// HandleAddReaction is a PUT so that reacting twice is harmless.
+func (rh *ReactionHandler) HandleAddReaction(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, kind, ok := rh.readParams(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, rh.workoutStore), rh.logger, workoutID, currentUser, "react to"); !ok {
+		return
+	}
+
+	reaction := &models.Reaction{
+		WorkoutID: workoutID,
+		UserID:    currentUser.ID,
+		Username:  currentUser.Username,
+		Kind:      kind,
+	}
+	if err := rh.reactionStore.AddReaction(r.Context(), reaction); err != nil {
+		rh.logger.Error("failed to execute reaction creation in store", "workout_id", workoutID, "user_id", currentUser.ID, "kind", kind, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to add the reaction due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Reaction{
+			"reaction": reaction,
+		},
+	}); err != nil {
+		rh.logger.Error("failed to write success response for add reaction", "workout_id", workoutID, "error", err)
+	}
+}
+
+func (rh *ReactionHandler) HandleRemoveReaction(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	workoutID, kind, ok := rh.readParams(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := readWorkoutOwner(r.Context(), w, scopedWorkouts(r, rh.workoutStore), rh.logger, workoutID, currentUser, "remove a reaction from"); !ok {
+		return
+	}
+
+	if err := rh.reactionStore.RemoveReaction(r.Context(), workoutID, currentUser.ID, kind); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "You have not reacted to this workout with " + kind + ".",
+			})
+			return
+		}
+
+		rh.logger.Error("failed to execute reaction deletion in store", "workout_id", workoutID, "user_id", currentUser.ID, "kind", kind, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to remove the reaction due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rh *ReactionHandler) readParams(w http.ResponseWriter, r *http.Request) (int64, string, bool) {
+	workoutID, ok := readWorkoutIDParam(w, r, rh.logger)
+	if !ok {
+		return 0, "", false
+	}
+
+	kind := chi.URLParam(r, "kind")
+	if !models.IsValidReaction(kind) {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid reaction. Must be one of like, fire, strong or clap.",
+		})
+		return 0, "", false
+	}
+
+	return workoutID, kind, true
+}
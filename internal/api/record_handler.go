@@ -0,0 +1,96 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type RecordHandler struct {
+	recordStore   store.RecordStore
+	exerciseStore store.ExerciseStore
+	logger        *slog.Logger
+}
+
+func NewRecordHandler(recordStore store.RecordStore, exerciseStore store.ExerciseStore, logger *slog.Logger) *RecordHandler {
+	return &RecordHandler{
+		recordStore:   recordStore,
+		exerciseStore: exerciseStore,
+		logger:        logger,
+	}
+}
+
+func (rh *RecordHandler) HandleGetRecords(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	records, err := scopedRecords(r, rh.recordStore).GetCurrentRecords(r.Context(), currentUser.ID)
+	if err != nil {
+		rh.logger.Error("failed to fetch personal records", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch personal records due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.PersonalRecord{
+			"records": records,
+		},
+	}); err != nil {
+		rh.logger.Error("failed to write success response for get records", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (rh *RecordHandler) HandleGetExerciseRecords(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	exercise := models.NormalizeExerciseName(chi.URLParam(r, "exercise"))
+	if exercise == "" {
+		rh.logger.Warn("missing exercise parameter")
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid exercise. Please provide an exercise name.",
+		})
+		return
+	}
+
+	// records are keyed by the canonical name, so accept aliases too
+	var exerciseID *int64
+	ids, err := scopedExercises(r, rh.exerciseStore).ResolveExerciseIDs(r.Context(), []string{exercise})
+	if err != nil {
+		rh.logger.Error("failed to resolve exercise", "exercise", exercise, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch personal records due to a server error. Please try again later.",
+		})
+		return
+	}
+	if id, ok := ids[exercise]; ok {
+		exerciseID = &id
+	}
+
+	records, err := scopedRecords(r, rh.recordStore).GetRecordHistory(r.Context(), currentUser.ID, exercise, exerciseID)
+	if err != nil {
+		rh.logger.Error("failed to fetch personal record history", "user_id", currentUser.ID, "exercise", exercise, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch personal records due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.PersonalRecord{
+			"records": records,
+		},
+	}); err != nil {
+		rh.logger.Error("failed to write success response for get exercise records", "user_id", currentUser.ID, "error", err)
+	}
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
+)
+
+type StatsHandler struct {
+	analytics     analytics.Store
+	exerciseStore store.ExerciseStore
+	logger        *slog.Logger
+}
+
+func NewStatsHandler(analyticsStore analytics.Store, exerciseStore store.ExerciseStore, logger *slog.Logger) *StatsHandler {
+	return &StatsHandler{
+		analytics:     analyticsStore,
+		exerciseStore: exerciseStore,
+		logger:        logger,
+	}
+}
+
+func (sh *StatsHandler) HandleGetVolume(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	q := r.URL.Query()
+
+	query := analytics.VolumeQuery{
+		UserID:  currentUser.ID,
+		Period:  q.Get("period"),
+		GroupBy: q.Get("group_by"),
+	}
+	if query.Period == "" {
+		query.Period = analytics.PeriodWeek
+	}
+	if !analytics.IsValidPeriod(query.Period) {
+		sh.writeBadRequest(w, "Invalid period. Must be one of day, week or month.")
+		return
+	}
+	switch query.GroupBy {
+	case analytics.GroupByNone, analytics.GroupByExercise, analytics.GroupByMuscle:
+	default:
+		sh.writeBadRequest(w, "Invalid group_by. Must be either exercise or muscle.")
+		return
+	}
+
+	timezone, ok := sh.readTimezone(w, r, currentUser)
+	if !ok {
+		return
+	}
+	query.Timezone = timezone
+
+	if from := q.Get("from"); from != "" {
+		t, _, err := parseDateParam(from)
+		if err != nil {
+			sh.writeBadRequest(w, "Invalid from. Must be a date (YYYY-MM-DD) or RFC 3339 timestamp.")
+			return
+		}
+		query.From = &t
+	}
+	if to := q.Get("to"); to != "" {
+		t, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			sh.writeBadRequest(w, "Invalid to. Must be a date (YYYY-MM-DD) or RFC 3339 timestamp.")
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		query.To = &t
+	}
+
+	buckets, err := scopedAnalytics(r, sh.analytics).Volume(r.Context(), query)
+	if err != nil {
+		sh.logger.Error("failed to compute training volume", "user_id", currentUser.ID, "error", err)
+		sh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]any{
+			"period":   query.Period,
+			"group_by": query.GroupBy,
+			"timezone": query.Timezone,
+			"volume":   buckets,
+		},
+	}); err != nil {
+		sh.logger.Error("failed to write success response for volume stats", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (sh *StatsHandler) HandleGetSummary(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	timezone, ok := sh.readTimezone(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	summary, err := scopedAnalytics(r, sh.analytics).Summary(r.Context(), currentUser.ID, timezone)
+	if err != nil {
+		sh.logger.Error("failed to compute training summary", "user_id", currentUser.ID, "error", err)
+		sh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*analytics.Summary{
+			"summary": summary,
+		},
+	}); err != nil {
+		sh.logger.Error("failed to write success response for summary stats", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (sh *StatsHandler) HandleGetExerciseProgression(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	exercise := models.NormalizeExerciseName(chi.URLParam(r, "name"))
+	if exercise == "" {
+		sh.writeBadRequest(w, "Invalid exercise. Please provide an exercise name.")
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = analytics.PeriodWeek
+	}
+	if !analytics.IsValidPeriod(period) {
+		sh.writeBadRequest(w, "Invalid period. Must be one of day, week or month.")
+		return
+	}
+
+	timezone, ok := sh.readTimezone(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	var exerciseID *int64
+	ids, err := scopedExercises(r, sh.exerciseStore).ResolveExerciseIDs(r.Context(), []string{exercise})
+	if err != nil {
+		sh.logger.Error("failed to resolve exercise", "exercise", exercise, "error", err)
+		sh.writeServerError(w, err)
+		return
+	}
+	if id, ok := ids[exercise]; ok {
+		exerciseID = &id
+	}
+
+	points, err := scopedAnalytics(r, sh.analytics).ExerciseProgression(r.Context(), currentUser.ID, exercise, exerciseID, period, timezone)
+	if err != nil {
+		sh.logger.Error("failed to compute exercise progression", "user_id", currentUser.ID, "exercise", exercise, "error", err)
+		sh.writeServerError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]any{
+			"exercise":    exercise,
+			"period":      period,
+			"timezone":    timezone,
+			"progression": points,
+		},
+	}); err != nil {
+		sh.logger.Error("failed to write success response for exercise progression", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+// readTimezone uses the tz query parameter if given and falls back to the
+// timezone stored on the user's profile.
+func (sh *StatsHandler) readTimezone(w http.ResponseWriter, r *http.Request, user *models.User) (string, bool) {
+	timezone := r.URL.Query().Get("tz")
+	if timezone == "" {
+		timezone = user.Timezone
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	if !isValidTimezone(timezone) {
+		sh.logger.Warn("invalid timezone for stats", "timezone", timezone)
+		sh.writeBadRequest(w, "Invalid timezone. Please provide an IANA timezone such as Europe/Berlin.")
+		return "", false
+	}
+	return timezone, true
+}
+
+func (sh *StatsHandler) writeBadRequest(w http.ResponseWriter, message string) {
+	utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+		"status":  "fail",
+		"message": message,
+	})
+}
+
+func (sh *StatsHandler) writeServerError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to compute statistics due to a server error. Please try again later.",
+	})
+}
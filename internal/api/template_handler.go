@@ -0,0 +1,387 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/progression"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+type templateRequest struct {
+	Name        string                        `json:"name"`
+	Description string                        `json:"description"`
+	Entries     []models.WorkoutTemplateEntry `json:"entries"`
+}
+
+type TemplateHandler struct {
+	templateStore store.TemplateStore
+	workoutStore  store.WorkoutStore
+	exerciseStore store.ExerciseStore
+	auditor       *audit.Auditor
+	logger        *slog.Logger
+}
+
+func NewTemplateHandler(templateStore store.TemplateStore, workoutStore store.WorkoutStore, exerciseStore store.ExerciseStore, auditor *audit.Auditor, logger *slog.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateStore: templateStore,
+		workoutStore:  workoutStore,
+		exerciseStore: exerciseStore,
+		auditor:       auditor,
+		logger:        logger,
+	}
+}
+
+func (th *TemplateHandler) HandleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	req := &templateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		th.logger.Warn("failed to decode template create request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	if err := validateTemplateRequest(req); err != nil {
+		th.logger.Warn("invalid template request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid template: " + err.Error() + ".",
+		})
+		return
+	}
+
+	if err := th.resolveExercises(r, req.Entries); err != nil {
+		writeResolveExercisesError(w, th.logger, err)
+		return
+	}
+
+	template := &models.WorkoutTemplate{
+		UserID:      currentUser.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Entries:     req.Entries,
+	}
+	if err := scopedTemplates(r, th.templateStore).CreateTemplate(r.Context(), template); err != nil {
+		th.logger.Error("failed to execute template creation in store", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to create the template due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.WorkoutTemplate{
+			"template": template,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for create template", "template_id", template.ID, "error", err)
+		return
+	}
+	th.logger.Info("template created successfully", "template_id", template.ID)
+}
+
+func (th *TemplateHandler) HandleListTemplates(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	templates, err := scopedTemplates(r, th.templateStore).ListTemplates(r.Context(), currentUser.ID)
+	if err != nil {
+		th.logger.Error("failed to list templates", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch templates due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.WorkoutTemplate{
+			"templates": templates,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for list templates", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+func (th *TemplateHandler) HandleGetTemplateByID(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	template, ok := th.readTemplate(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.WorkoutTemplate{
+			"template": template,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for get template", "template_id", template.ID, "error", err)
+	}
+}
+
+func (th *TemplateHandler) HandleUpdateTemplateByID(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	template, ok := th.readTemplate(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	var updateTemplateRequest struct {
+		Name        *string                       `json:"name"`
+		Description *string                       `json:"description"`
+		Entries     []models.WorkoutTemplateEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&updateTemplateRequest); err != nil {
+		th.logger.Warn("failed to decode template update request", "template_id", template.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	req := &templateRequest{
+		Name:        template.Name,
+		Description: template.Description,
+		Entries:     template.Entries,
+	}
+	if updateTemplateRequest.Name != nil {
+		req.Name = *updateTemplateRequest.Name
+	}
+	if updateTemplateRequest.Description != nil {
+		req.Description = *updateTemplateRequest.Description
+	}
+	if updateTemplateRequest.Entries != nil {
+		req.Entries = updateTemplateRequest.Entries
+	}
+
+	if err := validateTemplateRequest(req); err != nil {
+		th.logger.Warn("invalid template request", "template_id", template.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid template: " + err.Error() + ".",
+		})
+		return
+	}
+
+	if updateTemplateRequest.Entries != nil {
+		if err := th.resolveExercises(r, req.Entries); err != nil {
+			writeResolveExercisesError(w, th.logger, err)
+			return
+		}
+	}
+
+	template.Name = req.Name
+	template.Description = req.Description
+	template.Entries = req.Entries
+
+	if err := scopedTemplates(r, th.templateStore).UpdateTemplate(r.Context(), template); err != nil {
+		th.logger.Error("failed to execute template update in store", "template_id", template.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to update the template due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.WorkoutTemplate{
+			"template": template,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for update template", "template_id", template.ID, "error", err)
+		return
+	}
+	th.logger.Info("template updated successfully", "template_id", template.ID)
+}
+
+func (th *TemplateHandler) HandleDeleteTemplateByID(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	templateID, err := utils.ReadIDParam(r)
+	if err != nil {
+		th.logger.Warn("failed to read or parse template id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid template ID. Please provide a valid numeric identifier.",
+		})
+		return
+	}
+
+	if err := scopedTemplates(r, th.templateStore).DeleteTemplateByID(r.Context(), templateID, currentUser.ID); err != nil {
+		if errors.Is(err, store.ErrTemplateInUse) {
+			th.logger.Warn("attempted to delete a template used by a program", "template_id", templateID)
+			utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+				"status":  "fail",
+				"message": "The template is scheduled by a program. Remove it from the program first.",
+			})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			th.logger.Warn("attempted to delete a template that does not exist", "template_id", templateID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The template you are trying to delete could not be found.",
+			})
+			return
+		}
+
+		th.logger.Error("failed to execute template deletion in store", "template_id", templateID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to delete the template due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	th.logger.Info("template deleted successfully", "template_id", templateID)
+}
+
+// HandleStartTemplate creates a planned workout from the template. Weights
+// progress from the last completed workout of the same template unless the
+// request sets progression=false.
+func (th *TemplateHandler) HandleStartTemplate(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	template, ok := th.readTemplate(w, r, currentUser)
+	if !ok {
+		return
+	}
+
+	progressive := r.URL.Query().Get("progression") != "false"
+
+	last, err := scopedWorkouts(r, th.workoutStore).GetLastCompletedFromTemplate(r.Context(), currentUser.ID, template.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		th.logger.Error("failed to fetch last workout for template", "template_id", template.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to start the workout due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	workout := progression.Instantiate(template, last, progressive)
+	if err := scopedWorkouts(r, th.workoutStore).CreateWorkout(r.Context(), workout); err != nil {
+		th.logger.Error("failed to create workout from template", "template_id", template.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to start the workout due to a server error. Please try again later.",
+		})
+		return
+	}
+	th.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &currentUser.ID,
+		Action:     audit.ActionWorkoutCreated,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workout.ID,
+	}, nil, workout)
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Workout{
+			"workout": workout,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for start template", "template_id", template.ID, "error", err)
+		return
+	}
+	th.logger.Info("workout started from template", "template_id", template.ID, "workout_id", workout.ID)
+}
+
+// readTemplate loads the template named by the id URL parameter and writes
+// the error response itself when that fails.
+func (th *TemplateHandler) readTemplate(w http.ResponseWriter, r *http.Request, user *models.User) (*models.WorkoutTemplate, bool) {
+	templateID, err := utils.ReadIDParam(r)
+	if err != nil {
+		th.logger.Warn("failed to read or parse template id parameter", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid template ID. Please provide a valid numeric identifier.",
+		})
+		return nil, false
+	}
+
+	template, err := scopedTemplates(r, th.templateStore).GetTemplateByID(r.Context(), templateID, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			th.logger.Warn("template not found for given id", "template_id", templateID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested template could not be found.",
+			})
+			return nil, false
+		}
+
+		th.logger.Error("failed to fetch template by id", "template_id", templateID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the template due to a server error. Please try again later.",
+		})
+		return nil, false
+	}
+
+	return template, true
+}
+
+func (th *TemplateHandler) resolveExercises(r *http.Request, entries []models.WorkoutTemplateEntry) error {
+	refs := make([]exerciseRef, 0, len(entries))
+	for i := range entries {
+		refs = append(refs, exerciseRef{id: &entries[i].ExerciseID, name: &entries[i].ExerciseName})
+	}
+	return resolveExerciseRefs(r.Context(), scopedExercises(r, th.exerciseStore), refs)
+}
+
+func validateTemplateRequest(req *templateRequest) error {
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 255 {
+		return errors.New("name can't be greater than 255 characters")
+	}
+
+	for _, entry := range req.Entries {
+		if entry.ExerciseName == "" && entry.ExerciseID == nil {
+			return errors.New("every entry needs an exercise_name or exercise_id")
+		}
+		if entry.TargetSets < 1 || entry.TargetSets > 100 {
+			return errors.New("target_sets must be between 1 and 100")
+		}
+		if (entry.TargetReps == nil) == (entry.TargetDurationSeconds == nil) {
+			return errors.New("every entry needs either target_reps or target_duration_seconds")
+		}
+		if entry.TargetReps != nil && *entry.TargetReps < 1 {
+			return errors.New("target_reps must be positive")
+		}
+		if entry.TargetDurationSeconds != nil && *entry.TargetDurationSeconds < 1 {
+			return errors.New("target_duration_seconds must be positive")
+		}
+		if entry.TargetWeight != nil && *entry.TargetWeight < 0 {
+			return errors.New("target_weight can't be negative")
+		}
+		if entry.WeightIncrement != nil && *entry.WeightIncrement < 0 {
+			return errors.New("weight_increment can't be negative")
+		}
+	}
+
+	return nil
+}
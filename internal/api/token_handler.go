@@ -1,11 +1,17 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/mailer"
+	"github.com/agkmw/workout-service/internal/middleware"
 	"github.com/agkmw/workout-service/internal/store"
 	"github.com/agkmw/workout-service/internal/tokens"
 	"github.com/agkmw/workout-service/internal/utils"
@@ -19,13 +25,17 @@ type createTokenRequest struct {
 type TokenHandler struct {
 	tokenStore store.TokenStore
 	userStore  store.UserStore
+	mailer     mailer.Mailer
+	auditor    *audit.Auditor
 	logger     *slog.Logger
 }
 
-func NewTokenHandler(tokenStore store.TokenStore, userStore store.UserStore, logger *slog.Logger) *TokenHandler {
+func NewTokenHandler(tokenStore store.TokenStore, userStore store.UserStore, mailer mailer.Mailer, auditor *audit.Auditor, logger *slog.Logger) *TokenHandler {
 	return &TokenHandler{
 		tokenStore: tokenStore,
 		userStore:  userStore,
+		mailer:     mailer,
+		auditor:    auditor,
 		logger:     logger,
 	}
 }
@@ -42,9 +52,17 @@ func (th *TokenHandler) HandleCreateToken(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := th.userStore.GetUserByUsername(req.Username)
+	user, err := th.userStore.GetUserByUsername(r.Context(), req.Username)
 	if err != nil || user == nil {
 		th.logger.Warn("failed to fetch user by username", "error", err)
+		// there's no user to point at, so keep the name that was tried
+		th.auditor.Record(r, &audit.Event{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			Changes: map[string]audit.Change{
+				"username": {To: req.Username},
+			},
+		})
 		utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
 			"status":  "fail",
 			"message": "User not found or incorrect credentials provided.",
@@ -64,6 +82,11 @@ func (th *TokenHandler) HandleCreateToken(w http.ResponseWriter, r *http.Request
 
 	if !passwordDoMatch {
 		th.logger.Warn("invalid credentials provided")
+		th.auditor.Record(r, &audit.Event{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   &user.ID,
+		})
 		utils.WriteJSON(w, http.StatusUnauthorized, utils.Envelope{
 			"status":  "fail",
 			"message": "Invalid username or password. Please try again.",
@@ -71,22 +94,301 @@ func (th *TokenHandler) HandleCreateToken(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	token, err := th.tokenStore.CreateNewToken(user.ID, 24*time.Hour, tokens.ScopeAuth)
+	if user.Disabled {
+		th.logger.Warn("login attempt on a disabled account", "user_id", user.ID)
+		th.auditor.Record(r, &audit.Event{
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetID:   &user.ID,
+		})
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "Your account has been disabled.",
+		})
+		return
+	}
+
+	pair, err := th.tokenStore.CreateTokenPair(r.Context(), user.ID, r.UserAgent(), utils.ClientIP(r))
 	if err != nil {
 		th.logger.Error("failed to create authentication token", "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to create an authentication token due to a server error.",
 		})
 		return
 	}
+	th.auditor.Record(r, &audit.Event{
+		ActorID:    &user.ID,
+		Action:     audit.ActionTokenCreated,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	})
 
 	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
 		"status": "success",
 		"data": map[string]tokens.Token{
-			"auth_token": *token,
+			"auth_token":    *pair.Access,
+			"refresh_token": *pair.Refresh,
 		},
 	}); err != nil {
 		th.logger.Error("failed to write token creation response", "error", err)
 	}
 }
+
+// HandleRefreshToken rotates a refresh token, handing out a fresh access
+// token and the refresh token to use next time.
+func (th *TokenHandler) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var refreshRequest struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&refreshRequest); err != nil || refreshRequest.RefreshToken == "" {
+		th.logger.Warn("failed to decode token refresh request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please provide a refresh_token.",
+		})
+		return
+	}
+
+	pair, err := th.tokenStore.RotateRefreshToken(r.Context(), refreshRequest.RefreshToken, r.UserAgent(), utils.ClientIP(r))
+	if err != nil {
+		if errors.Is(err, store.ErrRefreshTokenReused) {
+			th.logger.Warn("refresh token reused, token family revoked", "ip", utils.ClientIP(r))
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.Envelope{
+				"status":  "fail",
+				"message": "Refresh token was already used. All sessions started from it have been revoked, please log in again.",
+			})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			th.logger.Warn("invalid or expired refresh token")
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.Envelope{
+				"status":  "fail",
+				"message": "Token expired, or invalid token.",
+			})
+			return
+		}
+
+		th.logger.Error("failed to rotate refresh token", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to refresh the token due to a server error.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+		"status": "success",
+		"data": map[string]tokens.Token{
+			"auth_token":    *pair.Access,
+			"refresh_token": *pair.Refresh,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write token refresh response", "error", err)
+	}
+}
+
+// HandleRequestPasswordReset mails a password reset token to the address.
+// The response is the same whether or not an account uses the address, so
+// the endpoint can't be used to find out who is registered.
+func (th *TokenHandler) HandleRequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var resetRequest struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&resetRequest); err != nil || resetRequest.Email == "" {
+		th.logger.Warn("failed to decode password reset request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please provide an email.",
+		})
+		return
+	}
+
+	accepted := utils.Envelope{
+		"status":  "success",
+		"message": "If an account with that email exists, a password reset link has been sent to it.",
+	}
+
+	user, err := th.userStore.GetUserByEmail(r.Context(), resetRequest.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			th.logger.Warn("password reset requested for unknown email")
+			utils.WriteJSON(w, http.StatusAccepted, accepted)
+			return
+		}
+
+		th.logger.Error("failed to fetch user by email", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to request a password reset due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	// only the latest reset token stays valid
+	if err := th.tokenStore.DeleteAllTokensForUser(r.Context(), user.ID, tokens.ScopePasswordReset); err != nil {
+		th.logger.Error("failed to revoke previous password reset tokens", "user_id", user.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to request a password reset due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	token, err := th.tokenStore.CreateNewToken(r.Context(), user.ID, tokens.ScopePasswordReset)
+	if err != nil {
+		th.logger.Error("failed to create password reset token", "user_id", user.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to request a password reset due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := th.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse this token to choose a new password: %s\n\nIt expires at %s. If you didn't ask for a reset, you can ignore this email.",
+			user.Username,
+			token.PlainText,
+			token.Expiry.UTC().Format(time.RFC1123),
+		),
+	}); err != nil {
+		th.logger.Error("failed to send password reset email", "user_id", user.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to send the password reset email. Please try again later.",
+		})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
+	th.logger.Info("password reset token sent", "user_id", user.ID)
+}
+
+// HandleResendActivation mails a new activation token. Like password resets
+// it answers the same way for unknown addresses.
+func (th *TokenHandler) HandleResendActivation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var activationRequest struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&activationRequest); err != nil || activationRequest.Email == "" {
+		th.logger.Warn("failed to decode activation request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please provide an email.",
+		})
+		return
+	}
+
+	accepted := utils.Envelope{
+		"status":  "success",
+		"message": "If an account with that email still needs activation, an activation link has been sent to it.",
+	}
+
+	user, err := th.userStore.GetUserByEmail(r.Context(), activationRequest.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			th.logger.Warn("activation requested for unknown email")
+			utils.WriteJSON(w, http.StatusAccepted, accepted)
+			return
+		}
+
+		th.logger.Error("failed to fetch user by email", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to send the activation email due to a server error. Please try again later.",
+		})
+		return
+	}
+	if user.Activated {
+		utils.WriteJSON(w, http.StatusAccepted, accepted)
+		return
+	}
+
+	if err := sendActivationEmail(r.Context(), th.tokenStore, th.mailer, user); err != nil {
+		th.logger.Error("failed to send activation email", "user_id", user.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to send the activation email due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, accepted)
+	th.logger.Info("activation token sent", "user_id", user.ID)
+}
+
+// HandleRevokeToken logs out by revoking the token the request was made
+// with.
+func (th *TokenHandler) HandleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	if err := th.tokenStore.DeleteToken(r.Context(), tokens.ScopeAuth, middleware.GetToken(r)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// revoked concurrently, the outcome is the same
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		th.logger.Error("failed to revoke authentication token", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to log out due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	th.logger.Info("authentication token revoked", "user_id", currentUser.ID)
+}
+
+// HandleRevokeAllTokens logs the user out of every session, including the
+// current one.
+func (th *TokenHandler) HandleRevokeAllTokens(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	for _, scope := range []string{tokens.ScopeAuth, tokens.ScopeRefresh} {
+		if err := th.tokenStore.DeleteAllTokensForUser(r.Context(), currentUser.ID, scope); err != nil {
+			th.logger.Error("failed to revoke all authentication tokens", "user_id", currentUser.ID, "scope", scope, "error", err)
+			utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+				"status":  "error",
+				"message": "Failed to log out of all sessions due to a server error. Please try again later.",
+			})
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	th.logger.Info("all authentication tokens revoked", "user_id", currentUser.ID)
+}
+
+func (th *TokenHandler) HandleListTokens(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	sessions, err := th.tokenStore.ListSessions(r.Context(), currentUser.ID, middleware.GetToken(r))
+	if err != nil {
+		th.logger.Error("failed to list authentication tokens", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch sessions due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]tokens.Session{
+			"sessions": sessions,
+		},
+	}); err != nil {
+		th.logger.Error("failed to write success response for list tokens", "user_id", currentUser.ID, "error", err)
+	}
+}
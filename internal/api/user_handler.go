@@ -1,15 +1,26 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/mailer"
+	"github.com/agkmw/workout-service/internal/middleware"
 	"github.com/agkmw/workout-service/internal/models"
 	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
 	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type registerUserRequest struct {
@@ -17,20 +28,37 @@ type registerUserRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Bio      string `json:"bio"`
+	Timezone string `json:"timezone"`
 }
 
 type UserHandler struct {
-	userStore store.UserStore
-	logger    *slog.Logger
+	userStore    store.UserStore
+	tokenStore   store.TokenStore
+	workoutStore store.WorkoutStore
+	mailer       mailer.Mailer
+	auditor      *audit.Auditor
+	passwordCost int
+	logger       *slog.Logger
 }
 
-func NewUserHandler(userStore store.UserStore, logger *slog.Logger) *UserHandler {
+func NewUserHandler(userStore store.UserStore, tokenStore store.TokenStore, workoutStore store.WorkoutStore, mailer mailer.Mailer, auditor *audit.Auditor, passwordCost int, logger *slog.Logger) *UserHandler {
 	return &UserHandler{
-		userStore: userStore,
-		logger:    logger,
+		userStore:    userStore,
+		tokenStore:   tokenStore,
+		workoutStore: workoutStore,
+		mailer:       mailer,
+		auditor:      auditor,
+		passwordCost: passwordCost,
+		logger:       logger,
 	}
 }
 
+const (
+	defaultUserSearchPageSize = 20
+	maxUserSearchPageSize     = 50
+	recentPublicWorkouts      = 5
+)
+
 func (uh *UserHandler) HandleRegisterUser(w http.ResponseWriter, r *http.Request) {
 	req := &registerUserRequest{}
 	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
@@ -54,27 +82,44 @@ func (uh *UserHandler) HandleRegisterUser(w http.ResponseWriter, r *http.Request
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
+		Timezone: req.Timezone,
 	}
 	if req.Bio != "" {
 		user.Bio = req.Bio
 	}
-	if err := user.PasswordHash.Set(req.Password); err != nil {
+	if err := user.PasswordHash.Set(req.Password, uh.passwordCost); err != nil {
 		uh.logger.Error("failed to hash password", "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "An unexpected error occurred.",
 		})
 		return
 	}
 
-	if err := uh.userStore.CreateUser(user); err != nil {
+	if err := uh.userStore.CreateUser(r.Context(), user); err != nil {
+		if uh.writeDuplicateUser(w, err) {
+			return
+		}
+
 		uh.logger.Error("failed to execute user registration in store", "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to register the user due to a server error. Please try again later.",
 		})
 		return
 	}
+	uh.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &user.ID,
+		Action:     audit.ActionUserRegistered,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	}, nil, user)
+
+	// the account exists either way; a lost email can be resent through
+	// POST /tokens/activation
+	if err := sendActivationEmail(r.Context(), uh.tokenStore, uh.mailer, user); err != nil {
+		uh.logger.Error("failed to send activation email", "user_id", user.ID, "error", err)
+	}
 
 	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
 		"status": "success",
@@ -89,34 +134,595 @@ func (uh *UserHandler) HandleRegisterUser(w http.ResponseWriter, r *http.Request
 	uh.logger.Info("user created successfully", "user_id", user.ID)
 }
 
+// HandleSearchUsers looks users up by username. Results are public
+// profiles only.
+func (uh *UserHandler) HandleSearchUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Please provide a search query with q.",
+		})
+		return
+	}
+
+	page, limit, err := readPageParams(r, defaultUserSearchPageSize, maxUserSearchPageSize)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Invalid query parameters: %s.", err),
+		})
+		return
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	profiles, err := uh.userStore.SearchUsersByUsername(r.Context(), query, limit+1, (page-1)*limit)
+	if err != nil {
+		uh.logger.Error("failed to search users", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to search users due to a server error. Please try again later.",
+		})
+		return
+	}
+	hasMore := len(profiles) > limit
+	if hasMore {
+		profiles = profiles[:limit]
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]models.PublicProfile{
+			"users": profiles,
+		},
+		"metadata": map[string]any{
+			"page":     page,
+			"limit":    limit,
+			"has_more": hasMore,
+		},
+	}); err != nil {
+		uh.logger.Error("failed to write success response for search users", "error", err)
+	}
+}
+
+// HandleGetPublicProfile returns a user's public profile with their most
+// recent public workouts.
+func (uh *UserHandler) HandleGetPublicProfile(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	profile, err := uh.userStore.GetPublicProfile(r.Context(), username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			uh.logger.Warn("profile not found for username", "username", username)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested user could not be found.",
+			})
+			return
+		}
+
+		uh.logger.Error("failed to fetch public profile", "username", username, "error", err)
+		uh.writeProfileError(w, err)
+		return
+	}
+
+	workouts, _, err := uh.workoutStore.ListWorkouts(r.Context(), store.WorkoutFilter{
+		UserID:     profile.ID,
+		Status:     models.StatusCompleted,
+		Visibility: models.VisibilityPublic,
+		Sort:       store.WorkoutSortNewest,
+		Limit:      recentPublicWorkouts,
+	})
+	if err != nil {
+		uh.logger.Error("failed to list recent public workouts", "user_id", profile.ID, "error", err)
+		uh.writeProfileError(w, err)
+		return
+	}
+	profile.RecentWorkouts = workouts
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.PublicProfile{
+			"user": profile,
+		},
+	}); err != nil {
+		uh.logger.Error("failed to write success response for get public profile", "user_id", profile.ID, "error", err)
+	}
+}
+
+// readPageParams parses the 1-based page and the limit of an offset
+// paginated list.
+func readPageParams(r *http.Request, defaultLimit, maxLimit int) (page, limit int, err error) {
+	q := r.URL.Query()
+
+	page, limit = 1, defaultLimit
+	if l := q.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 || n > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		limit = n
+	}
+	if p := q.Get("page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return 0, 0, errors.New("page must be a positive number")
+		}
+		page = n
+	}
+
+	return page, limit, nil
+}
+
+func (uh *UserHandler) writeProfileError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to fetch the profile due to a server error. Please try again later.",
+	})
+}
+
+func (uh *UserHandler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.User{
+			"user": currentUser,
+		},
+	}); err != nil {
+		uh.logger.Error("failed to write success response for get current user", "user_id", currentUser.ID, "error", err)
+	}
+}
+
+// HandleUpdateCurrentUser applies a partial profile update. Changing the
+// email deactivates the account until the new address is confirmed.
+func (uh *UserHandler) HandleUpdateCurrentUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	var updateUserRequest struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+		Bio      *string `json:"bio"`
+		Timezone *string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&updateUserRequest); err != nil {
+		uh.logger.Warn("failed to decode user update request", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	user := *currentUser
+	if updateUserRequest.Username != nil {
+		user.Username = *updateUserRequest.Username
+	}
+	emailChanged := false
+	if updateUserRequest.Email != nil && !strings.EqualFold(*updateUserRequest.Email, user.Email) {
+		user.Email = *updateUserRequest.Email
+		user.Activated = false
+		emailChanged = true
+	}
+	if updateUserRequest.Bio != nil {
+		user.Bio = *updateUserRequest.Bio
+	}
+	if updateUserRequest.Timezone != nil {
+		user.Timezone = *updateUserRequest.Timezone
+	}
+
+	if err := validateProfile(&user); err != nil {
+		uh.logger.Warn("invalid user update request", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid user: " + err.Error() + ".",
+		})
+		return
+	}
+
+	if err := uh.userStore.UpdateUser(r.Context(), &user); err != nil {
+		if uh.writeDuplicateUser(w, err) {
+			return
+		}
+
+		uh.logger.Error("failed to execute user update in store", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to update the profile due to a server error. Please try again later.",
+		})
+		return
+	}
+	uh.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &user.ID,
+		Action:     audit.ActionUserUpdated,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	}, currentUser, &user)
+
+	if emailChanged {
+		if err := sendActivationEmail(r.Context(), uh.tokenStore, uh.mailer, &user); err != nil {
+			uh.logger.Error("failed to send activation email", "user_id", user.ID, "error", err)
+		}
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.User{
+			"user": &user,
+		},
+	}); err != nil {
+		uh.logger.Error("failed to write success response for update current user", "user_id", user.ID, "error", err)
+		return
+	}
+	uh.logger.Info("user updated successfully", "user_id", user.ID)
+}
+
+// HandleChangePassword sets a new password after checking the current one.
+// Every other session of the user is logged out.
+func (uh *UserHandler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	currentUser := middleware.GetUser(r)
+
+	var changePasswordRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&changePasswordRequest); err != nil {
+		uh.logger.Warn("failed to decode password change request", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	if err := validatePassword(changePasswordRequest.NewPassword); err != nil {
+		uh.logger.Warn("invalid new password", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid password: " + err.Error() + ".",
+		})
+		return
+	}
+
+	match, err := currentUser.PasswordHash.Match(changePasswordRequest.CurrentPassword)
+	if err != nil {
+		uh.logger.Error("error comparing password hash", "user_id", currentUser.ID, "error", err)
+		uh.writeChangePasswordError(w, err)
+		return
+	}
+	if !match {
+		uh.logger.Warn("incorrect current password on password change", "user_id", currentUser.ID)
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "The current password is incorrect.",
+		})
+		return
+	}
+
+	user := *currentUser
+	if err := user.PasswordHash.Set(changePasswordRequest.NewPassword, uh.passwordCost); err != nil {
+		uh.logger.Error("failed to hash password", "user_id", user.ID, "error", err)
+		uh.writeChangePasswordError(w, err)
+		return
+	}
+	// every other session is logged out with the old password
+	if err := uh.userStore.ChangePassword(r.Context(), &user, middleware.GetToken(r)); err != nil {
+		uh.logger.Error("failed to change password in store", "user_id", user.ID, "error", err)
+		uh.writeChangePasswordError(w, err)
+		return
+	}
+	uh.auditor.Record(r, &audit.Event{
+		ActorID:    &user.ID,
+		Action:     audit.ActionPasswordChanged,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+	uh.logger.Info("password changed successfully", "user_id", user.ID)
+}
+
+func (uh *UserHandler) writeChangePasswordError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to change the password due to a server error. Please try again later.",
+	})
+}
+
+// HandleDeleteCurrentUser deletes the account along with all of its data.
+func (uh *UserHandler) HandleDeleteCurrentUser(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+
+	if err := uh.userStore.DeleteUser(r.Context(), currentUser.ID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		uh.logger.Error("failed to execute user deletion in store", "user_id", currentUser.ID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to delete the account due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	uh.logger.Info("user deleted successfully", "user_id", currentUser.ID)
+}
+
+// writeDuplicateUser answers with 409 Conflict if err is a taken username
+// or email and reports whether it did.
+func (uh *UserHandler) writeDuplicateUser(w http.ResponseWriter, err error) bool {
+	var message string
+	switch {
+	case errors.Is(err, store.ErrDuplicateUsername):
+		message = "The username is already taken."
+	case errors.Is(err, store.ErrDuplicateEmail):
+		message = "The email is already in use by another account."
+	default:
+		return false
+	}
+
+	uh.logger.Warn("duplicate user", "error", err)
+	utils.WriteJSON(w, http.StatusConflict, utils.Envelope{
+		"status":  "fail",
+		"message": message,
+	})
+	return true
+}
+
+// HandleActivateUser consumes an activation token and marks its user as
+// activated.
+func (uh *UserHandler) HandleActivateUser(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var activateRequest struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&activateRequest); err != nil || activateRequest.Token == "" {
+		uh.logger.Warn("failed to decode user activation request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please provide the activation token.",
+		})
+		return
+	}
+
+	user, err := uh.userStore.GetUserByToken(r.Context(), tokens.ScopeActivation, activateRequest.Token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			uh.logger.Warn("invalid or expired activation token")
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "The activation token is invalid or has expired.",
+			})
+			return
+		}
+
+		uh.logger.Error("failed to fetch user by activation token", "error", err)
+		uh.writeActivateError(w, err)
+		return
+	}
+
+	user.Activated = true
+	if err := uh.userStore.UpdateUser(r.Context(), user); err != nil {
+		uh.logger.Error("failed to activate user in store", "user_id", user.ID, "error", err)
+		uh.writeActivateError(w, err)
+		return
+	}
+
+	if err := uh.tokenStore.DeleteAllTokensForUser(r.Context(), user.ID, tokens.ScopeActivation); err != nil {
+		uh.logger.Error("failed to revoke activation tokens", "user_id", user.ID, "error", err)
+		uh.writeActivateError(w, err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.User{
+			"user": user,
+		},
+	}); err != nil {
+		uh.logger.Error("failed to write user activation response", "user_id", user.ID, "error", err)
+		return
+	}
+	uh.logger.Info("user activated successfully", "user_id", user.ID)
+}
+
+func (uh *UserHandler) writeActivateError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to activate the account due to a server error. Please try again later.",
+	})
+}
+
+// sendActivationEmail replaces any outstanding activation token of the user
+// with a new one and mails it.
+func sendActivationEmail(ctx context.Context, tokenStore store.TokenStore, m mailer.Mailer, user *models.User) error {
+	if err := tokenStore.DeleteAllTokensForUser(ctx, user.ID, tokens.ScopeActivation); err != nil {
+		return err
+	}
+
+	token, err := tokenStore.CreateNewToken(ctx, user.ID, tokens.ScopeActivation)
+	if err != nil {
+		return err
+	}
+
+	return m.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Activate your account",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nThanks for signing up. Activate your account with this token: %s\n\nIt expires at %s.",
+			user.Username,
+			token.PlainText,
+			token.Expiry.UTC().Format(time.RFC1123),
+		),
+	})
+}
+
+// HandleResetPassword sets a new password using a password reset token. The
+// token works once, and every session of the user is logged out afterwards.
+func (uh *UserHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	var resetPasswordRequest struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&resetPasswordRequest); err != nil {
+		uh.logger.Warn("failed to decode password reset request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	if err := validatePassword(resetPasswordRequest.Password); err != nil {
+		uh.logger.Warn("invalid password in reset request", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid password: " + err.Error() + ".",
+		})
+		return
+	}
+
+	invalidToken := utils.Envelope{
+		"status":  "fail",
+		"message": "The password reset token is invalid or has expired.",
+	}
+
+	user, err := uh.userStore.GetUserByToken(r.Context(), tokens.ScopePasswordReset, resetPasswordRequest.Token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			uh.logger.Warn("invalid or expired password reset token")
+			utils.WriteJSON(w, http.StatusBadRequest, invalidToken)
+			return
+		}
+
+		uh.logger.Error("failed to fetch user by password reset token", "error", err)
+		uh.writeResetPasswordError(w, err)
+		return
+	}
+
+	if err := user.PasswordHash.Set(resetPasswordRequest.Password, uh.passwordCost); err != nil {
+		uh.logger.Error("failed to hash password", "user_id", user.ID, "error", err)
+		uh.writeResetPasswordError(w, err)
+		return
+	}
+
+	// the token, the new hash and the revoked sessions are stored in one
+	// transaction, so a failed reset leaves the token usable and a
+	// successful one can't keep stolen sessions alive. Concurrent resets with
+	// the same token lose the race to consume it.
+	if err := uh.userStore.ResetPassword(r.Context(), user, resetPasswordRequest.Token); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			uh.logger.Warn("password reset token already used", "user_id", user.ID)
+			utils.WriteJSON(w, http.StatusBadRequest, invalidToken)
+			return
+		}
+
+		uh.logger.Error("failed to reset password in store", "user_id", user.ID, "error", err)
+		uh.writeResetPasswordError(w, err)
+		return
+	}
+
+	uh.auditor.Record(r, &audit.Event{
+		ActorID:    &user.ID,
+		Action:     audit.ActionPasswordReset,
+		TargetType: audit.TargetUser,
+		TargetID:   &user.ID,
+	})
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status":  "success",
+		"message": "Your password has been reset. Please log in again.",
+	}); err != nil {
+		uh.logger.Error("failed to write password reset response", "user_id", user.ID, "error", err)
+		return
+	}
+	uh.logger.Info("password reset successfully", "user_id", user.ID)
+}
+
+func (uh *UserHandler) writeResetPasswordError(w http.ResponseWriter, err error) {
+	utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+		"status":  "error",
+		"message": "Failed to reset the password due to a server error. Please try again later.",
+	})
+}
+
 func (uh *UserHandler) validateUserRequest(req *registerUserRequest) error {
-	// validate username
-	if req.Username == "" {
+	if err := validateUsername(req.Username); err != nil {
+		return err
+	}
+	if err := validateEmail(req.Email); err != nil {
+		return err
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+	if req.Timezone != "" && !isValidTimezone(req.Timezone) {
+		return errors.New("invalid timezone")
+	}
+
+	return nil
+}
+
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// validateProfile checks the fields a user can change on their profile.
+func validateProfile(user *models.User) error {
+	if err := validateUsername(user.Username); err != nil {
+		return err
+	}
+	if err := validateEmail(user.Email); err != nil {
+		return err
+	}
+	if !isValidTimezone(user.Timezone) {
+		return errors.New("invalid timezone")
+	}
+	return nil
+}
+
+func validateUsername(username string) error {
+	if username == "" {
 		return errors.New("username is required")
 	}
-	if len(req.Username) < 5 {
+	if len(username) < 5 {
 		return errors.New("username must contain at least 5 characters")
 	}
-	if len(req.Username) > 50 {
+	if len(username) > 50 {
 		return errors.New("username can't be greater than 50 characters")
 	}
+	return nil
+}
 
-	// validate email
-	if req.Email == "" {
+func validateEmail(email string) error {
+	if email == "" {
 		return errors.New("email is required")
 	}
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	if !emailRegex.MatchString(req.Email) {
+	if !emailRegex.MatchString(email) {
 		return errors.New("invalid email format")
 	}
+	return nil
+}
 
-	// validate password
-	if req.Password == "" {
+func validatePassword(password string) error {
+	if password == "" {
 		return errors.New("password is required")
 	}
-	if len(req.Password) < 10 {
+	if len(password) < 10 {
 		return errors.New("password must contain at least 10 characters")
 	}
-
 	return nil
 }
+
+// isValidTimezone accepts IANA zone names that postgres understands too,
+// which rules out Go's special "Local" zone.
+func isValidTimezone(tz string) bool {
+	if tz == "" || tz == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(tz)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/policy"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+// readWorkoutOwner returns the owner of a workout the viewer is allowed to
+// see and writes the error response itself otherwise. Workouts hidden from
+// the viewer are reported as not found so their existence doesn't leak.
+// action completes messages like "The workout you are trying to <action>".
+func readWorkoutOwner(ctx context.Context, w http.ResponseWriter, workoutStore store.WorkoutStore, logger *slog.Logger, workoutID int64, viewer *models.User, action string) (int64, bool) {
+	ownerID, err := workoutStore.GetVisibleWorkoutOwner(ctx, workoutID, viewer.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			logger.Warn("attempted to "+action+" a workout that does not exist", "workout_id", workoutID, "user_id", viewer.ID)
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The workout you are trying to " + action + " could not be found.",
+			})
+			return 0, false
+		}
+
+		logger.Error("failed to fetch workout owner", "workout_id", workoutID, "action", action, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "An unexpected error occurred while preparing to " + action + ". Please try again later.",
+		})
+		return 0, false
+	}
+
+	return ownerID, true
+}
+
+// authorizeWorkout is readWorkoutOwner plus a 403 for anyone the policy
+// doesn't allow to perform action on the workout.
+func authorizeWorkout(ctx context.Context, w http.ResponseWriter, workoutStore store.WorkoutStore, pol *policy.Policy, logger *slog.Logger, workoutID int64, user *models.User, action policy.Action) bool {
+	ownerID, ok := readWorkoutOwner(ctx, w, workoutStore, logger, workoutID, user, string(action))
+	if !ok {
+		return false
+	}
+
+	return authorizeOwner(ctx, w, pol, logger, ownerID, user, action, "this workout")
+}
+
+// authorizeOwner writes a 403 unless the policy allows user to perform
+// action on workouts of ownerID. target completes "You are not authorized
+// to <action> <target>".
+func authorizeOwner(ctx context.Context, w http.ResponseWriter, pol *policy.Policy, logger *slog.Logger, ownerID int64, user *models.User, action policy.Action, target string) bool {
+	allowed, err := pol.CanActOnWorkouts(ctx, user, ownerID, action)
+	if err != nil {
+		logger.Error("failed to evaluate workout policy", "user_id", user.ID, "owner_id", ownerID, "action", action, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "An unexpected error occurred while checking your permissions. Please try again later.",
+		})
+		return false
+	}
+
+	if !allowed {
+		logger.Warn("unauthorized attempt to "+string(action)+" workouts", "owner_id", ownerID, "user_id", user.ID)
+		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+			"status":  "fail",
+			"message": "You are not authorized to " + string(action) + " " + target + ".",
+		})
+		return false
+	}
+
+	return true
+}
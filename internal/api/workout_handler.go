@@ -4,24 +4,38 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/agkmw/workout-service/internal/audit"
 	"github.com/agkmw/workout-service/internal/middleware"
 	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/policy"
 	"github.com/agkmw/workout-service/internal/store"
 	"github.com/agkmw/workout-service/internal/utils"
+	"github.com/go-chi/chi/v5"
 )
 
 type WorkoutHandler struct {
-	workoutStore store.WorkoutStore
-	logger       *slog.Logger
+	workoutStore      store.WorkoutStore
+	exerciseStore     store.ExerciseStore
+	organizationStore store.OrganizationStore
+	policy            *policy.Policy
+	auditor           *audit.Auditor
+	logger            *slog.Logger
 }
 
-func NewWorkoutHandler(workoutStore store.WorkoutStore, logger *slog.Logger) *WorkoutHandler {
+func NewWorkoutHandler(workoutStore store.WorkoutStore, exerciseStore store.ExerciseStore, organizationStore store.OrganizationStore, policy *policy.Policy, auditor *audit.Auditor, logger *slog.Logger) *WorkoutHandler {
 	return &WorkoutHandler{
-		workoutStore: workoutStore,
-		logger:       logger,
+		workoutStore:      workoutStore,
+		exerciseStore:     exerciseStore,
+		organizationStore: organizationStore,
+		policy:            policy,
+		auditor:           auditor,
+		logger:            logger,
 	}
 }
 
@@ -37,7 +51,9 @@ func (wh *WorkoutHandler) HandleGetWorkoutByID(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	workout, err := wh.workoutStore.GetWorkoutByID(workoutID)
+	currentUser := middleware.GetUser(r)
+
+	workout, err := scopedWorkouts(r, wh.workoutStore).GetWorkoutByID(r.Context(), workoutID, currentUser.ID)
 	if err != nil {
 		// Handle "Not Found" error
 		if errors.Is(err, sql.ErrNoRows) {
@@ -51,7 +67,7 @@ func (wh *WorkoutHandler) HandleGetWorkoutByID(w http.ResponseWriter, r *http.Re
 
 		// Handle other server errors
 		wh.logger.Error("failed to fetch workout by id", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to fetch the workout due to a server error. Please try again later.",
 		})
@@ -70,6 +86,190 @@ func (wh *WorkoutHandler) HandleGetWorkoutByID(w http.ResponseWriter, r *http.Re
 	wh.logger.Info("workout served successfully", "workout_id", workout.ID)
 }
 
+func (wh *WorkoutHandler) HandleGetSharedWorkout(w http.ResponseWriter, r *http.Request) {
+	shareToken := chi.URLParam(r, "token")
+
+	workout, err := wh.workoutStore.GetWorkoutByShareToken(r.Context(), shareToken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			wh.logger.Warn("shared workout not found for given token")
+			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+				"status":  "fail",
+				"message": "The requested workout could not be found.",
+			})
+			return
+		}
+
+		wh.logger.Error("failed to fetch workout by share token", "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch the workout due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string]*models.Workout{
+			"workout": workout,
+		},
+	}); err != nil {
+		wh.logger.Error("failed to write success response for get shared workout", "workout_id", workout.ID, "error", err)
+		return
+	}
+	wh.logger.Info("shared workout served successfully", "workout_id", workout.ID)
+}
+
+func (wh *WorkoutHandler) HandleListWorkouts(w http.ResponseWriter, r *http.Request) {
+	currentUser := middleware.GetUser(r)
+	wh.listWorkouts(w, r, currentUser.ID)
+}
+
+// HandleListAthleteWorkouts lets a coach browse the full log of an athlete,
+// private workouts included.
+func (wh *WorkoutHandler) HandleListAthleteWorkouts(w http.ResponseWriter, r *http.Request) {
+	athleteID, ok := wh.authorizeAthlete(w, r, policy.ActionRead)
+	if !ok {
+		return
+	}
+	wh.listWorkouts(w, r, athleteID)
+}
+
+// HandleCreateAthleteWorkout lets a coach put a planned workout into an
+// athlete's log. Completing it is up to the athlete.
+func (wh *WorkoutHandler) HandleCreateAthleteWorkout(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	athleteID, ok := wh.authorizeAthlete(w, r, policy.ActionPlan)
+	if !ok {
+		return
+	}
+	if !wh.athleteInOrganization(w, r, athleteID) {
+		return
+	}
+
+	workout := &models.Workout{}
+	if err := json.NewDecoder(r.Body).Decode(workout); err != nil {
+		wh.logger.Warn("failed to decode athlete workout create request payload", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid request payload. Please ensure all fields are correctly provided.",
+		})
+		return
+	}
+
+	if workout.Status == "" {
+		workout.Status = models.StatusPlanned
+	}
+	if workout.Status != models.StatusPlanned {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Workouts created for an athlete must be planned.",
+		})
+		return
+	}
+	workout.UserID = athleteID
+
+	wh.createWorkout(w, r, workout)
+}
+
+// athleteInOrganization checks the athlete belongs to the organization the
+// request acts in, so a coach can't put workouts into an organization the
+// athlete isn't part of. Requests without one act in the personal space.
+func (wh *WorkoutHandler) athleteInOrganization(w http.ResponseWriter, r *http.Request, athleteID int64) bool {
+	membership := middleware.GetMembership(r)
+	if membership == nil {
+		return true
+	}
+
+	_, err := wh.organizationStore.GetMembership(r.Context(), membership.Organization.Slug, athleteID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			wh.logger.Warn("attempt to plan a workout for an athlete outside the organization", "organization_id", membership.Organization.ID, "athlete_id", athleteID)
+			utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+				"status":  "fail",
+				"message": "This athlete is not a member of the organization.",
+			})
+			return false
+		}
+
+		wh.logger.Error("failed to fetch athlete membership", "organization_id", membership.Organization.ID, "athlete_id", athleteID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to create the workout due to a server error. Please try again later.",
+		})
+		return false
+	}
+	return true
+}
+
+// authorizeAthlete reads the athlete id URL parameter and checks the
+// current user may perform action on that athlete's workouts.
+func (wh *WorkoutHandler) authorizeAthlete(w http.ResponseWriter, r *http.Request, action policy.Action) (int64, bool) {
+	athleteID, err := utils.ReadIDParam(r)
+	if err != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid athlete ID. Please provide a valid numeric identifier.",
+		})
+		return 0, false
+	}
+
+	currentUser := middleware.GetUser(r)
+	if !authorizeOwner(r.Context(), w, wh.policy, wh.logger, athleteID, currentUser, action, "workouts of this athlete") {
+		return 0, false
+	}
+	return athleteID, true
+}
+
+func (wh *WorkoutHandler) listWorkouts(w http.ResponseWriter, r *http.Request, userID int64) {
+	filter, err := parseWorkoutFilter(r)
+	if err != nil {
+		wh.logger.Warn("invalid workout list query", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": fmt.Sprintf("Invalid query parameters: %s.", err),
+		})
+		return
+	}
+	filter.UserID = userID
+
+	workouts, nextCursor, err := scopedWorkouts(r, wh.workoutStore).ListWorkouts(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			wh.logger.Warn("invalid workout list cursor", "user_id", userID, "error", err)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "Invalid cursor. Please use the cursor returned by the previous page.",
+			})
+			return
+		}
+
+		wh.logger.Error("failed to list workouts", "user_id", userID, "error", err)
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+			"status":  "error",
+			"message": "Failed to fetch workouts due to a server error. Please try again later.",
+		})
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+		"status": "success",
+		"data": map[string][]*models.Workout{
+			"workouts": workouts,
+		},
+		"metadata": map[string]any{
+			"limit":       filter.Limit,
+			"sort":        filter.Sort,
+			"next_cursor": nextCursor,
+			"has_more":    nextCursor != "",
+		},
+	}); err != nil {
+		wh.logger.Error("failed to write success response for list workouts", "user_id", userID, "error", err)
+		return
+	}
+	wh.logger.Info("workouts listed successfully", "user_id", userID, "count", len(workouts))
+}
+
 func (wh *WorkoutHandler) HandleCreateWorkout(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	workout := &models.Workout{}
@@ -93,17 +293,73 @@ func (wh *WorkoutHandler) HandleCreateWorkout(w http.ResponseWriter, r *http.Req
 	}
 
 	workout.UserID = currentUser.ID
+	if workout.Status == "" {
+		workout.Status = models.StatusCompleted
+	}
 
-	// TODO: Add field validation
+	wh.createWorkout(w, r, workout)
+}
 
-	if err := wh.workoutStore.CreateWorkout(workout); err != nil {
+// createWorkout validates and stores a decoded workout whose owner and
+// default status are already set, and writes the response.
+func (wh *WorkoutHandler) createWorkout(w http.ResponseWriter, r *http.Request, workout *models.Workout) {
+	if workout.Visibility == "" {
+		workout.Visibility = models.VisibilityPrivate
+	}
+	if !models.IsValidVisibility(workout.Visibility) {
+		wh.logger.Warn("invalid workout visibility", "visibility", workout.Visibility)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid visibility. Must be one of private, followers, public or unlisted.",
+		})
+		return
+	}
+	if !wh.allowVisibility(w, r, workout.Visibility) {
+		return
+	}
+
+	// these links are only set by starting a workout from a template or program
+	workout.TemplateID = nil
+	workout.EnrollmentID = nil
+	workout.ProgramSessionID = nil
+	if !models.IsValidStatus(workout.Status) {
+		wh.logger.Warn("invalid workout status", "status", workout.Status)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid status. Must be either planned or completed.",
+		})
+		return
+	}
+
+	if err := validateWorkoutEntries(workout.Entries); err != nil {
+		wh.logger.Warn("invalid workout entries", "error", err)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Invalid workout: " + err.Error() + ".",
+		})
+		return
+	}
+
+	if err := wh.resolveExercises(r, workout.Entries); err != nil {
+		writeResolveExercisesError(w, wh.logger, err)
+		return
+	}
+
+	if err := scopedWorkouts(r, wh.workoutStore).CreateWorkout(r.Context(), workout); err != nil {
 		wh.logger.Error("failed to execute workout creation in store", "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to create the workout due to a server error. Please try again later.",
 		})
 		return
 	}
+	currentUser := middleware.GetUser(r)
+	wh.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &currentUser.ID,
+		Action:     audit.ActionWorkoutCreated,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workout.ID,
+	}, nil, workout)
 
 	if err := utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
 		"status": "success",
@@ -130,8 +386,18 @@ func (wh *WorkoutHandler) HandleUpdateWorkoutByID(w http.ResponseWriter, r *http
 		return
 	}
 
+	currentUser := middleware.GetUser(r)
+	if currentUser == nil || currentUser == models.AnonymousUser {
+		wh.logger.Warn("unauthorized attempt to update a workout", "username", currentUser.Username)
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "You must be logged in to update a workout.",
+		})
+		return
+	}
+
 	// Check if the workout to update exists
-	existingWorkout, err := wh.workoutStore.GetWorkoutByID(workoutID)
+	existingWorkout, err := scopedWorkouts(r, wh.workoutStore).GetWorkoutByID(r.Context(), workoutID, currentUser.ID)
 	if err != nil {
 		// Handle "Not Found" error
 		if errors.Is(err, sql.ErrNoRows) {
@@ -145,7 +411,7 @@ func (wh *WorkoutHandler) HandleUpdateWorkoutByID(w http.ResponseWriter, r *http
 
 		// Handle other server errors
 		wh.logger.Error("failed to fetch workout for update", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "An unexpected error occurred while preparing to update. Please try again later.",
 		})
@@ -157,6 +423,8 @@ func (wh *WorkoutHandler) HandleUpdateWorkoutByID(w http.ResponseWriter, r *http
 		Description     *string               `json:"description"`
 		DurationMinutes *int                  `json:"duration_minutes"`
 		CaloriesBurned  *int                  `json:"calories_burned"`
+		Visibility      *string               `json:"visibility"`
+		Status          *string               `json:"status"`
 		Entries         []models.WorkoutEntry `json:"entries"`
 	}
 
@@ -169,6 +437,7 @@ func (wh *WorkoutHandler) HandleUpdateWorkoutByID(w http.ResponseWriter, r *http
 		return
 	}
 
+	before := *existingWorkout
 	if updateWorkoutRequest.Title != nil {
 		existingWorkout.Title = *updateWorkoutRequest.Title
 	}
@@ -181,58 +450,81 @@ func (wh *WorkoutHandler) HandleUpdateWorkoutByID(w http.ResponseWriter, r *http
 	if updateWorkoutRequest.CaloriesBurned != nil {
 		existingWorkout.CaloriesBurned = *updateWorkoutRequest.CaloriesBurned
 	}
-	if updateWorkoutRequest.Entries != nil {
-		existingWorkout.Entries = updateWorkoutRequest.Entries
+	if updateWorkoutRequest.Visibility != nil {
+		if !models.IsValidVisibility(*updateWorkoutRequest.Visibility) {
+			wh.logger.Warn("invalid workout visibility", "workout_id", workoutID, "visibility", *updateWorkoutRequest.Visibility)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "Invalid visibility. Must be one of private, followers, public or unlisted.",
+			})
+			return
+		}
+		if !wh.allowVisibility(w, r, *updateWorkoutRequest.Visibility) {
+			return
+		}
+		existingWorkout.Visibility = *updateWorkoutRequest.Visibility
 	}
-
-	currentUser := middleware.GetUser(r)
-	if currentUser == nil || currentUser == models.AnonymousUser {
-		wh.logger.Warn("unauthorized attempt to update a workout", "username", currentUser.Username)
-		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
-			"status":  "fail",
-			"message": "You must be logged in to update a workout.",
-		})
-		return
+	if updateWorkoutRequest.Status != nil {
+		if !models.IsValidStatus(*updateWorkoutRequest.Status) {
+			wh.logger.Warn("invalid workout status", "workout_id", workoutID, "status", *updateWorkoutRequest.Status)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+				"status":  "fail",
+				"message": "Invalid status. Must be either planned or completed.",
+			})
+			return
+		}
+		existingWorkout.Status = *updateWorkoutRequest.Status
 	}
-
-	workoutOwner, err := wh.workoutStore.GetWorkoutOwner(workoutID)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			wh.logger.Warn("attempted to update a workout that does not exist", "error", err)
-			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
+	if updateWorkoutRequest.Entries != nil {
+		if err := validateWorkoutEntries(updateWorkoutRequest.Entries); err != nil {
+			wh.logger.Warn("invalid workout entries", "workout_id", workoutID, "error", err)
+			utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
 				"status":  "fail",
-				"message": "The workout you are trying to update could not be found.",
+				"message": "Invalid workout: " + err.Error() + ".",
 			})
 			return
 		}
+		if err := wh.resolveExercises(r, updateWorkoutRequest.Entries); err != nil {
+			writeResolveExercisesError(w, wh.logger, err)
+			return
+		}
+		existingWorkout.Entries = updateWorkoutRequest.Entries
+	}
 
-		wh.logger.Error("failed to fetch workout for update", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
-			"status":  "error",
-			"message": "An unexpected error occurred while preparing to update. Please try again later.",
-		})
+	if !authorizeWorkout(r.Context(), w, scopedWorkouts(r, wh.workoutStore), wh.policy, wh.logger, workoutID, currentUser, policy.ActionUpdate) {
 		return
 	}
 
-	if workoutOwner != currentUser.ID {
-		wh.logger.Warn("unauthorized attempt to update a workout", "user_id", currentUser.ID)
+	// coaches may edit the log, but who gets to see it is the athlete's call
+	if updateWorkoutRequest.Visibility != nil && existingWorkout.UserID != currentUser.ID {
+		wh.logger.Warn("attempt to change visibility of another user's workout", "workout_id", workoutID, "user_id", currentUser.ID)
 		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
 			"status":  "fail",
-			"message": "You are not authorized to update this workout.",
+			"message": "Only the owner can change the visibility of a workout.",
 		})
 		return
 	}
 
 	// TODO:Add field validation
 
-	if err := wh.workoutStore.UpdateWorkoutByID(existingWorkout); err != nil {
+	if err := scopedWorkouts(r, wh.workoutStore).UpdateWorkoutByID(r.Context(), existingWorkout); err != nil {
 		wh.logger.Error("failed to execute workout update in store", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to update the workout due to a server error. Please try again later.",
 		})
 		return
 	}
+	// the store hands back the share link, which only the owner may see
+	if existingWorkout.UserID != currentUser.ID {
+		existingWorkout.ShareToken = ""
+	}
+	wh.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &currentUser.ID,
+		Action:     audit.ActionWorkoutUpdated,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workoutID,
+	}, &before, existingWorkout)
 
 	if err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 		"status": "success",
@@ -267,36 +559,31 @@ func (wh *WorkoutHandler) HandleDeleteWorkoutByID(w http.ResponseWriter, r *http
 		return
 	}
 
-	workoutOwner, err := wh.workoutStore.GetWorkoutOwner(workoutID)
+	if !authorizeWorkout(r.Context(), w, scopedWorkouts(r, wh.workoutStore), wh.policy, wh.logger, workoutID, currentUser, policy.ActionDelete) {
+		return
+	}
+
+	// fetched for the audit trail, which keeps what the workout looked like
+	existingWorkout, err := scopedWorkouts(r, wh.workoutStore).GetWorkoutByID(r.Context(), workoutID, currentUser.ID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			wh.logger.Warn("attempted to delete a workout that does not exist", "error", err)
+			wh.logger.Warn("attempted to delete a workout that does not exist", "workout_id", workoutID)
 			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
 				"status":  "fail",
-				"message": "The workout you are trying to delete could not be found.",
+				"message": "The workout you are tyring to delete could not be found.",
 			})
 			return
 		}
 
 		wh.logger.Error("failed to fetch workout for delete", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "An unexpected error occurred while preparing to delete. Please try again later.",
 		})
 		return
 	}
 
-	if workoutOwner != currentUser.ID {
-		wh.logger.Warn("unauthorized attempt to delete a workout", "user_id", currentUser.ID)
-		utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
-			"status":  "fail",
-			"message": "You are not authorized to delete this workout.",
-		})
-		return
-	}
-
-	err = wh.workoutStore.DeleteWorkoutByID(workoutID)
-	if err != nil {
+	if err := scopedWorkouts(r, wh.workoutStore).DeleteWorkoutByID(r.Context(), workoutID); err != nil {
 		if err == sql.ErrNoRows {
 			wh.logger.Warn("attempted to delete a workout that does not exist", "workout_id", workoutID, "error", err)
 			utils.WriteJSON(w, http.StatusNotFound, utils.Envelope{
@@ -307,13 +594,179 @@ func (wh *WorkoutHandler) HandleDeleteWorkoutByID(w http.ResponseWriter, r *http
 		}
 
 		wh.logger.Error("failed to execute workout deletion in store", "workout_id", workoutID, "error", err)
-		utils.WriteJSON(w, http.StatusInternalServerError, utils.Envelope{
+		utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
 			"status":  "error",
 			"message": "Failed to delete the workout due to a server error. Please try again later.",
 		})
 		return
 	}
+	wh.auditor.RecordChange(r, &audit.Event{
+		ActorID:    &currentUser.ID,
+		Action:     audit.ActionWorkoutDeleted,
+		TargetType: audit.TargetWorkout,
+		TargetID:   &workoutID,
+	}, existingWorkout, nil)
 
 	w.WriteHeader(http.StatusNoContent)
 	wh.logger.Info("workout deleted successfully", "workout_id", workoutID)
 }
+
+// allowVisibility rejects share links inside organizations. Shared workouts
+// are served without an organization, so the link could never resolve.
+func (wh *WorkoutHandler) allowVisibility(w http.ResponseWriter, r *http.Request, visibility string) bool {
+	if visibility == models.VisibilityUnlisted && middleware.OrganizationID(r) != nil {
+		utils.WriteJSON(w, http.StatusBadRequest, utils.Envelope{
+			"status":  "fail",
+			"message": "Workouts in an organization can't be shared by link.",
+		})
+		return false
+	}
+	return true
+}
+
+// resolveExercises links entries to the exercise catalog. Entries naming an
+// exercise that isn't in the catalog are kept as free text.
+func (wh *WorkoutHandler) resolveExercises(r *http.Request, entries []models.WorkoutEntry) error {
+	refs := make([]exerciseRef, 0, len(entries))
+	for i := range entries {
+		refs = append(refs, exerciseRef{id: &entries[i].ExerciseID, name: &entries[i].ExerciseName})
+	}
+	return resolveExerciseRefs(r.Context(), scopedExercises(r, wh.exerciseStore), refs)
+}
+
+const (
+	defaultWorkoutPageSize = 20
+	maxWorkoutPageSize     = 100
+)
+
+// validateWorkoutEntries checks what the workout_entries and workout_sets
+// constraints would reject, naming the offending field. Entries logged set by
+// set take their values from the sets, others need reps or a duration.
+func validateWorkoutEntries(entries []models.WorkoutEntry) error {
+	for i, entry := range entries {
+		if entry.Reps != nil && entry.DurationSeconds != nil {
+			return fmt.Errorf("entries[%d] can't have both reps and duration_seconds", i)
+		}
+		if len(entry.Sets) == 0 && entry.Reps == nil && entry.DurationSeconds == nil {
+			return fmt.Errorf("entries[%d] needs sets, reps or duration_seconds", i)
+		}
+		if entry.Reps != nil && *entry.Reps < 0 {
+			return fmt.Errorf("entries[%d].reps can't be negative", i)
+		}
+		if entry.DurationSeconds != nil && *entry.DurationSeconds < 0 {
+			return fmt.Errorf("entries[%d].duration_seconds can't be negative", i)
+		}
+
+		for j, set := range entry.Sets {
+			if set.Reps == nil && set.DurationSeconds == nil && set.DistanceMeters == nil {
+				return fmt.Errorf("entries[%d].sets[%d] needs reps, duration_seconds or distance_meters", i, j)
+			}
+			if set.Reps != nil && *set.Reps < 0 {
+				return fmt.Errorf("entries[%d].sets[%d].reps can't be negative", i, j)
+			}
+			if set.DurationSeconds != nil && *set.DurationSeconds < 0 {
+				return fmt.Errorf("entries[%d].sets[%d].duration_seconds can't be negative", i, j)
+			}
+			if set.DistanceMeters != nil && *set.DistanceMeters < 0 {
+				return fmt.Errorf("entries[%d].sets[%d].distance_meters can't be negative", i, j)
+			}
+			if set.Weight != nil && *set.Weight < 0 {
+				return fmt.Errorf("entries[%d].sets[%d].weight can't be negative", i, j)
+			}
+			if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+				return fmt.Errorf("entries[%d].sets[%d].rpe must be between 1 and 10", i, j)
+			}
+			if set.RestSeconds != nil && *set.RestSeconds < 0 {
+				return fmt.Errorf("entries[%d].sets[%d].rest_seconds can't be negative", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+func parseWorkoutFilter(r *http.Request) (store.WorkoutFilter, error) {
+	q := r.URL.Query()
+	filter := store.WorkoutFilter{
+		Title:    q.Get("title"),
+		Exercise: q.Get("exercise"),
+		Sort:     store.WorkoutSortNewest,
+		Cursor:   q.Get("cursor"),
+		Limit:    defaultWorkoutPageSize,
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		switch sort {
+		case store.WorkoutSortNewest, store.WorkoutSortLongest, store.WorkoutSortCalories:
+			filter.Sort = sort
+		default:
+			return filter, errors.New("sort must be one of newest, longest or calories")
+		}
+	}
+
+	if status := q.Get("status"); status != "" {
+		if !models.IsValidStatus(status) {
+			return filter, errors.New("status must be either planned or completed")
+		}
+		filter.Status = status
+	}
+
+	if limit := q.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxWorkoutPageSize {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxWorkoutPageSize)
+		}
+		filter.Limit = n
+	}
+
+	if from := q.Get("from"); from != "" {
+		t, _, err := parseDateParam(from)
+		if err != nil {
+			return filter, errors.New("from must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		}
+		filter.From = &t
+	}
+
+	if to := q.Get("to"); to != "" {
+		t, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return filter, errors.New("to must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+		}
+		// a plain date includes the whole day
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = &t
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{
+		{"min_duration", &filter.MinDuration},
+		{"max_duration", &filter.MaxDuration},
+	} {
+		value := q.Get(p.name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return filter, fmt.Errorf("%s must be a non-negative integer", p.name)
+		}
+		*p.dst = &n
+	}
+
+	if filter.MinDuration != nil && filter.MaxDuration != nil && *filter.MinDuration > *filter.MaxDuration {
+		return filter, errors.New("min_duration can't be greater than max_duration")
+	}
+
+	return filter, nil
+}
+
+func parseDateParam(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/api"
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/policy"
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+func TestCreateWorkoutValidatesEntries(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := store.NewMemoryDB()
+
+	user := &models.User{
+		Username:     "lifter",
+		Email:        "lifter@example.com",
+		Timezone:     "UTC",
+		PasswordHash: models.Password{Hash: []byte("not a real hash")},
+	}
+	if err := store.NewMemoryUserStore(db).CreateUser(ctx, user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	wh := api.NewWorkoutHandler(
+		store.NewMemoryWorkoutStore(db),
+		store.NewMemoryExerciseStore(db),
+		store.NewMemoryOrganizationStore(db),
+		policy.New(store.NewMemoryCoachStore(db)),
+		audit.New(audit.NewMemoryStore(), logger),
+		logger,
+	)
+
+	tests := []struct {
+		name     string
+		entry    string
+		wantCode int
+		wantMsg  string
+	}{
+		{"reps", `{"exercise_name": "Squat", "reps": 5}`, http.StatusCreated, ""},
+		{"sets only", `{"exercise_name": "Row", "sets": [{"distance_meters": 500}]}`, http.StatusCreated, ""},
+		{"no value", `{"exercise_name": "Squat"}`, http.StatusBadRequest, "entries[0] needs sets, reps or duration_seconds"},
+		{"reps and duration", `{"exercise_name": "Plank", "reps": 5, "duration_seconds": 60}`, http.StatusBadRequest, "entries[0] can't have both reps and duration_seconds"},
+		{"empty set", `{"exercise_name": "Squat", "sets": [{"reps": 5}, {"weight": 100}]}`, http.StatusBadRequest, "entries[0].sets[1] needs reps, duration_seconds or distance_meters"},
+		{"rpe too high", `{"exercise_name": "Squat", "sets": [{"reps": 5, "rpe": 11}]}`, http.StatusBadRequest, "entries[0].sets[0].rpe must be between 1 and 10"},
+		{"rpe too low", `{"exercise_name": "Squat", "sets": [{"reps": 5, "rpe": 0.5}]}`, http.StatusBadRequest, "entries[0].sets[0].rpe must be between 1 and 10"},
+		{"negative reps", `{"exercise_name": "Squat", "sets": [{"reps": -1}]}`, http.StatusBadRequest, "entries[0].sets[0].reps can't be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := `{"title": "Leg day", "duration_minutes": 60, "entries": [` + tt.entry + `]}`
+			r := httptest.NewRequest(http.MethodPost, "/workouts", strings.NewReader(body))
+			r = middleware.SetUser(r, user)
+			w := httptest.NewRecorder()
+
+			wh.HandleCreateWorkout(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantMsg == "" {
+				return
+			}
+			var resp struct {
+				Message string `json:"message"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if !strings.Contains(resp.Message, tt.wantMsg) {
+				t.Errorf("message = %q, want it to mention %q", resp.Message, tt.wantMsg)
+			}
+		})
+	}
+}
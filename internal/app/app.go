@@ -1,70 +1,166 @@
 package app
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 
+	"github.com/agkmw/workout-service/internal/analytics"
 	"github.com/agkmw/workout-service/internal/api"
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/config"
+	"github.com/agkmw/workout-service/internal/mailer"
 	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/policy"
 	"github.com/agkmw/workout-service/internal/store"
-	"github.com/agkmw/workout-service/migrations"
 )
 
+// ErrMigration marks failures to bring the database schema or seed data up
+// to date, as opposed to the database being unreachable.
+var ErrMigration = errors.New("migration failed")
+
 type Application struct {
-	Logger         *slog.Logger
-	UserStore      store.UserStore
-	UserHandler    *api.UserHandler
-	WorkoutStore   store.WorkoutStore
-	WorkoutHandler *api.WorkoutHandler
-	TokenStore     store.TokenStore
-	TokenHandler   *api.TokenHandler
-	Middleware     *middleware.UserMiddleware
-	DB             *sql.DB
+	Config              *config.Config
+	Logger              *slog.Logger
+	UserStore           store.UserStore
+	UserHandler         *api.UserHandler
+	WorkoutStore        store.WorkoutStore
+	WorkoutHandler      *api.WorkoutHandler
+	TokenStore          store.TokenStore
+	TokenHandler        *api.TokenHandler
+	ExerciseStore       store.ExerciseStore
+	ExerciseHandler     *api.ExerciseHandler
+	RecordStore         store.RecordStore
+	RecordHandler       *api.RecordHandler
+	Analytics           analytics.Store
+	StatsHandler        *api.StatsHandler
+	TemplateStore       store.TemplateStore
+	TemplateHandler     *api.TemplateHandler
+	ProgramStore        store.ProgramStore
+	ProgramHandler      *api.ProgramHandler
+	FollowStore         store.FollowStore
+	FollowHandler       *api.FollowHandler
+	CommentStore        store.CommentStore
+	CommentHandler      *api.CommentHandler
+	ReactionStore       store.ReactionStore
+	ReactionHandler     *api.ReactionHandler
+	AdminStore          store.AdminStore
+	AdminHandler        *api.AdminHandler
+	CoachStore          store.CoachStore
+	CoachHandler        *api.CoachHandler
+	OrganizationStore   store.OrganizationStore
+	OrganizationHandler *api.OrganizationHandler
+	AuditStore          audit.Store
+	AuditHandler        *api.AuditHandler
+	Mailer              mailer.Mailer
+	Middleware          *middleware.UserMiddleware
+	Lifecycle           *Lifecycle
+	DB                  *sql.DB
 }
 
-func New() (*Application, error) {
-	db, err := store.Open()
-	if err != nil {
-		return nil, err
-	}
+func New(cfg *config.Config) (*Application, error) {
+	logger := cfg.Log.NewLogger(os.Stdout)
 
-	if err := store.MigrateFS(db, migrations.FS, "."); err != nil {
+	b, err := openBackend(cfg, logger)
+	if err != nil {
 		return nil, err
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	mail := mailer.NewLogMailer(logger)
 
-	// stores
-	userStore := store.NewPostgresUserStore(db)
-	workoutStore := store.NewPostgresWorkoutStore(db)
-	tokenStore := store.NewPostgresTokenStore(db)
+	workoutPolicy := policy.New(b.coaches)
+	auditor := audit.New(b.audit, logger)
 
 	// handlers
-	userHandler := api.NewUserHandler(userStore, logger)
-	workoutHandler := api.NewWorkoutHandler(workoutStore, logger)
-	tokenHandler := api.NewTokenHandler(tokenStore, userStore, logger)
+	userHandler := api.NewUserHandler(b.users, b.tokens, b.workouts, mail, auditor, cfg.BcryptCost, logger)
+	workoutHandler := api.NewWorkoutHandler(b.workouts, b.exercises, b.organizations, workoutPolicy, auditor, logger)
+	tokenHandler := api.NewTokenHandler(b.tokens, b.users, mail, auditor, logger)
+	exerciseHandler := api.NewExerciseHandler(b.exercises, logger)
+	recordHandler := api.NewRecordHandler(b.records, b.exercises, logger)
+	statsHandler := api.NewStatsHandler(b.analytics, b.exercises, logger)
+	templateHandler := api.NewTemplateHandler(b.templates, b.workouts, b.exercises, auditor, logger)
+	programHandler := api.NewProgramHandler(b.programs, b.templates, b.workouts, b.records, auditor, logger)
+	followHandler := api.NewFollowHandler(b.follows, b.users, b.workouts, logger)
+	commentHandler := api.NewCommentHandler(b.comments, b.workouts, logger)
+	reactionHandler := api.NewReactionHandler(b.reactions, b.workouts, logger)
+	adminHandler := api.NewAdminHandler(b.users, b.tokens, b.admin, auditor, logger)
+	coachHandler := api.NewCoachHandler(b.coaches, b.users, mail, logger)
+	organizationHandler := api.NewOrganizationHandler(b.organizations, b.users, logger)
+	auditHandler := api.NewAuditHandler(b.audit, logger)
+
+	// background workers
+	lifecycle := NewLifecycle(logger)
+	lifecycle.Add(Every("token-cleanup", cfg.Workers.TokenCleanupInterval, logger, func(ctx context.Context) error {
+		deleted, err := b.tokens.DeleteExpiredTokens(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("expired tokens deleted", "count", deleted)
+		return nil
+	}))
 
 	// middleware
-	middlewareHandler := middleware.NewUserMiddleware(userStore)
+	middlewareHandler := middleware.NewUserMiddleware(b.users, b.tokens, b.organizations, logger)
 
 	app := &Application{
-		Logger:         logger,
-		UserStore:      userStore,
-		UserHandler:    userHandler,
-		WorkoutStore:   workoutStore,
-		WorkoutHandler: workoutHandler,
-		TokenStore:     tokenStore,
-		TokenHandler:   tokenHandler,
-		Middleware:     middlewareHandler,
-		DB:             db,
+		Config:              cfg,
+		Logger:              logger,
+		UserStore:           b.users,
+		UserHandler:         userHandler,
+		WorkoutStore:        b.workouts,
+		WorkoutHandler:      workoutHandler,
+		TokenStore:          b.tokens,
+		TokenHandler:        tokenHandler,
+		ExerciseStore:       b.exercises,
+		ExerciseHandler:     exerciseHandler,
+		RecordStore:         b.records,
+		RecordHandler:       recordHandler,
+		Analytics:           b.analytics,
+		StatsHandler:        statsHandler,
+		TemplateStore:       b.templates,
+		TemplateHandler:     templateHandler,
+		ProgramStore:        b.programs,
+		ProgramHandler:      programHandler,
+		FollowStore:         b.follows,
+		FollowHandler:       followHandler,
+		CommentStore:        b.comments,
+		CommentHandler:      commentHandler,
+		ReactionStore:       b.reactions,
+		ReactionHandler:     reactionHandler,
+		AdminStore:          b.admin,
+		AdminHandler:        adminHandler,
+		CoachStore:          b.coaches,
+		CoachHandler:        coachHandler,
+		OrganizationStore:   b.organizations,
+		OrganizationHandler: organizationHandler,
+		AuditStore:          b.audit,
+		AuditHandler:        auditHandler,
+		Mailer:              mail,
+		Middleware:          middlewareHandler,
+		Lifecycle:           lifecycle,
+		DB:                  b.db,
 	}
 
 	return app, nil
 }
 
+// Close stops the background workers and then closes the database, if the
+// store has one. If the workers don't stop before ctx is done the database
+// is left open, since they may still be using it.
+func (app *Application) Close(ctx context.Context) error {
+	if err := app.Lifecycle.Stop(ctx); err != nil {
+		return err
+	}
+	if app.DB == nil {
+		return nil
+	}
+	return app.DB.Close()
+}
+
 func (app *Application) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintln(w, "Status is available...")
 }
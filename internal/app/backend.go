@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/audit"
+	"github.com/agkmw/workout-service/internal/config"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
+	"github.com/agkmw/workout-service/migrations"
+	sqlitemigrations "github.com/agkmw/workout-service/migrations/sqlite"
+	"github.com/agkmw/workout-service/seeds"
+)
+
+// backend is every store of one storage backend.
+type backend struct {
+	users         store.UserStore
+	workouts      store.WorkoutStore
+	tokens        store.TokenStore
+	exercises     store.ExerciseStore
+	records       store.RecordStore
+	analytics     analytics.Store
+	templates     store.TemplateStore
+	programs      store.ProgramStore
+	follows       store.FollowStore
+	comments      store.CommentStore
+	reactions     store.ReactionStore
+	admin         store.AdminStore
+	coaches       store.CoachStore
+	organizations store.OrganizationStore
+	audit         audit.Store
+	// db is nil for backends without a database connection.
+	db *sql.DB
+}
+
+// openBackend connects to the configured store and brings its schema and
+// seed data up to date.
+func openBackend(cfg *config.Config, logger *slog.Logger) (*backend, error) {
+	ttls := tokens.TTLs{
+		Access:        cfg.Tokens.AccessTTL,
+		Refresh:       cfg.Tokens.RefreshTTL,
+		PasswordReset: cfg.Tokens.PasswordResetTTL,
+		Activation:    cfg.Tokens.ActivationTTL,
+	}
+
+	switch cfg.Store {
+	case config.StoreMemory:
+		return openMemory(ttls, logger)
+	case config.StoreSQLite:
+		return openSQLite(cfg, ttls)
+	default:
+		return openPostgres(cfg.DB, ttls)
+	}
+}
+
+func openPostgres(cfg config.DB, ttls tokens.TTLs) (*backend, error) {
+	db, err := store.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.MigrateFS(db, migrations.FS, "."); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+
+	exerciseStore := store.NewPostgresExerciseStore(db, cfg.QueryTimeout)
+	if err := store.SeedExercisesFS(context.Background(), exerciseStore, seeds.FS, "exercises.json"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+
+	return &backend{
+		users:         store.NewPostgresUserStore(db, cfg.QueryTimeout),
+		workouts:      store.NewPostgresWorkoutStore(db, cfg.QueryTimeout),
+		tokens:        store.NewPostgresTokenStore(db, cfg.QueryTimeout, ttls),
+		exercises:     exerciseStore,
+		records:       store.NewPostgresRecordStore(db, cfg.QueryTimeout),
+		analytics:     analytics.NewPostgresStore(db, cfg.QueryTimeout),
+		templates:     store.NewPostgresTemplateStore(db, cfg.QueryTimeout),
+		programs:      store.NewPostgresProgramStore(db, cfg.QueryTimeout),
+		follows:       store.NewPostgresFollowStore(db, cfg.QueryTimeout),
+		comments:      store.NewPostgresCommentStore(db, cfg.QueryTimeout),
+		reactions:     store.NewPostgresReactionStore(db, cfg.QueryTimeout),
+		admin:         store.NewPostgresAdminStore(db, cfg.QueryTimeout),
+		coaches:       store.NewPostgresCoachStore(db, cfg.QueryTimeout),
+		organizations: store.NewPostgresOrganizationStore(db, cfg.QueryTimeout),
+		audit:         audit.NewPostgresStore(db, cfg.QueryTimeout),
+		db:            db,
+	}, nil
+}
+
+func openSQLite(cfg *config.Config, ttls tokens.TTLs) (*backend, error) {
+	db, err := store.OpenSQLite(cfg.SQLite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.MigrateSQLiteFS(db, sqlitemigrations.FS, "."); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+
+	exerciseStore := store.NewSQLiteExerciseStore(db, cfg.DB.QueryTimeout)
+	if err := store.SeedExercisesFS(context.Background(), exerciseStore, seeds.FS, "exercises.json"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+
+	return &backend{
+		users:         store.NewSQLiteUserStore(db, cfg.DB.QueryTimeout),
+		workouts:      store.NewSQLiteWorkoutStore(db, cfg.DB.QueryTimeout),
+		tokens:        store.NewSQLiteTokenStore(db, cfg.DB.QueryTimeout, ttls),
+		exercises:     exerciseStore,
+		records:       store.NewSQLiteRecordStore(db, cfg.DB.QueryTimeout),
+		analytics:     analytics.NewSQLiteStore(db, cfg.DB.QueryTimeout),
+		templates:     store.NewSQLiteTemplateStore(db, cfg.DB.QueryTimeout),
+		programs:      store.NewSQLiteProgramStore(db, cfg.DB.QueryTimeout),
+		follows:       store.NewSQLiteFollowStore(db, cfg.DB.QueryTimeout),
+		comments:      store.NewSQLiteCommentStore(db, cfg.DB.QueryTimeout),
+		reactions:     store.NewSQLiteReactionStore(db, cfg.DB.QueryTimeout),
+		admin:         store.NewSQLiteAdminStore(db, cfg.DB.QueryTimeout),
+		coaches:       store.NewSQLiteCoachStore(db, cfg.DB.QueryTimeout),
+		organizations: store.NewSQLiteOrganizationStore(db, cfg.DB.QueryTimeout),
+		audit:         audit.NewSQLiteStore(db, cfg.DB.QueryTimeout),
+		db:            db,
+	}, nil
+}
+
+func openMemory(ttls tokens.TTLs, logger *slog.Logger) (*backend, error) {
+	db := store.NewMemoryDB()
+
+	exerciseStore := store.NewMemoryExerciseStore(db)
+	if err := store.SeedExercisesFS(context.Background(), exerciseStore, seeds.FS, "exercises.json"); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMigration, err)
+	}
+
+	logger.Warn("using the in-memory store, all data is lost on shutdown")
+
+	return &backend{
+		users:         store.NewMemoryUserStore(db),
+		workouts:      store.NewMemoryWorkoutStore(db),
+		tokens:        store.NewMemoryTokenStore(db, ttls),
+		exercises:     exerciseStore,
+		records:       store.NewMemoryRecordStore(db),
+		analytics:     analytics.NewMemoryStore(db),
+		templates:     store.NewMemoryTemplateStore(db),
+		programs:      store.NewMemoryProgramStore(db),
+		follows:       store.NewMemoryFollowStore(db),
+		comments:      store.NewMemoryCommentStore(db),
+		reactions:     store.NewMemoryReactionStore(db),
+		admin:         store.NewMemoryAdminStore(db),
+		coaches:       store.NewMemoryCoachStore(db),
+		organizations: store.NewMemoryOrganizationStore(db),
+		audit:         audit.NewMemoryStore(),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Worker is a background job running next to the server. Run must return
+// once ctx is cancelled.
+type Worker struct {
+	Name string
+	Run  func(ctx context.Context)
+}
+
+// Lifecycle starts the background workers and stops them again on
+// shutdown, so that nothing uses the database after it is closed.
+type Lifecycle struct {
+	logger  *slog.Logger
+	workers []Worker
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewLifecycle(logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{
+		logger: logger,
+	}
+}
+
+// Add registers a worker. Workers added after Start are not run.
+func (l *Lifecycle) Add(worker Worker) {
+	l.workers = append(l.workers, worker)
+}
+
+func (l *Lifecycle) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+
+	for _, worker := range l.workers {
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			defer func() {
+				// a crashing job shouldn't take the server down with it
+				if err := recover(); err != nil {
+					l.logger.Error("worker panicked", "worker", worker.Name, "error", err)
+				}
+			}()
+
+			l.logger.Info("worker started", "worker", worker.Name)
+			worker.Run(ctx)
+			l.logger.Info("worker stopped", "worker", worker.Name)
+		}()
+	}
+}
+
+// Stop cancels the workers and waits until all of them returned or ctx is
+// done, whichever happens first.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	if l.cancel == nil {
+		return nil
+	}
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for workers to stop: %w", ctx.Err())
+	}
+}
+
+// Every returns a worker that calls job once per interval. Failures are
+// logged and the job is tried again at the next tick. job's context is
+// cancelled on shutdown.
+func Every(name string, interval time.Duration, logger *slog.Logger, job func(ctx context.Context) error) Worker {
+	return Worker{
+		Name: name,
+		Run: func(ctx context.Context) {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := job(ctx); err != nil {
+						logger.Error("scheduled job failed", "worker", name, "error", err)
+					}
+				}
+			}
+		},
+	}
+}
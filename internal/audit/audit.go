@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/utils"
+)
+
+const (
+	ActionTokenCreated    = "token.created"
+	ActionLoginFailed     = "login.failed"
+	ActionUserRegistered  = "user.registered"
+	ActionUserUpdated     = "user.updated"
+	ActionPasswordChanged = "user.password_changed"
+	ActionPasswordReset   = "user.password_reset"
+	ActionWorkoutCreated  = "workout.created"
+	ActionWorkoutUpdated  = "workout.updated"
+	ActionWorkoutDeleted  = "workout.deleted"
+
+	TargetUser    = "user"
+	TargetWorkout = "workout"
+)
+
+// Change is the old and new value of one field. From is nil for created
+// records and To is nil for deleted ones.
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+type Event struct {
+	ID         int64             `json:"id"`
+	ActorID    *int64            `json:"actor_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   *int64            `json:"target_id"`
+	IP         string            `json:"ip"`
+	UserAgent  string            `json:"user_agent"`
+	Changes    map[string]Change `json:"changes"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// Filter narrows down a listing. Zero values match everything.
+type Filter struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   *int64
+	Limit      int
+	Offset     int
+}
+
+type Store interface {
+	Record(ctx context.Context, event *Event) error
+	// List returns matching events, most recent first.
+	List(ctx context.Context, filter Filter) ([]*Event, error)
+}
+
+type PostgresStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewPostgresStore(db *sql.DB, queryTimeout time.Duration) *PostgresStore {
+	return &PostgresStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (pg *PostgresStore) Record(ctx context.Context, event *Event) error {
+	ctx, cancel := store.WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	if event.Changes == nil {
+		event.Changes = map[string]Change{}
+	}
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, ip, user_agent, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	return pg.db.QueryRowContext(
+		ctx,
+		query,
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.IP,
+		event.UserAgent,
+		changes,
+	).Scan(
+		&event.ID,
+		&event.CreatedAt,
+	)
+}
+
+func (pg *PostgresStore) List(ctx context.Context, filter Filter) ([]*Event, error) {
+	ctx, cancel := store.WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	conditions := []string{}
+	args := []any{}
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ActorID != nil {
+		add("actor_id = $%d", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != nil {
+		add("target_id = $%d", *filter.TargetID)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query := fmt.Sprintf(`
+		SELECT id, actor_id, action, target_type, target_id, ip, user_agent, changes, created_at
+		FROM audit_events
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*Event{}
+	for rows.Next() {
+		event := &Event{}
+		var changes []byte
+		if err := rows.Scan(
+			&event.ID,
+			&event.ActorID,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.IP,
+			&event.UserAgent,
+			&changes,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// Auditor records events on behalf of the handlers.
+type Auditor struct {
+	store  Store
+	logger *slog.Logger
+}
+
+func New(store Store, logger *slog.Logger) *Auditor {
+	return &Auditor{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Record stores the event with the client address and user agent of r.
+// The audited change has already happened by then, so a failure is only
+// logged.
+func (a *Auditor) Record(r *http.Request, event *Event) {
+	event.IP = utils.ClientIP(r)
+	event.UserAgent = r.UserAgent()
+	// the client going away mustn't lose the record of what it did
+	if err := a.store.Record(context.WithoutCancel(r.Context()), event); err != nil {
+		a.logger.Error("failed to record audit event", "action", event.Action, "target_type", event.TargetType, "error", err)
+	}
+}
+
+// RecordChange is Record with the event's changes set to the diff between
+// before and after.
+func (a *Auditor) RecordChange(r *http.Request, event *Event, before, after any) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		a.logger.Error("failed to diff audited record", "action", event.Action, "target_type", event.TargetType, "error", err)
+	}
+	event.Changes = changes
+	a.Record(r, event)
+}
+
+// ignoredFields change on every write and would only add noise to a diff.
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// Diff compares the JSON representations of before and after and returns
+// the top-level fields that differ. Either side may be nil, so creations
+// and deletions list every field. Fields hidden from JSON, like password
+// hashes, never show up.
+func Diff(before, after any) (map[string]Change, error) {
+	from, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for field, value := range from {
+		if ignoredFields[field] || reflect.DeepEqual(value, to[field]) {
+			continue
+		}
+		changes[field] = Change{From: value, To: to[field]}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; ok || ignoredFields[field] {
+			continue
+		}
+		changes[field] = Change{To: value}
+	}
+	return changes, nil
+}
+
+func toFields(v any) (map[string]any, error) {
+	if v == nil {
+		return map[string]any{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
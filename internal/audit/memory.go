@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps events in process memory. Changes are stored as JSON,
+// like the jsonb column, so listed events don't share maps with recorded
+// ones.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	lastID int64
+	events []memoryEvent
+}
+
+type memoryEvent struct {
+	event   Event
+	changes []byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (ms *MemoryStore) Record(ctx context.Context, event *Event) error {
+	if event.Changes == nil {
+		event.Changes = map[string]Change{}
+	}
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return err
+	}
+
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.lastID++
+	event.ID = ms.lastID
+	event.CreatedAt = time.Now().Truncate(time.Microsecond)
+
+	stored := *event
+	stored.ActorID = clone(event.ActorID)
+	stored.TargetID = clone(event.TargetID)
+	stored.Changes = nil
+	ms.events = append(ms.events, memoryEvent{event: stored, changes: changes})
+	return nil
+}
+
+func (ms *MemoryStore) List(ctx context.Context, filter Filter) ([]*Event, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	matching := []memoryEvent{}
+	for _, e := range ms.events {
+		if filter.ActorID != nil && (e.event.ActorID == nil || *e.event.ActorID != *filter.ActorID) {
+			continue
+		}
+		if filter.Action != "" && e.event.Action != filter.Action {
+			continue
+		}
+		if filter.TargetType != "" && e.event.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != nil && (e.event.TargetID == nil || *e.event.TargetID != *filter.TargetID) {
+			continue
+		}
+		matching = append(matching, e)
+	}
+	sort.Slice(matching, func(i, j int) bool {
+		a, b := matching[i].event, matching[j].event
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	start := min(filter.Offset, len(matching))
+	end := min(start+filter.Limit, len(matching))
+	events := []*Event{}
+	for _, e := range matching[start:end] {
+		event := e.event
+		event.ActorID = clone(e.event.ActorID)
+		event.TargetID = clone(e.event.TargetID)
+		if err := json.Unmarshal(e.changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+func clone(id *int64) *int64 {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
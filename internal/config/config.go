@@ -0,0 +1,351 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the name of every environment variable read by Load.
+const EnvPrefix = "WORKOUT_"
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// the backends data can be kept in
+const (
+	StorePostgres = "postgres"
+	// StoreSQLite keeps everything in a single file, for small deployments
+	// without a database server.
+	StoreSQLite = "sqlite"
+	// StoreMemory keeps everything in process memory, which is lost on
+	// shutdown. It is meant for tests and demos.
+	StoreMemory = "memory"
+)
+
+type Config struct {
+	Port       int     `yaml:"port"`
+	BcryptCost int     `yaml:"bcrypt_cost"`
+	Store      string  `yaml:"store"`
+	DB         DB      `yaml:"db"`
+	SQLite     SQLite  `yaml:"sqlite"`
+	Tokens     Tokens  `yaml:"tokens"`
+	Log        Log     `yaml:"log"`
+	Server     Server  `yaml:"server"`
+	Workers    Workers `yaml:"workers"`
+	CORS       CORS    `yaml:"cors"`
+}
+
+type DB struct {
+	DSN          string        `yaml:"dsn"`
+	MaxOpenConns int           `yaml:"max_open_conns"`
+	MaxIdleConns int           `yaml:"max_idle_conns"`
+	MaxIdleTime  time.Duration `yaml:"max_idle_time"`
+	// QueryTimeout bounds every single store call, so a stuck query fails
+	// the request instead of holding a connection.
+	QueryTimeout time.Duration `yaml:"query_timeout"`
+}
+
+type SQLite struct {
+	// Path is the database file, created if it doesn't exist.
+	Path string `yaml:"path"`
+}
+
+type Tokens struct {
+	AccessTTL        time.Duration `yaml:"access_ttl"`
+	RefreshTTL       time.Duration `yaml:"refresh_ttl"`
+	PasswordResetTTL time.Duration `yaml:"password_reset_ttl"`
+	ActivationTTL    time.Duration `yaml:"activation_ttl"`
+}
+
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type Server struct {
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests and background workers
+	// get to finish once a shutdown signal arrives.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type Workers struct {
+	TokenCleanupInterval time.Duration `yaml:"token_cleanup_interval"`
+}
+
+// CORS lists the origins browsers may call the API from. "*" allows any
+// origin; an empty list disables cross-origin requests.
+type CORS struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// Default returns the settings used for anything not configured, which
+// match a local docker-compose database.
+func Default() *Config {
+	return &Config{
+		Port:       8080,
+		BcryptCost: 13,
+		Store:      StorePostgres,
+		DB: DB{
+			DSN:          "host=localhost dbname=postgres password=postgres user=postgres port=5432 sslmode=disable",
+			MaxOpenConns: 10,
+			MaxIdleConns: 5,
+			MaxIdleTime:  30 * time.Second,
+			QueryTimeout: 5 * time.Second,
+		},
+		SQLite: SQLite{
+			Path: "workout.db",
+		},
+		Tokens: Tokens{
+			AccessTTL:        15 * time.Minute,
+			RefreshTTL:       30 * 24 * time.Hour,
+			PasswordResetTTL: 45 * time.Minute,
+			ActivationTTL:    3 * 24 * time.Hour,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: LogFormatText,
+		},
+		Server: Server{
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Workers: Workers{
+			TokenCleanupInterval: time.Hour,
+		},
+		CORS: CORS{
+			AllowedOrigins: []string{},
+		},
+	}
+}
+
+// setting is one option that can be given as a flag or an environment
+// variable. set parses the raw value into the config.
+type setting struct {
+	name  string
+	usage string
+	set   func(value string) error
+}
+
+// env returns the environment variable for the setting, e.g. db-dsn is read
+// from WORKOUT_DB_DSN.
+func (s setting) env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"port", "the port to listen to requests", intValue(&c.Port)},
+		{"bcrypt-cost", "the bcrypt cost new password hashes are created with", intValue(&c.BcryptCost)},
+		{"store", "where data is kept: postgres, sqlite or memory", stringValue(&c.Store)},
+		{"db-dsn", "the PostgreSQL connection string", stringValue(&c.DB.DSN)},
+		{"db-max-open-conns", "the maximum number of open database connections", intValue(&c.DB.MaxOpenConns)},
+		{"db-max-idle-conns", "the maximum number of idle database connections", intValue(&c.DB.MaxIdleConns)},
+		{"db-max-idle-time", "how long a database connection may stay idle", durationValue(&c.DB.MaxIdleTime)},
+		{"db-query-timeout", "how long a single database query may run", durationValue(&c.DB.QueryTimeout)},
+		{"sqlite-path", "the SQLite database file", stringValue(&c.SQLite.Path)},
+		{"access-token-ttl", "how long access tokens are valid", durationValue(&c.Tokens.AccessTTL)},
+		{"refresh-token-ttl", "how long refresh tokens are valid", durationValue(&c.Tokens.RefreshTTL)},
+		{"password-reset-ttl", "how long password reset tokens are valid", durationValue(&c.Tokens.PasswordResetTTL)},
+		{"activation-ttl", "how long activation tokens are valid", durationValue(&c.Tokens.ActivationTTL)},
+		{"log-level", "the minimum log level: debug, info, warn or error", stringValue(&c.Log.Level)},
+		{"log-format", "the log format: text or json", stringValue(&c.Log.Format)},
+		{"read-timeout", "the maximum duration for reading a request", durationValue(&c.Server.ReadTimeout)},
+		{"write-timeout", "the maximum duration for writing a response", durationValue(&c.Server.WriteTimeout)},
+		{"idle-timeout", "how long keep-alive connections may stay idle", durationValue(&c.Server.IdleTimeout)},
+		{"shutdown-timeout", "how long to wait for requests and workers to finish on shutdown", durationValue(&c.Server.ShutdownTimeout)},
+		{"token-cleanup-interval", "how often expired tokens are deleted", durationValue(&c.Workers.TokenCleanupInterval)},
+		{"cors-origins", "comma separated origins allowed to make cross-origin requests", listValue(&c.CORS.AllowedOrigins)},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML file named by -config or WORKOUT_CONFIG, the
+// environment and the command line flags. The result is validated.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	// flags are parsed first to find the config file, but only applied
+	// after the file and the environment so that they win
+	fs := flag.NewFlagSet("workout-service", flag.ContinueOnError)
+	configPath := fs.String("config", getenv(EnvPrefix+"CONFIG"), "path to a YAML config file")
+	type flagValue struct {
+		setting setting
+		value   string
+	}
+	var flagValues []flagValue
+	for _, s := range settings {
+		fs.Func(s.name, fmt.Sprintf("%s (env %s)", s.usage, s.env()), func(value string) error {
+			flagValues = append(flagValues, flagValue{setting: s, value: value})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		value := getenv(s.env())
+		if value == "" {
+			continue
+		}
+		if err := s.set(value); err != nil {
+			return nil, fmt.Errorf("config: invalid %s: %w", s.env(), err)
+		}
+	}
+
+	for _, f := range flagValues {
+		if err := f.setting.set(f.value); err != nil {
+			return nil, fmt.Errorf("config: invalid -%s: %w", f.setting.name, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	defer f.Close()
+
+	// unknown keys are most likely typos, which would otherwise be ignored
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Port > 0 && c.Port <= 65535, "port must be between 1 and 65535, got %d", c.Port)
+	check(c.BcryptCost >= bcrypt.MinCost && c.BcryptCost <= bcrypt.MaxCost,
+		"bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, c.BcryptCost)
+
+	check(c.Store == StorePostgres || c.Store == StoreSQLite || c.Store == StoreMemory,
+		"store must be one of %s, %s or %s, got %q", StorePostgres, StoreSQLite, StoreMemory, c.Store)
+	check(c.Store != StorePostgres || c.DB.DSN != "", "db dsn must not be empty")
+	check(c.Store != StoreSQLite || c.SQLite.Path != "", "sqlite path must not be empty")
+	check(c.DB.MaxOpenConns > 0, "db max open conns must be positive, got %d", c.DB.MaxOpenConns)
+	check(c.DB.MaxIdleConns >= 0 && c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"db max idle conns must be between 0 and max open conns (%d), got %d", c.DB.MaxOpenConns, c.DB.MaxIdleConns)
+	check(c.DB.MaxIdleTime >= 0, "db max idle time must not be negative, got %s", c.DB.MaxIdleTime)
+	check(c.DB.QueryTimeout > 0, "db query timeout must be positive, got %s", c.DB.QueryTimeout)
+
+	check(c.Tokens.AccessTTL > 0, "access token ttl must be positive, got %s", c.Tokens.AccessTTL)
+	check(c.Tokens.RefreshTTL > 0, "refresh token ttl must be positive, got %s", c.Tokens.RefreshTTL)
+	check(c.Tokens.PasswordResetTTL > 0, "password reset ttl must be positive, got %s", c.Tokens.PasswordResetTTL)
+	check(c.Tokens.ActivationTTL > 0, "activation ttl must be positive, got %s", c.Tokens.ActivationTTL)
+	check(c.Tokens.RefreshTTL >= c.Tokens.AccessTTL, "refresh token ttl must not be shorter than access token ttl")
+
+	_, err := c.Log.SlogLevel()
+	check(err == nil, "log level must be one of debug, info, warn or error, got %q", c.Log.Level)
+	check(c.Log.Format == LogFormatText || c.Log.Format == LogFormatJSON,
+		"log format must be either %s or %s, got %q", LogFormatText, LogFormatJSON, c.Log.Format)
+
+	check(c.Server.ReadTimeout > 0, "read timeout must be positive, got %s", c.Server.ReadTimeout)
+	check(c.Server.WriteTimeout > 0, "write timeout must be positive, got %s", c.Server.WriteTimeout)
+	check(c.Server.IdleTimeout > 0, "idle timeout must be positive, got %s", c.Server.IdleTimeout)
+	check(c.Server.ShutdownTimeout > 0, "shutdown timeout must be positive, got %s", c.Server.ShutdownTimeout)
+	check(c.Workers.TokenCleanupInterval > 0, "token cleanup interval must be positive, got %s", c.Workers.TokenCleanupInterval)
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(isValidOrigin(origin), "cors origin %q must be * or a scheme and host like https://example.com", origin)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: invalid settings:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// isValidOrigin accepts "*" and origins as browsers send them, without a
+// path or trailing slash.
+func isValidOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.Path == "" && u.RawQuery == ""
+}
+
+func stringValue(dst *string) func(string) error {
+	return func(value string) error {
+		*dst = value
+		return nil
+	}
+}
+
+func intValue(dst *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		*dst = n
+		return nil
+	}
+}
+
+func durationValue(dst *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s or 15m", value)
+		}
+		*dst = d
+		return nil
+	}
+}
+
+func listValue(dst *[]string) func(string) error {
+	return func(value string) error {
+		list := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*dst = list
+		return nil
+	}
+}
@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/agkmw/workout-service/internal/models"
 	"github.com/agkmw/workout-service/internal/store"
@@ -11,19 +15,36 @@ import (
 	"github.com/agkmw/workout-service/internal/utils"
 )
 
+// lastUsedInterval bounds how often a token's last use is written back.
+const lastUsedInterval = time.Minute
+
 type UserMiddleware struct {
-	UserStore store.UserStore
+	UserStore         store.UserStore
+	TokenStore        store.TokenStore
+	OrganizationStore store.OrganizationStore
+	Logger            *slog.Logger
 }
 
-func NewUserMiddleware(userStore store.UserStore) *UserMiddleware {
+func NewUserMiddleware(userStore store.UserStore, tokenStore store.TokenStore, organizationStore store.OrganizationStore, logger *slog.Logger) *UserMiddleware {
 	return &UserMiddleware{
-		UserStore: userStore,
+		UserStore:         userStore,
+		TokenStore:        tokenStore,
+		OrganizationStore: organizationStore,
+		Logger:            logger,
 	}
 }
 
 type contextKey string
 
-const UserContextKey = contextKey("use")
+const (
+	UserContextKey       = contextKey("use")
+	TokenContextKey      = contextKey("token")
+	MembershipContextKey = contextKey("membership")
+)
+
+// OrganizationHeader selects, by slug, the organization a request acts in.
+// Without it requests act in the user's personal space.
+const OrganizationHeader = "X-Organization"
 
 func SetUser(r *http.Request, user *models.User) *http.Request {
 	ctx := context.WithValue(r.Context(), UserContextKey, user)
@@ -40,6 +61,42 @@ func GetUser(r *http.Request) *models.User {
 	return user
 }
 
+// SetToken stores the bearer token the request was authenticated with.
+func SetToken(r *http.Request, token string) *http.Request {
+	ctx := context.WithValue(r.Context(), TokenContextKey, token)
+	return r.WithContext(ctx)
+}
+
+// GetToken returns the bearer token of the request, or "" for anonymous
+// requests.
+func GetToken(r *http.Request) string {
+	token, _ := r.Context().Value(TokenContextKey).(string)
+	return token
+}
+
+// SetMembership stores the organization the request acts in.
+func SetMembership(r *http.Request, membership *models.Membership) *http.Request {
+	ctx := context.WithValue(r.Context(), MembershipContextKey, membership)
+	return r.WithContext(ctx)
+}
+
+// GetMembership returns the current user's membership of the organization
+// the request acts in, or nil in the personal space.
+func GetMembership(r *http.Request) *models.Membership {
+	membership, _ := r.Context().Value(MembershipContextKey).(*models.Membership)
+	return membership
+}
+
+// OrganizationID returns the id of the organization the request acts in,
+// or nil in the personal space. It is what tenant-scoped stores expect.
+func OrganizationID(r *http.Request) *int64 {
+	membership := GetMembership(r)
+	if membership == nil {
+		return nil
+	}
+	return &membership.Organization.ID
+}
+
 func (um *UserMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
@@ -61,7 +118,17 @@ func (um *UserMiddleware) Authenticate(next http.Handler) http.Handler {
 		}
 
 		token := headerParts[1]
-		user, err := um.UserStore.GetUserByToken(tokens.ScopeAuth, token)
+		user, err := um.UserStore.GetUserByToken(r.Context(), tokens.ScopeAuth, token)
+		if errors.Is(err, context.DeadlineExceeded) {
+			// a slow database says nothing about the token, so don't make
+			// the client drop it
+			um.Logger.Error("timed out looking up token", "error", err)
+			utils.WriteJSON(w, http.StatusGatewayTimeout, utils.Envelope{
+				"status":  "error",
+				"message": "The server took too long to respond. Please try again later.",
+			})
+			return
+		}
 		if err != nil || user == nil {
 			utils.WriteJSON(w, http.StatusUnauthorized, utils.Envelope{
 				"status":  "fail",
@@ -70,7 +137,20 @@ func (um *UserMiddleware) Authenticate(next http.Handler) http.Handler {
 			return
 		}
 
+		if user.Disabled {
+			utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+				"status":  "fail",
+				"message": "Your account has been disabled.",
+			})
+			return
+		}
+
+		if err := um.TokenStore.TouchToken(r.Context(), token, lastUsedInterval); err != nil {
+			um.Logger.Error("failed to record token use", "user_id", user.ID, "error", err)
+		}
+
 		r = SetUser(r, user)
+		r = SetToken(r, token)
 		next.ServeHTTP(w, r)
 		return
 	})
@@ -91,3 +171,89 @@ func (um *UserMiddleware) RequireUser(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequireActivatedUser lets through only logged in users who have activated
+// their account.
+func (um *UserMiddleware) RequireActivatedUser(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUser(r)
+
+		if !user.Activated {
+			utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+				"status":  "fail",
+				"message": "Your account must be activated to access this route.",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return um.RequireUser(fn)
+}
+
+// RequirePermission lets through only logged in users whose role grants
+// permission.
+func (um *UserMiddleware) RequirePermission(permission models.Permission, next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := GetUser(r)
+
+		if !user.HasPermission(permission) {
+			um.Logger.Warn("permission denied", "user_id", user.ID, "role", user.Role, "permission", permission)
+			utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+				"status":  "fail",
+				"message": "You do not have permission to access this route.",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return um.RequireUser(fn)
+}
+
+// Organization resolves the OrganizationHeader of an authenticated request
+// and makes sure the user is a member. It must run after Authenticate.
+func (um *UserMiddleware) Organization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", OrganizationHeader)
+		slug := r.Header.Get(OrganizationHeader)
+		if slug == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := GetUser(r)
+		if user.IsAnonymous() {
+			utils.WriteJSON(w, http.StatusUnauthorized, utils.Envelope{
+				"status":  "fail",
+				"message": "You must be logged in to act in an organization.",
+			})
+			return
+		}
+
+		membership, err := um.OrganizationStore.GetMembership(r.Context(), slug, user.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// outsiders can't tell organizations they aren't in from
+				// ones that don't exist
+				utils.WriteJSON(w, http.StatusForbidden, utils.Envelope{
+					"status":  "fail",
+					"message": "You are not a member of this organization.",
+				})
+				return
+			}
+
+			um.Logger.Error("failed to fetch organization membership", "slug", slug, "user_id", user.ID, "error", err)
+			utils.WriteJSON(w, utils.ServerErrorStatus(err), utils.Envelope{
+				"status":  "error",
+				"message": "Failed to load the organization due to a server error. Please try again later.",
+			})
+			return
+		}
+
+		r = SetMembership(r, membership)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,86 @@
+package middleware_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+// organizationStore knows a single organization and its members. Only
+// GetMembership is used by the middleware.
+type organizationStore struct {
+	store.OrganizationStore
+	organization models.Organization
+	members      map[int64]string
+}
+
+func (s *organizationStore) GetMembership(ctx context.Context, slug string, userID int64) (*models.Membership, error) {
+	role, ok := s.members[userID]
+	if slug != s.organization.Slug || !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &models.Membership{Organization: s.organization, Role: role}, nil
+}
+
+func TestOrganization(t *testing.T) {
+	owner := &models.User{ID: 1, Username: "owner"}
+	outsider := &models.User{ID: 2, Username: "outsider"}
+
+	organization := models.Organization{ID: 7, Name: "Iron Gym", Slug: "iron-gym"}
+	organizations := &organizationStore{
+		organization: organization,
+		members:      map[int64]string{owner.ID: models.OrganizationRoleOwner},
+	}
+
+	um := middleware.NewUserMiddleware(nil, nil, organizations, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		slug     string
+		wantCode int
+		wantOrg  *int64
+	}{
+		{"no header", outsider, "", http.StatusOK, nil},
+		{"member", owner, "iron-gym", http.StatusOK, &organization.ID},
+		{"non-member", outsider, "iron-gym", http.StatusForbidden, nil},
+		{"unknown organization", owner, "no-such-gym", http.StatusForbidden, nil},
+		{"anonymous", models.AnonymousUser, "iron-gym", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				got := middleware.OrganizationID(r)
+				if (got == nil) != (tt.wantOrg == nil) || (got != nil && *got != *tt.wantOrg) {
+					t.Errorf("OrganizationID = %v, want %v", got, tt.wantOrg)
+				}
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/workouts", nil)
+			if tt.slug != "" {
+				r.Header.Set(middleware.OrganizationHeader, tt.slug)
+			}
+			r = middleware.SetUser(r, tt.user)
+			w := httptest.NewRecorder()
+
+			um.Organization(next).ServeHTTP(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if called != (tt.wantCode == http.StatusOK) {
+				t.Errorf("next handler called = %v, want %v", called, !called)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	CoachLinkPending = "pending"
+	CoachLinkActive  = "active"
+)
+
+// CoachLink connects a coach to an athlete. A coach invites, the link stays
+// pending until the athlete accepts it, and either side can remove it.
+type CoachLink struct {
+	ID              int64      `json:"id"`
+	CoachID         int64      `json:"coach_id"`
+	CoachUsername   string     `json:"coach_username"`
+	AthleteID       int64      `json:"athlete_id"`
+	AthleteUsername string     `json:"athlete_username"`
+	Status          string     `json:"status"`
+	CreatedAt       time.Time  `json:"created_at"`
+	AcceptedAt      *time.Time `json:"accepted_at"`
+}
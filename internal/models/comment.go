@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	ReactionLike   = "like"
+	ReactionFire   = "fire"
+	ReactionStrong = "strong"
+	ReactionClap   = "clap"
+)
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	WorkoutID int64     `json:"workout_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Reaction struct {
+	WorkoutID int64     `json:"workout_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func IsValidReaction(kind string) bool {
+	switch kind {
+	case ReactionLike, ReactionFire, ReactionStrong, ReactionClap:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package models
+
+import "strings"
+
+type Exercise struct {
+	ID               int64    `json:"id"`
+	Name             string   `json:"name"`
+	Aliases          []string `json:"aliases"`
+	PrimaryMuscles   []string `json:"primary_muscles"`
+	SecondaryMuscles []string `json:"secondary_muscles"`
+	Equipment        string   `json:"equipment"`
+	MovementType     string   `json:"movement_type"`
+	OrganizationID   *int64   `json:"organization_id"`
+}
+
+// NormalizeExerciseName lowercases the name and collapses whitespace so
+// "Bench  Press " and "bench press" resolve to the same exercise.
+func NormalizeExerciseName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleCoach  = "coach"
+	OrganizationRoleMember = "member"
+)
+
+func IsValidOrganizationRole(role string) bool {
+	switch role {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleCoach, OrganizationRoleMember:
+		return true
+	}
+	return false
+}
+
+// Organization is a tenant, typically one gym. Workouts, templates and
+// custom exercises created inside it are only visible inside it.
+type Organization struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganizationMember struct {
+	OrganizationID int64     `json:"organization_id"`
+	UserID         int64     `json:"user_id"`
+	Username       string    `json:"username"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+// Membership is an organization as seen by one of its members.
+type Membership struct {
+	Organization Organization `json:"organization"`
+	Role         string       `json:"role"`
+}
+
+func (m *Membership) CanManageMembers() bool {
+	return m.Role == OrganizationRoleOwner || m.Role == OrganizationRoleAdmin
+}
+
+func (m *Membership) CanManageExercises() bool {
+	return m.CanManageMembers() || m.Role == OrganizationRoleCoach
+}
@@ -11,8 +11,10 @@ type Password struct {
 	Hash      []byte
 }
 
-func (p *Password) Set(plainTextPassword string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(plainTextPassword), 13)
+// Set hashes the password with the given bcrypt cost. Existing hashes keep
+// the cost they were created with, Match reads it from the hash.
+func (p *Password) Set(plainTextPassword string, cost int) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plainTextPassword), cost)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type PersonalRecord struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"user_id"`
+	WorkoutID     int64     `json:"workout_id"`
+	ExerciseID    *int64    `json:"exercise_id"`
+	ExerciseName  string    `json:"exercise_name"`
+	RecordType    string    `json:"record_type"`
+	Value         float64   `json:"value"`
+	Weight        *float64  `json:"weight"`
+	Reps          *int      `json:"reps"`
+	PreviousValue *float64  `json:"previous_value,omitempty"`
+	AchievedAt    time.Time `json:"achieved_at"`
+}
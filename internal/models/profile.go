@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PublicProfile is what anyone can see of a user. It deliberately has no
+// email or password fields so handlers can't leak them by accident.
+type PublicProfile struct {
+	ID                 int64      `json:"id"`
+	Username           string     `json:"username"`
+	Bio                string     `json:"bio"`
+	JoinedAt           time.Time  `json:"joined_at"`
+	PublicWorkoutCount int        `json:"public_workout_count"`
+	FollowerCount      int        `json:"follower_count"`
+	FollowingCount     int        `json:"following_count"`
+	RecentWorkouts     []*Workout `json:"recent_workouts,omitempty"`
+}
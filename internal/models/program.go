@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+const (
+	EnrollmentActive    = "active"
+	EnrollmentCancelled = "cancelled"
+)
+
+type Program struct {
+	ID          int64  `json:"id"`
+	UserID      int64  `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Weeks       int    `json:"weeks"`
+	DeloadWeeks []int  `json:"deload_weeks"`
+	// DeloadPercentage scales every weight during deload weeks.
+	DeloadPercentage float64          `json:"deload_percentage"`
+	IsPublic         bool             `json:"is_public"`
+	Sessions         []ProgramSession `json:"sessions"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
+
+// ProgramSession schedules a template on a day (1-7) of a program week.
+type ProgramSession struct {
+	ID         int64 `json:"id"`
+	ProgramID  int64 `json:"program_id"`
+	Week       int   `json:"week"`
+	Day        int   `json:"day"`
+	TemplateID int64 `json:"template_id"`
+	// IntensityPercentage prescribes weights as a percentage of the
+	// athlete's estimated 1RM instead of the template's targets.
+	IntensityPercentage *float64 `json:"intensity_percentage"`
+}
+
+type ProgramEnrollment struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	ProgramID int64     `json:"program_id"`
+	StartDate time.Time `json:"start_date"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SessionWorkout links a workout back to the program session it fulfils.
+type SessionWorkout struct {
+	WorkoutID        int64     `json:"workout_id"`
+	ProgramSessionID int64     `json:"program_session_id"`
+	Status           string    `json:"status"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func (p *Program) IsDeloadWeek(week int) bool {
+	for _, w := range p.DeloadWeeks {
+		if w == week {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Program) Session(week, day int) *ProgramSession {
+	for i := range p.Sessions {
+		if p.Sessions[i].Week == week && p.Sessions[i].Day == day {
+			return &p.Sessions[i]
+		}
+	}
+	return nil
+}
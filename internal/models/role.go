@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	RoleUser  = "user"
+	RoleCoach = "coach"
+	RoleAdmin = "admin"
+)
+
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleCoach, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+type Permission string
+
+const (
+	PermissionCoachAthletes  Permission = "athletes:coach"
+	PermissionManageUsers    Permission = "users:manage"
+	PermissionManageWorkouts Permission = "workouts:manage"
+	PermissionViewAdminLog   Permission = "admin_actions:read"
+)
+
+// rolePermissions lists what each role may do on top of what every logged in
+// user can do with their own data.
+var rolePermissions = map[string][]Permission{
+	RoleUser:  {},
+	RoleCoach: {PermissionCoachAthletes},
+	RoleAdmin: {
+		PermissionCoachAthletes,
+		PermissionManageUsers,
+		PermissionManageWorkouts,
+		PermissionViewAdminLog,
+	},
+}
+
+func (u *User) HasPermission(permission Permission) bool {
+	for _, p := range rolePermissions[u.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	AdminActionDisableUser   = "disable_user"
+	AdminActionEnableUser    = "enable_user"
+	AdminActionChangeRole    = "change_role"
+	AdminActionRevokeTokens  = "revoke_tokens"
+	AdminActionDeleteWorkout = "delete_workout"
+)
+
+// AdminAction records something an admin did to another user's data.
+// AdminID is nil once the admin's account has been deleted.
+type AdminAction struct {
+	ID         int64          `json:"id"`
+	AdminID    *int64         `json:"admin_id"`
+	Action     string         `json:"action"`
+	TargetType string         `json:"target_type"`
+	TargetID   int64          `json:"target_id"`
+	Details    map[string]any `json:"details"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
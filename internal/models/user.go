@@ -10,6 +10,10 @@ type User struct {
 	Email        string    `json:"email"`
 	PasswordHash Password  `json:"-"`
 	Bio          string    `json:"bio"`
+	Timezone     string    `json:"timezone"`
+	Activated    bool      `json:"activated"`
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
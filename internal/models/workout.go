@@ -2,14 +2,53 @@ package models
 
 import "time"
 
+const (
+	VisibilityPrivate   = "private"
+	VisibilityFollowers = "followers"
+	VisibilityPublic    = "public"
+	VisibilityUnlisted  = "unlisted" // reachable only through its share link
+)
+
+const (
+	// StatusPlanned workouts haven't been performed yet, so they don't
+	// count towards records or statistics.
+	StatusPlanned   = "planned"
+	StatusCompleted = "completed"
+)
+
 type Workout struct {
-	ID              int64          `json:"id"`
-	UserID          int64          `json:"user_id"`
-	Title           string         `json:"title"`
-	Description     string         `json:"description"`
-	DurationMinutes int            `json:"duration_minutes"`
-	CaloriesBurned  int            `json:"calories_burned"`
-	CreatedAt       time.Time      `json:"created_at"`
-	UpdatedAt       time.Time      `json:"updated_at"`
-	Entries         []WorkoutEntry `json:"entries"`
+	ID               int64          `json:"id"`
+	UserID           int64          `json:"user_id"`
+	OrganizationID   *int64         `json:"organization_id"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	DurationMinutes  int            `json:"duration_minutes"`
+	CaloriesBurned   int            `json:"calories_burned"`
+	Visibility       string         `json:"visibility"`
+	Status           string         `json:"status"`
+	TemplateID       *int64         `json:"template_id"`
+	EnrollmentID     *int64         `json:"enrollment_id"`
+	ProgramSessionID *int64         `json:"program_session_id"`
+	ShareToken       string         `json:"share_token,omitempty"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	Entries          []WorkoutEntry `json:"entries"`
+	CommentCount     int            `json:"comment_count"`
+	ReactionCounts   map[string]int `json:"reaction_counts"`
+
+	// NewRecords lists the personal records set by this workout. It is only
+	// filled in when the workout is created or updated.
+	NewRecords []PersonalRecord `json:"new_records,omitempty"`
+}
+
+func IsValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPrivate, VisibilityFollowers, VisibilityPublic, VisibilityUnlisted:
+		return true
+	}
+	return false
+}
+
+func IsValidStatus(status string) bool {
+	return status == StatusPlanned || status == StatusCompleted
 }
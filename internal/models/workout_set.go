@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+type WorkoutSet struct {
+	ID              int64     `json:"id"`
+	WorkoutEntryID  int64     `json:"workout_entry_id"`
+	SetNumber       int       `json:"set_number"`
+	Reps            *int      `json:"reps"`
+	Weight          *float64  `json:"weight"`
+	DurationSeconds *int      `json:"duration_seconds"`
+	DistanceMeters  *float64  `json:"distance_meters"`
+	RPE             *float64  `json:"rpe"`
+	RestSeconds     *int      `json:"rest_seconds"`
+	Failed          bool      `json:"failed"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type WorkoutTemplate struct {
+	ID             int64                  `json:"id"`
+	UserID         int64                  `json:"user_id"`
+	OrganizationID *int64                 `json:"organization_id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Entries        []WorkoutTemplateEntry `json:"entries"`
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+type WorkoutTemplateEntry struct {
+	ID                    int64    `json:"id"`
+	TemplateID            int64    `json:"template_id"`
+	ExerciseID            *int64   `json:"exercise_id"`
+	ExerciseName          string   `json:"exercise_name"`
+	TargetSets            int      `json:"target_sets"`
+	TargetReps            *int     `json:"target_reps"`
+	TargetWeight          *float64 `json:"target_weight"`
+	TargetDurationSeconds *int     `json:"target_duration_seconds"`
+	RestSeconds           *int     `json:"rest_seconds"`
+	// WeightIncrement is added to last session's weight once every target
+	// rep was completed. Leave it empty to repeat the same weight.
+	WeightIncrement *float64  `json:"weight_increment"`
+	Notes           string    `json:"notes"`
+	OrderIndex      int       `json:"order_index"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
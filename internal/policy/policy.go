@@ -0,0 +1,53 @@
+// Package policy decides who may act on a user's workouts. Owners may do
+// anything and coaches act on behalf of the athletes who accepted them.
+// Admin moderation goes through the admin API instead.
+package policy
+
+import (
+	"context"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionPlan is creating a planned workout in someone's log.
+	ActionPlan Action = "plan"
+)
+
+// CoachLinks is the part of the coach store the policy needs.
+type CoachLinks interface {
+	IsCoach(ctx context.Context, coachID, athleteID int64) (bool, error)
+}
+
+type Policy struct {
+	links CoachLinks
+}
+
+func New(links CoachLinks) *Policy {
+	return &Policy{
+		links: links,
+	}
+}
+
+// CanActOnWorkouts reports whether user may perform action on workouts owned
+// by ownerID. Visibility to followers and the public is the store's concern;
+// this only covers access that comes from who the user is.
+func (p *Policy) CanActOnWorkouts(ctx context.Context, user *models.User, ownerID int64, action Action) (bool, error) {
+	if user.IsAnonymous() {
+		return false, nil
+	}
+	if user.ID == ownerID {
+		return true, nil
+	}
+	if action == ActionDelete {
+		// coaches can see, edit and plan, but deleting stays with the athlete
+		return false, nil
+	}
+
+	return p.links.IsCoach(ctx, user.ID, ownerID)
+}
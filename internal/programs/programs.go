@@ -0,0 +1,154 @@
+package programs
+
+import (
+	"math"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+const DaysPerWeek = 7
+
+// WeightStep is what prescribed weights are rounded to, the smallest jump
+// most gyms can load.
+const WeightStep = 2.5
+
+// Position returns the program week and day (both 1-based) that today falls
+// on for an enrollment starting on start. ok is false before the start date
+// and after the last week.
+func Position(program *models.Program, start, today time.Time) (week, day int, ok bool) {
+	days := daysBetween(start, today)
+	if days < 0 {
+		return 0, 0, false
+	}
+
+	week = days/DaysPerWeek + 1
+	day = days%DaysPerWeek + 1
+	return week, day, week <= program.Weeks
+}
+
+// SessionDate returns the calendar date a session is scheduled on.
+func SessionDate(start time.Time, week, day int) time.Time {
+	return start.AddDate(0, 0, (week-1)*DaysPerWeek+day-1)
+}
+
+type SessionAdherence struct {
+	SessionID  int64   `json:"session_id"`
+	Week       int     `json:"week"`
+	Day        int     `json:"day"`
+	TemplateID int64   `json:"template_id"`
+	Date       string  `json:"date"`
+	Status     string  `json:"status"`
+	WorkoutIDs []int64 `json:"workout_ids"`
+}
+
+const (
+	SessionCompleted = "completed"
+	SessionMissed    = "missed"
+	SessionUpcoming  = "upcoming"
+)
+
+type Adherence struct {
+	Scheduled  int                `json:"scheduled"`
+	Completed  int                `json:"completed"`
+	Missed     int                `json:"missed"`
+	Upcoming   int                `json:"upcoming"`
+	Percentage float64            `json:"percentage"`
+	Sessions   []SessionAdherence `json:"sessions"`
+}
+
+// ComputeAdherence matches the enrollment's workouts against the schedule.
+// A session counts as completed once any completed workout was logged for
+// it, missed once its date has passed without one and upcoming otherwise.
+// Percentage is completed over sessions that are already due.
+func ComputeAdherence(program *models.Program, start, today time.Time, workouts []models.SessionWorkout) *Adherence {
+	bySession := map[int64][]models.SessionWorkout{}
+	for _, w := range workouts {
+		bySession[w.ProgramSessionID] = append(bySession[w.ProgramSessionID], w)
+	}
+
+	adherence := &Adherence{Sessions: make([]SessionAdherence, 0, len(program.Sessions))}
+	for _, session := range program.Sessions {
+		date := SessionDate(start, session.Week, session.Day)
+		sa := SessionAdherence{
+			SessionID:  session.ID,
+			Week:       session.Week,
+			Day:        session.Day,
+			TemplateID: session.TemplateID,
+			Date:       date.Format(time.DateOnly),
+			WorkoutIDs: []int64{},
+		}
+
+		completed := false
+		for _, w := range bySession[session.ID] {
+			sa.WorkoutIDs = append(sa.WorkoutIDs, w.WorkoutID)
+			if w.Status == models.StatusCompleted {
+				completed = true
+			}
+		}
+
+		adherence.Scheduled++
+		switch {
+		case completed:
+			sa.Status = SessionCompleted
+			adherence.Completed++
+		case daysBetween(date, today) > 0:
+			sa.Status = SessionMissed
+			adherence.Missed++
+		default:
+			sa.Status = SessionUpcoming
+			adherence.Upcoming++
+		}
+		adherence.Sessions = append(adherence.Sessions, sa)
+	}
+
+	if due := adherence.Completed + adherence.Missed; due > 0 {
+		adherence.Percentage = math.Round(float64(adherence.Completed)/float64(due)*10000) / 100
+	}
+	return adherence
+}
+
+// Prescribe overwrites the weights of a workout started from a session.
+// With an intensity percentage each entry is loaded relative to its
+// estimated 1RM from oneRepMaxes; entries without an estimate keep the
+// template's weight. During deload weeks every weight is scaled down by the
+// program's deload percentage on top.
+func Prescribe(workout *models.Workout, program *models.Program, session *models.ProgramSession, oneRepMaxes map[int]float64) {
+	for i := range workout.Entries {
+		entry := &workout.Entries[i]
+
+		weight := entry.Weight
+		if session.IntensityPercentage != nil {
+			if oneRepMax, ok := oneRepMaxes[i]; ok {
+				w := oneRepMax * *session.IntensityPercentage / 100
+				weight = &w
+			}
+		}
+		if weight != nil && program.IsDeloadWeek(session.Week) {
+			w := *weight * program.DeloadPercentage / 100
+			weight = &w
+		}
+		if weight == nil {
+			continue
+		}
+
+		rounded := RoundWeight(*weight)
+		entry.Weight = &rounded
+		for j := range entry.Sets {
+			w := rounded
+			entry.Sets[j].Weight = &w
+		}
+	}
+}
+
+// RoundWeight rounds to the nearest WeightStep.
+func RoundWeight(weight float64) float64 {
+	return math.Round(weight/WeightStep) * WeightStep
+}
+
+// daysBetween counts calendar days from a to b, ignoring the time of day.
+func daysBetween(a, b time.Time) int {
+	ad := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	bd := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(bd.Sub(ad).Hours() / 24)
+}
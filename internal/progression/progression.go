@@ -0,0 +1,144 @@
+package progression
+
+import (
+	"math"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+// Instantiate builds a planned workout from the template with one set per
+// target set. When progressive is true and last (the previous completed
+// workout from the same template) is given, weights continue from last time
+// and go up by the entry's weight increment once every target was hit.
+func Instantiate(template *models.WorkoutTemplate, last *models.Workout, progressive bool) *models.Workout {
+	templateID := template.ID
+	workout := &models.Workout{
+		UserID:      template.UserID,
+		TemplateID:  &templateID,
+		Title:       template.Name,
+		Description: template.Description,
+		Visibility:  models.VisibilityPrivate,
+		Status:      models.StatusPlanned,
+		Entries:     make([]models.WorkoutEntry, 0, len(template.Entries)),
+	}
+
+	for _, target := range template.Entries {
+		var previous *models.WorkoutEntry
+		if last != nil {
+			previous = findEntry(last, target)
+		}
+
+		weight := target.TargetWeight
+		if progressive {
+			weight = NextWeight(target, previous)
+		}
+
+		entry := models.WorkoutEntry{
+			ExerciseID:      target.ExerciseID,
+			ExerciseName:    target.ExerciseName,
+			Reps:            copyInt(target.TargetReps),
+			DurationSeconds: copyInt(target.TargetDurationSeconds),
+			Weight:          copyFloat(weight),
+			Notes:           target.Notes,
+			OrderIndex:      target.OrderIndex,
+			Sets:            make([]models.WorkoutSet, 0, target.TargetSets),
+		}
+		for i := 0; i < target.TargetSets; i++ {
+			entry.Sets = append(entry.Sets, models.WorkoutSet{
+				SetNumber:       i + 1,
+				Reps:            copyInt(target.TargetReps),
+				DurationSeconds: copyInt(target.TargetDurationSeconds),
+				Weight:          copyFloat(weight),
+				RestSeconds:     copyInt(target.RestSeconds),
+			})
+		}
+		workout.Entries = append(workout.Entries, entry)
+	}
+
+	return workout
+}
+
+// NextWeight picks the weight for the next session. Without history it falls
+// back to the template's target weight.
+func NextWeight(target models.WorkoutTemplateEntry, previous *models.WorkoutEntry) *float64 {
+	if previous == nil {
+		return target.TargetWeight
+	}
+
+	lastWeight := heaviestWeight(previous)
+	if lastWeight == nil {
+		return target.TargetWeight
+	}
+
+	if target.WeightIncrement != nil && HitAllTargets(target, previous) {
+		next := math.Round((*lastWeight+*target.WeightIncrement)*100) / 100
+		return &next
+	}
+	return lastWeight
+}
+
+// HitAllTargets reports whether the previous session completed every target
+// set with at least the target reps or duration.
+func HitAllTargets(target models.WorkoutTemplateEntry, previous *models.WorkoutEntry) bool {
+	completed := 0
+	for _, set := range previous.Sets {
+		if set.Failed {
+			return false
+		}
+		if target.TargetReps != nil && (set.Reps == nil || *set.Reps < *target.TargetReps) {
+			return false
+		}
+		if target.TargetDurationSeconds != nil &&
+			(set.DurationSeconds == nil || *set.DurationSeconds < *target.TargetDurationSeconds) {
+			return false
+		}
+		completed++
+	}
+	return completed >= target.TargetSets
+}
+
+func findEntry(workout *models.Workout, target models.WorkoutTemplateEntry) *models.WorkoutEntry {
+	name := models.NormalizeExerciseName(target.ExerciseName)
+	for i := range workout.Entries {
+		entry := &workout.Entries[i]
+		if target.ExerciseID != nil && entry.ExerciseID != nil && *target.ExerciseID == *entry.ExerciseID {
+			return entry
+		}
+		if models.NormalizeExerciseName(entry.ExerciseName) == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+func heaviestWeight(entry *models.WorkoutEntry) *float64 {
+	var heaviest *float64
+	for _, set := range entry.Sets {
+		if set.Failed || set.Weight == nil {
+			continue
+		}
+		if heaviest == nil || *set.Weight > *heaviest {
+			heaviest = copyFloat(set.Weight)
+		}
+	}
+	if heaviest == nil {
+		return copyFloat(entry.Weight)
+	}
+	return heaviest
+}
+
+func copyInt(v *int) *int {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func copyFloat(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
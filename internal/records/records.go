@@ -0,0 +1,122 @@
+package records
+
+import (
+	"math"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+const (
+	TypeMaxWeight   = "max_weight"
+	TypeMaxReps     = "max_reps" // most reps at a given weight
+	TypeEpley1RM    = "estimated_1rm_epley"
+	TypeBrzycki1RM  = "estimated_1rm_brzycki"
+	TypeMaxDuration = "max_duration"
+)
+
+// brzyckiMaxReps is where the Brzycki formula stops making sense; at 37 reps
+// it divides by zero.
+const brzyckiMaxReps = 36
+
+// Epley estimates the one-rep max as w * (1 + r/30).
+func Epley(weight float64, reps int) float64 {
+	if reps == 1 {
+		return weight
+	}
+	return round(weight * (1 + float64(reps)/30))
+}
+
+// Brzycki estimates the one-rep max as w * 36 / (37 - r).
+func Brzycki(weight float64, reps int) float64 {
+	if reps == 1 {
+		return weight
+	}
+	return round(weight * 36 / float64(37-reps))
+}
+
+// Candidates returns the best performance of every record type achieved in
+// the entry. Failed sets don't count. Entries without logged sets fall back
+// to the entry-level reps, weight and duration.
+func Candidates(entry models.WorkoutEntry) []models.PersonalRecord {
+	sets := entry.Sets
+	if len(sets) == 0 {
+		sets = []models.WorkoutSet{{
+			Reps:            entry.Reps,
+			Weight:          entry.Weight,
+			DurationSeconds: entry.DurationSeconds,
+		}}
+	}
+
+	best := map[string]*models.PersonalRecord{}
+	repsAtWeight := map[float64]*models.PersonalRecord{}
+	consider := func(recordType string, value float64, weight *float64, reps *int) {
+		if current, ok := best[recordType]; ok && current.Value >= value {
+			return
+		}
+		best[recordType] = newCandidate(recordType, value, weight, reps)
+	}
+
+	for _, set := range sets {
+		if set.Failed {
+			continue
+		}
+
+		if set.DurationSeconds != nil && *set.DurationSeconds > 0 {
+			consider(TypeMaxDuration, float64(*set.DurationSeconds), nil, nil)
+		}
+
+		if set.Weight == nil || *set.Weight <= 0 || set.Reps == nil || *set.Reps <= 0 {
+			continue
+		}
+		weight, reps := *set.Weight, *set.Reps
+
+		consider(TypeMaxWeight, weight, set.Weight, set.Reps)
+		consider(TypeEpley1RM, Epley(weight, reps), set.Weight, set.Reps)
+		if reps <= brzyckiMaxReps {
+			consider(TypeBrzycki1RM, Brzycki(weight, reps), set.Weight, set.Reps)
+		}
+
+		if current, ok := repsAtWeight[weight]; !ok || current.Value < float64(reps) {
+			repsAtWeight[weight] = newCandidate(TypeMaxReps, float64(reps), set.Weight, set.Reps)
+		}
+	}
+
+	candidates := make([]models.PersonalRecord, 0, len(best)+len(repsAtWeight))
+	for _, recordType := range []string{TypeMaxWeight, TypeEpley1RM, TypeBrzycki1RM, TypeMaxDuration} {
+		if record, ok := best[recordType]; ok {
+			candidates = append(candidates, *record)
+		}
+	}
+
+	weights := make([]float64, 0, len(repsAtWeight))
+	for weight := range repsAtWeight {
+		weights = append(weights, weight)
+	}
+	sort.Float64s(weights)
+	for _, weight := range weights {
+		candidates = append(candidates, *repsAtWeight[weight])
+	}
+	return candidates
+}
+
+func newCandidate(recordType string, value float64, weight *float64, reps *int) *models.PersonalRecord {
+	record := &models.PersonalRecord{
+		RecordType: recordType,
+		Value:      value,
+	}
+	if weight != nil {
+		w := *weight
+		record.Weight = &w
+	}
+	if reps != nil {
+		r := *reps
+		record.Reps = &r
+	}
+	return record
+}
+
+// round keeps two decimals, matching the precision of the records table.
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -2,24 +2,112 @@ package routes
 
 import (
 	"github.com/agkmw/workout-service/internal/app"
+	"github.com/agkmw/workout-service/internal/middleware"
+	"github.com/agkmw/workout-service/internal/models"
 	"github.com/go-chi/chi/v5"
 )
 
 func SetupRoutes(app *app.Application) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(middleware.CORS(app.Config.CORS.AllowedOrigins))
 
 	r.Group(func(r chi.Router) {
 		r.Use(app.Middleware.Authenticate)
+		r.Use(app.Middleware.Organization)
 
-		r.Get("/workouts/{id}", app.Middleware.RequireUser(app.WorkoutHandler.HandleGetWorkoutByID))
-		r.Put("/workouts/{id}", app.Middleware.RequireUser(app.WorkoutHandler.HandleUpdateWorkoutByID))
+		r.Get("/workouts/{id}", app.WorkoutHandler.HandleGetWorkoutByID)
+		r.Put("/workouts/{id}", app.Middleware.RequireActivatedUser(app.WorkoutHandler.HandleUpdateWorkoutByID))
 		r.Delete("/workouts/{id}", app.Middleware.RequireUser(app.WorkoutHandler.HandleDeleteWorkoutByID))
-		r.Post("/workouts", app.Middleware.RequireUser(app.WorkoutHandler.HandleCreateWorkout))
+		r.Get("/workouts", app.Middleware.RequireUser(app.WorkoutHandler.HandleListWorkouts))
+		r.Post("/workouts", app.Middleware.RequireActivatedUser(app.WorkoutHandler.HandleCreateWorkout))
+
+		r.Get("/workouts/{id}/comments", app.CommentHandler.HandleListComments)
+		r.Post("/workouts/{id}/comments", app.Middleware.RequireActivatedUser(app.CommentHandler.HandleCreateComment))
+		r.Delete("/workouts/{id}/comments/{commentID}", app.Middleware.RequireUser(app.CommentHandler.HandleDeleteComment))
+		r.Get("/workouts/{id}/reactions", app.ReactionHandler.HandleListReactions)
+		r.Put("/workouts/{id}/reactions/{kind}", app.Middleware.RequireActivatedUser(app.ReactionHandler.HandleAddReaction))
+		r.Delete("/workouts/{id}/reactions/{kind}", app.Middleware.RequireUser(app.ReactionHandler.HandleRemoveReaction))
+
+		r.Get("/users/me", app.Middleware.RequireUser(app.UserHandler.HandleGetCurrentUser))
+		r.Patch("/users/me", app.Middleware.RequireUser(app.UserHandler.HandleUpdateCurrentUser))
+		r.Delete("/users/me", app.Middleware.RequireUser(app.UserHandler.HandleDeleteCurrentUser))
+		r.Put("/users/me/password", app.Middleware.RequireUser(app.UserHandler.HandleChangePassword))
+		r.Get("/users/me/audit", app.Middleware.RequireUser(app.AuditHandler.HandleListMyEvents))
+		r.Post("/users/{username}/follow", app.Middleware.RequireUser(app.FollowHandler.HandleFollow))
+		r.Delete("/users/{username}/follow", app.Middleware.RequireUser(app.FollowHandler.HandleUnfollow))
+		r.Get("/feed", app.Middleware.RequireUser(app.FollowHandler.HandleGetFeed))
+
+		r.Get("/exercises", app.ExerciseHandler.HandleSearchExercises)
+		r.Post("/exercises", app.Middleware.RequireUser(app.ExerciseHandler.HandleCreateExercise))
+
+		r.Get("/organizations", app.Middleware.RequireUser(app.OrganizationHandler.HandleListOrganizations))
+		r.Post("/organizations", app.Middleware.RequireActivatedUser(app.OrganizationHandler.HandleCreateOrganization))
+		r.Get("/organizations/{slug}/members", app.Middleware.RequireUser(app.OrganizationHandler.HandleListMembers))
+		r.Post("/organizations/{slug}/members", app.Middleware.RequireUser(app.OrganizationHandler.HandleAddMember))
+		r.Delete("/organizations/{slug}/members/{username}", app.Middleware.RequireUser(app.OrganizationHandler.HandleRemoveMember))
+
+		r.Get("/users/me/records", app.Middleware.RequireUser(app.RecordHandler.HandleGetRecords))
+		r.Get("/users/me/records/{exercise}", app.Middleware.RequireUser(app.RecordHandler.HandleGetExerciseRecords))
+
+		r.Get("/stats/volume", app.Middleware.RequireUser(app.StatsHandler.HandleGetVolume))
+		r.Get("/stats/summary", app.Middleware.RequireUser(app.StatsHandler.HandleGetSummary))
+		r.Get("/stats/exercise/{name}/progression", app.Middleware.RequireUser(app.StatsHandler.HandleGetExerciseProgression))
+
+		r.Get("/templates", app.Middleware.RequireUser(app.TemplateHandler.HandleListTemplates))
+		r.Post("/templates", app.Middleware.RequireUser(app.TemplateHandler.HandleCreateTemplate))
+		r.Get("/templates/{id}", app.Middleware.RequireUser(app.TemplateHandler.HandleGetTemplateByID))
+		r.Put("/templates/{id}", app.Middleware.RequireUser(app.TemplateHandler.HandleUpdateTemplateByID))
+		r.Delete("/templates/{id}", app.Middleware.RequireUser(app.TemplateHandler.HandleDeleteTemplateByID))
+		r.Post("/templates/{id}/start", app.Middleware.RequireActivatedUser(app.TemplateHandler.HandleStartTemplate))
+
+		r.Get("/tokens", app.Middleware.RequireUser(app.TokenHandler.HandleListTokens))
+		r.Delete("/tokens/authentication", app.Middleware.RequireUser(app.TokenHandler.HandleRevokeToken))
+		r.Delete("/tokens/authentication/all", app.Middleware.RequireUser(app.TokenHandler.HandleRevokeAllTokens))
+
+		r.Get("/programs", app.Middleware.RequireUser(app.ProgramHandler.HandleListPrograms))
+		r.Post("/programs", app.Middleware.RequireUser(app.ProgramHandler.HandleCreateProgram))
+		r.Get("/programs/{id}", app.Middleware.RequireUser(app.ProgramHandler.HandleGetProgramByID))
+		r.Delete("/programs/{id}", app.Middleware.RequireUser(app.ProgramHandler.HandleDeleteProgramByID))
+		r.Post("/programs/{id}/enrollments", app.Middleware.RequireUser(app.ProgramHandler.HandleEnroll))
+
+		r.Get("/enrollments", app.Middleware.RequireUser(app.ProgramHandler.HandleListEnrollments))
+		r.Delete("/enrollments/{id}", app.Middleware.RequireUser(app.ProgramHandler.HandleCancelEnrollment))
+		r.Get("/enrollments/{id}/today", app.Middleware.RequireUser(app.ProgramHandler.HandleGetToday))
+		r.Post("/enrollments/{id}/today/start", app.Middleware.RequireActivatedUser(app.ProgramHandler.HandleStartToday))
+		r.Get("/enrollments/{id}/adherence", app.Middleware.RequireUser(app.ProgramHandler.HandleGetAdherence))
+
+		r.Post("/coaching/invitations", app.Middleware.RequirePermission(models.PermissionCoachAthletes, app.CoachHandler.HandleInviteAthlete))
+		r.Get("/coaching/athletes", app.Middleware.RequirePermission(models.PermissionCoachAthletes, app.CoachHandler.HandleListAthletes))
+		r.Get("/coaching/coaches", app.Middleware.RequireUser(app.CoachHandler.HandleListCoaches))
+		r.Put("/coaching/invitations/{id}/accepted", app.Middleware.RequireUser(app.CoachHandler.HandleAcceptInvitation))
+		r.Delete("/coaching/links/{id}", app.Middleware.RequireUser(app.CoachHandler.HandleDeleteLink))
+		r.Get("/athletes/{id}/workouts", app.Middleware.RequireUser(app.WorkoutHandler.HandleListAthleteWorkouts))
+		r.Post("/athletes/{id}/workouts", app.Middleware.RequireActivatedUser(app.WorkoutHandler.HandleCreateAthleteWorkout))
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Get("/users", app.Middleware.RequirePermission(models.PermissionManageUsers, app.AdminHandler.HandleListUsers))
+			r.Put("/users/{id}/disabled", app.Middleware.RequirePermission(models.PermissionManageUsers, app.AdminHandler.HandleUpdateUserDisabled))
+			r.Put("/users/{id}/role", app.Middleware.RequirePermission(models.PermissionManageUsers, app.AdminHandler.HandleUpdateUserRole))
+			r.Delete("/users/{id}/tokens", app.Middleware.RequirePermission(models.PermissionManageUsers, app.AdminHandler.HandleRevokeUserTokens))
+			r.Delete("/workouts/{id}", app.Middleware.RequirePermission(models.PermissionManageWorkouts, app.AdminHandler.HandleDeleteWorkout))
+			r.Get("/actions", app.Middleware.RequirePermission(models.PermissionViewAdminLog, app.AdminHandler.HandleListActions))
+			r.Get("/audit", app.Middleware.RequirePermission(models.PermissionViewAdminLog, app.AuditHandler.HandleListEvents))
+		})
 	})
 
 	r.Get("/health", app.HealthCheck)
+	r.Get("/workouts/shared/{token}", app.WorkoutHandler.HandleGetSharedWorkout)
 	r.Post("/users", app.UserHandler.HandleRegisterUser)
+	r.Get("/users", app.UserHandler.HandleSearchUsers)
+	r.Get("/users/{username}", app.UserHandler.HandleGetPublicProfile)
+	r.Get("/users/{username}/followers", app.FollowHandler.HandleListFollowers)
+	r.Get("/users/{username}/following", app.FollowHandler.HandleListFollowing)
 	r.Post("/tokens/authentication", app.TokenHandler.HandleCreateToken)
+	r.Post("/tokens/refresh", app.TokenHandler.HandleRefreshToken)
+	r.Post("/tokens/password-reset", app.TokenHandler.HandleRequestPasswordReset)
+	r.Put("/users/password", app.UserHandler.HandleResetPassword)
+	r.Post("/tokens/activation", app.TokenHandler.HandleResendActivation)
+	r.Put("/users/activated", app.UserHandler.HandleActivateUser)
 
 	return r
 }
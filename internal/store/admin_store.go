@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type PostgresAdminStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewPostgresAdminStore(db *sql.DB, queryTimeout time.Duration) *PostgresAdminStore {
+	return &PostgresAdminStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (pg *PostgresAdminStore) RecordAction(ctx context.Context, action *models.AdminAction) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	if action.Details == nil {
+		action.Details = map[string]any{}
+	}
+	details, err := json.Marshal(action.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO admin_actions (admin_id, action, target_type, target_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return pg.db.QueryRowContext(
+		ctx,
+		query,
+		action.AdminID,
+		action.Action,
+		action.TargetType,
+		action.TargetID,
+		details,
+	).Scan(
+		&action.ID,
+		&action.CreatedAt,
+	)
+}
+
+// ListActions returns the admin log, most recent first.
+func (pg *PostgresAdminStore) ListActions(ctx context.Context, limit, offset int) ([]*models.AdminAction, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, admin_id, action, target_type, target_id, details, created_at
+		FROM admin_actions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := pg.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []*models.AdminAction{}
+	for rows.Next() {
+		action := &models.AdminAction{}
+		var details []byte
+		if err := rows.Scan(
+			&action.ID,
+			&action.AdminID,
+			&action.Action,
+			&action.TargetType,
+			&action.TargetID,
+			&details,
+			&action.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &action.Details); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// DeleteWorkout deletes the workout whichever organization it belongs to.
+// Workout stores only ever see one organization.
+func (pg *PostgresAdminStore) DeleteWorkout(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(ctx, `DELETE FROM workouts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var ErrCoachLinkExists = errors.New("coach link already exists")
+
+type PostgresCoachStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewPostgresCoachStore(db *sql.DB, queryTimeout time.Duration) *PostgresCoachStore {
+	return &PostgresCoachStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// coachLinkColumns is the select list scanCoachLink expects, with
+// coach_links aliased as cl and the coach and athlete joined as c and a.
+const coachLinkColumns = `
+	cl.id, cl.coach_id, c.username, cl.athlete_id, a.username,
+	cl.status, cl.created_at, cl.accepted_at
+`
+
+const coachLinkFrom = `
+	FROM coach_links cl
+	INNER JOIN users c ON c.id = cl.coach_id
+	INNER JOIN users a ON a.id = cl.athlete_id
+`
+
+func scanCoachLink(row rowScanner) (*models.CoachLink, error) {
+	link := &models.CoachLink{}
+	if err := row.Scan(
+		&link.ID,
+		&link.CoachID,
+		&link.CoachUsername,
+		&link.AthleteID,
+		&link.AthleteUsername,
+		&link.Status,
+		&link.CreatedAt,
+		&link.AcceptedAt,
+	); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// CreateInvitation stores a pending link. It returns ErrCoachLinkExists if
+// the coach already invited or coaches the athlete.
+func (pg *PostgresCoachStore) CreateInvitation(ctx context.Context, link *models.CoachLink) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO coach_links (coach_id, athlete_id)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at
+	`
+	err := pg.db.QueryRowContext(ctx, query, link.CoachID, link.AthleteID).Scan(
+		&link.ID,
+		&link.Status,
+		&link.CreatedAt,
+	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrCoachLinkExists
+	}
+	return err
+}
+
+// ListAthletes returns the links the user has as a coach.
+func (pg *PostgresCoachStore) ListAthletes(ctx context.Context, coachID int64) ([]*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + coachLinkColumns + coachLinkFrom + `
+		WHERE cl.coach_id = $1
+		ORDER BY cl.status, a.username
+	`
+	return pg.queryLinks(ctx, query, coachID)
+}
+
+// ListCoaches returns the links the user has as an athlete, including
+// invitations still waiting for an answer.
+func (pg *PostgresCoachStore) ListCoaches(ctx context.Context, athleteID int64) ([]*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + coachLinkColumns + coachLinkFrom + `
+		WHERE cl.athlete_id = $1
+		ORDER BY cl.status, c.username
+	`
+	return pg.queryLinks(ctx, query, athleteID)
+}
+
+func (pg *PostgresCoachStore) queryLinks(ctx context.Context, query string, args ...any) ([]*models.CoachLink, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*models.CoachLink{}
+	for rows.Next() {
+		link, err := scanCoachLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// AcceptInvitation activates a pending link addressed to the athlete. It
+// returns sql.ErrNoRows if there is no such invitation.
+func (pg *PostgresCoachStore) AcceptInvitation(ctx context.Context, id, athleteID int64) (*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		WITH accepted AS (
+			UPDATE coach_links
+			SET status = 'active', accepted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND athlete_id = $2 AND status = 'pending'
+			RETURNING *
+		)
+		SELECT ` + coachLinkColumns + `
+		FROM accepted cl
+		INNER JOIN users c ON c.id = cl.coach_id
+		INNER JOIN users a ON a.id = cl.athlete_id
+	`
+	return scanCoachLink(pg.db.QueryRowContext(ctx, query, id, athleteID))
+}
+
+// DeleteLink removes a link the user is either side of, which covers a coach
+// withdrawing an invitation, an athlete declining one and either of them
+// ending the relationship. It returns sql.ErrNoRows if there is no such link.
+func (pg *PostgresCoachStore) DeleteLink(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(
+		ctx,
+		`DELETE FROM coach_links WHERE id = $1 AND (coach_id = $2 OR athlete_id = $2)`,
+		id,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// IsCoach reports whether coachID has an accepted link to athleteID.
+func (pg *PostgresCoachStore) IsCoach(ctx context.Context, coachID, athleteID int64) (bool, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM coach_links
+			WHERE coach_id = $1 AND athlete_id = $2 AND status = 'active'
+		)
+	`
+	err := pg.db.QueryRowContext(ctx, query, coachID, athleteID).Scan(&exists)
+	return exists, err
+}
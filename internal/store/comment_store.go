@@ -0,0 +1,133 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type PostgresCommentStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewPostgresCommentStore(db *sql.DB, queryTimeout time.Duration) *PostgresCommentStore {
+	return &PostgresCommentStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (pg *PostgresCommentStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		WITH inserted AS (
+			INSERT INTO workout_comments (workout_id, user_id, body)
+			VALUES ($1, $2, $3)
+			RETURNING id, user_id, created_at, updated_at
+		)
+		SELECT i.id, u.username, i.created_at, i.updated_at
+		FROM inserted i
+		INNER JOIN users u ON u.id = i.user_id
+	`
+	return pg.db.QueryRowContext(
+		ctx,
+		query,
+		comment.WorkoutID,
+		comment.UserID,
+		comment.Body,
+	).Scan(
+		&comment.ID,
+		&comment.Username,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+}
+
+func (pg *PostgresCommentStore) GetCommentByID(ctx context.Context, id int64) (*models.Comment, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.workout_id, c.user_id, u.username, c.body, c.created_at, c.updated_at
+		FROM workout_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1
+	`
+	comment := &models.Comment{}
+	if err := pg.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.WorkoutID,
+		&comment.UserID,
+		&comment.Username,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListComments returns the comments on a workout, oldest first.
+func (pg *PostgresCommentStore) ListComments(ctx context.Context, workoutID int64, limit, offset int) ([]models.Comment, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.workout_id, c.user_id, u.username, c.body, c.created_at, c.updated_at
+		FROM workout_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.workout_id = $1
+		ORDER BY c.created_at, c.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := pg.db.QueryContext(ctx, query, workoutID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.WorkoutID,
+			&comment.UserID,
+			&comment.Username,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (pg *PostgresCommentStore) DeleteCommentByID(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(ctx, `DELETE FROM workout_comments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
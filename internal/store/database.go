@@ -1,17 +1,25 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/fs"
 	"time"
 
+	"github.com/agkmw/workout-service/internal/config"
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
 
-func Open() (*sql.DB, error) {
-	db, err := sql.Open("pgx", "host=localhost dbname=postgres password=postgres user=postgres port=5432 sslmode=disable")
+// WithTimeout bounds a single store call by the store's query timeout, on
+// top of any deadline the caller's context already has.
+func WithTimeout(ctx context.Context, queryTimeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
+func Open(cfg config.DB) (*sql.DB, error) {
+	db, err := sql.Open("pgx", cfg.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("db open: %w", err)
 	}
@@ -20,23 +28,31 @@ func Open() (*sql.DB, error) {
 		return nil, fmt.Errorf("db ping: %w", err)
 	}
 
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxIdleTime(30 * time.Second)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(cfg.MaxIdleTime)
 
 	return db, nil
 }
 
 func MigrateFS(db *sql.DB, migrationsFS fs.FS, dir string) error {
+	return migrateFS(db, "postgres", migrationsFS, dir)
+}
+
+func migrateFS(db *sql.DB, dialect string, migrationsFS fs.FS, dir string) error {
 	goose.SetBaseFS(migrationsFS)
 	defer func() {
 		goose.SetBaseFS(nil)
 	}()
-	return Migrate(db, dir)
+	return migrate(db, dialect, dir)
 }
 
 func Migrate(db *sql.DB, dir string) error {
-	if err := goose.SetDialect("postgres"); err != nil {
+	return migrate(db, "postgres", dir)
+}
+
+func migrate(db *sql.DB, dialect, dir string) error {
+	if err := goose.SetDialect(dialect); err != nil {
 		return fmt.Errorf("goose setDialect: %w", err)
 	}
 
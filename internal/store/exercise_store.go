@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type ExerciseFilter struct {
+	Query     string
+	Muscle    string
+	Equipment string
+	Limit     int
+}
+
+var ErrDuplicateExercise = errors.New("exercise already exists")
+
+type PostgresExerciseStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	// typeMap scans postgres arrays, which database/sql can't do on its own
+	typeMap *pgtype.Map
+	// organizationID adds the organization's own exercises to the shared
+	// catalog. nil sees the catalog only.
+	organizationID *int64
+}
+
+func NewPostgresExerciseStore(db *sql.DB, queryTimeout time.Duration) *PostgresExerciseStore {
+	return &PostgresExerciseStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+		typeMap:      pgtype.NewMap(),
+	}
+}
+
+// ForOrganization returns a store that sees the shared catalog plus the
+// custom exercises of the organization.
+func (pg *PostgresExerciseStore) ForOrganization(organizationID *int64) ExerciseStore {
+	return &PostgresExerciseStore{
+		db:             pg.db,
+		queryTimeout:   pg.queryTimeout,
+		typeMap:        pg.typeMap,
+		organizationID: organizationID,
+	}
+}
+
+// exerciseColumns is the select list scanExercise expects, with exercises
+// aliased as e.
+const exerciseColumns = `
+	e.id, e.name, e.primary_muscles, e.secondary_muscles,
+	COALESCE(e.equipment, ''), COALESCE(e.movement_type, ''),
+	COALESCE((
+		SELECT array_agg(a.alias ORDER BY a.alias)
+		FROM exercise_aliases a
+		WHERE a.exercise_id = e.id
+	), '{}'),
+	e.organization_id
+`
+
+// inCatalog is the condition under which exercise e is visible to the
+// organization bound to the placeholder.
+func inCatalog(placeholder string) string {
+	return "(e.organization_id IS NULL OR e.organization_id = " + placeholder + ")"
+}
+
+// ExerciseSeeder is implemented by the exercise store of every backend.
+type ExerciseSeeder interface {
+	UpsertExercises(ctx context.Context, exercises []models.Exercise) error
+}
+
+// SeedExercisesFS loads the exercise catalog from a JSON file and upserts it,
+// so it is safe to run on every startup.
+func SeedExercisesFS(ctx context.Context, seeder ExerciseSeeder, seedFS fs.FS, path string) error {
+	data, err := fs.ReadFile(seedFS, path)
+	if err != nil {
+		return fmt.Errorf("read exercise seed: %w", err)
+	}
+
+	var exercises []models.Exercise
+	if err := json.Unmarshal(data, &exercises); err != nil {
+		return fmt.Errorf("decode exercise seed: %w", err)
+	}
+
+	if err := seeder.UpsertExercises(ctx, exercises); err != nil {
+		return fmt.Errorf("seed exercises: %w", err)
+	}
+	return nil
+}
+
+// UpsertExercises runs without the query timeout, the whole catalog can
+// take a while on a fresh database.
+func (pg *PostgresExerciseStore) UpsertExercises(ctx context.Context, exercises []models.Exercise) error {
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertExercise := `
+		INSERT INTO exercises
+		(name, primary_muscles, secondary_muscles, equipment, movement_type)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ((lower(name))) WHERE organization_id IS NULL DO UPDATE
+		SET
+			primary_muscles = EXCLUDED.primary_muscles,
+			secondary_muscles = EXCLUDED.secondary_muscles,
+			equipment = EXCLUDED.equipment,
+			movement_type = EXCLUDED.movement_type,
+			updated_at = now()
+		RETURNING id
+	`
+	insertAlias := `
+		INSERT INTO exercise_aliases (exercise_id, alias)
+		VALUES ($1, $2)
+		ON CONFLICT ((lower(alias))) DO NOTHING
+	`
+	for i := range exercises {
+		exercise := &exercises[i]
+		if err := tx.QueryRowContext(
+			ctx,
+			upsertExercise,
+			exercise.Name,
+			nonNil(exercise.PrimaryMuscles),
+			nonNil(exercise.SecondaryMuscles),
+			exercise.Equipment,
+			exercise.MovementType,
+		).Scan(&exercise.ID); err != nil {
+			return err
+		}
+
+		for _, alias := range exercise.Aliases {
+			if _, err := tx.ExecContext(ctx, insertAlias, exercise.ID, models.NormalizeExerciseName(alias)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (pg *PostgresExerciseStore) SearchExercises(ctx context.Context, filter ExerciseFilter) ([]models.Exercise, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + exerciseColumns + `
+		FROM exercises e
+		WHERE
+			` + inCatalog("$6") + `
+			AND (
+				$1 = ''
+				OR e.name ILIKE '%' || $2 || '%'
+				OR EXISTS (
+					SELECT 1 FROM exercise_aliases a
+					WHERE a.exercise_id = e.id AND a.alias ILIKE '%' || $2 || '%'
+				)
+			)
+			AND ($3 = '' OR $3 = ANY(e.primary_muscles) OR $3 = ANY(e.secondary_muscles))
+			AND ($4 = '' OR e.equipment = $4)
+		ORDER BY
+			lower(e.name) = lower($1) DESC,
+			e.name ILIKE $2 || '%' DESC,
+			e.name
+		LIMIT $5
+	`
+	rows, err := pg.db.QueryContext(
+		ctx,
+		query,
+		filter.Query,
+		escapeLike(filter.Query),
+		filter.Muscle,
+		filter.Equipment,
+		filter.Limit,
+		pg.organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []models.Exercise{}
+	for rows.Next() {
+		exercise, err := pg.scanExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, *exercise)
+	}
+
+	return exercises, rows.Err()
+}
+
+func (pg *PostgresExerciseStore) GetExerciseByID(ctx context.Context, id int64) (*models.Exercise, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + exerciseColumns + `
+		FROM exercises e
+		WHERE e.id = $1 AND ` + inCatalog("$2") + `
+	`
+	return pg.scanExercise(pg.db.QueryRowContext(ctx, query, id, pg.organizationID))
+}
+
+// ResolveExerciseIDs maps free-text exercise names onto catalog ids by
+// matching canonical names and aliases. Names that match nothing are left
+// out of the result. An organization's own exercise wins over a catalog
+// exercise of the same name.
+func (pg *PostgresExerciseStore) ResolveExerciseIDs(ctx context.Context, names []string) (map[string]int64, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, models.NormalizeExerciseName(name))
+	}
+
+	query := `
+		SELECT name, id FROM (
+			SELECT lower(e.name) AS name, e.id, e.organization_id
+			FROM exercises e
+			WHERE lower(e.name) = ANY($1) AND ` + inCatalog("$2") + `
+			UNION
+			SELECT lower(alias), exercise_id, NULL
+			FROM exercise_aliases
+			WHERE lower(alias) = ANY($1)
+		) matches
+		ORDER BY organization_id NULLS FIRST
+	`
+	rows, err := pg.db.QueryContext(ctx, query, normalized, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int64{}
+	for rows.Next() {
+		var (
+			name string
+			id   int64
+		)
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, err
+		}
+		ids[name] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// CreateExercise adds a custom exercise to the store's organization. It
+// returns ErrDuplicateExercise if the organization already has one by that
+// name.
+func (pg *PostgresExerciseStore) CreateExercise(ctx context.Context, exercise *models.Exercise) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	if pg.organizationID == nil {
+		return errors.New("custom exercises need an organization")
+	}
+
+	query := `
+		INSERT INTO exercises
+		(organization_id, name, primary_muscles, secondary_muscles, equipment, movement_type)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id
+	`
+	exercise.OrganizationID = pg.organizationID
+	exercise.Aliases = []string{}
+	exercise.PrimaryMuscles = nonNil(exercise.PrimaryMuscles)
+	exercise.SecondaryMuscles = nonNil(exercise.SecondaryMuscles)
+	err := pg.db.QueryRowContext(
+		ctx,
+		query,
+		exercise.OrganizationID,
+		exercise.Name,
+		exercise.PrimaryMuscles,
+		exercise.SecondaryMuscles,
+		exercise.Equipment,
+		exercise.MovementType,
+	).Scan(&exercise.ID)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrDuplicateExercise
+	}
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (pg *PostgresExerciseStore) scanExercise(row rowScanner) (*models.Exercise, error) {
+	exercise := &models.Exercise{}
+	if err := row.Scan(
+		&exercise.ID,
+		&exercise.Name,
+		pg.typeMap.SQLScanner(&exercise.PrimaryMuscles),
+		pg.typeMap.SQLScanner(&exercise.SecondaryMuscles),
+		&exercise.Equipment,
+		&exercise.MovementType,
+		pg.typeMap.SQLScanner(&exercise.Aliases),
+		&exercise.OrganizationID,
+	); err != nil {
+		return nil, err
+	}
+	return exercise, nil
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type PostgresFollowStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewPostgresFollowStore(db *sql.DB, queryTimeout time.Duration) *PostgresFollowStore {
+	return &PostgresFollowStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// Follow is idempotent; following someone twice is not an error.
+func (pg *PostgresFollowStore) Follow(ctx context.Context, followerID, followeeID int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	_, err := pg.db.ExecContext(ctx, query, followerID, followeeID)
+	return err
+}
+
+// Unfollow returns sql.ErrNoRows if the follower wasn't following.
+func (pg *PostgresFollowStore) Unfollow(ctx context.Context, followerID, followeeID int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(
+		ctx,
+		`DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`,
+		followerID,
+		followeeID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListFollowers returns the users following userID, most recent first.
+func (pg *PostgresFollowStore) ListFollowers(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + publicProfileColumns + `
+		FROM follows f
+		INNER JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY f.created_at DESC, u.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	return pg.queryProfiles(ctx, query, userID, limit, offset)
+}
+
+// ListFollowing returns the users userID follows, most recent first.
+func (pg *PostgresFollowStore) ListFollowing(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + publicProfileColumns + `
+		FROM follows f
+		INNER JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC, u.id DESC
+		LIMIT $2 OFFSET $3
+	`
+	return pg.queryProfiles(ctx, query, userID, limit, offset)
+}
+
+func (pg *PostgresFollowStore) queryProfiles(ctx context.Context, query string, args ...any) ([]models.PublicProfile, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.PublicProfile{}
+	for rows.Next() {
+		profile, err := scanPublicProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
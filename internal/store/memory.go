@@ -0,0 +1,483 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+// MemoryDB holds the tables behind the in-memory stores. Stores built on the
+// same MemoryDB see each other's rows, the way Postgres stores share a
+// database, so lookups across tables and cascading deletes behave the same.
+// Everything is lost when the process exits.
+type MemoryDB struct {
+	mu     sync.RWMutex
+	lastID map[string]int64
+
+	users         map[int64]*models.User
+	tokens        map[string]*memoryToken
+	workouts      map[int64]*models.Workout
+	records       map[int64]*memoryRecord
+	exercises     map[int64]*models.Exercise
+	aliases       map[string]int64
+	templates     map[int64]*models.WorkoutTemplate
+	programs      map[int64]*models.Program
+	enrollments   map[int64]*models.ProgramEnrollment
+	follows       []memoryFollow
+	comments      map[int64]*models.Comment
+	reactions     []models.Reaction
+	adminActions  []*memoryAdminAction
+	coachLinks    map[int64]*models.CoachLink
+	organizations map[int64]*models.Organization
+	members       []models.OrganizationMember
+}
+
+type memoryToken struct {
+	id         int64
+	hash       []byte
+	userID     int64
+	expiry     time.Time
+	scope      string
+	createdAt  time.Time
+	lastUsedAt *time.Time
+	userAgent  string
+	ip         string
+	familyID   string
+	usedAt     *time.Time
+}
+
+// memoryRecord is a row of personal_records, which keeps the normalized
+// exercise name next to the record.
+type memoryRecord struct {
+	models.PersonalRecord
+	exerciseKey string
+}
+
+// memoryAdminAction keeps the details as JSON, the way the JSONB column
+// does, so every read decodes a fresh copy.
+type memoryAdminAction struct {
+	models.AdminAction
+	details []byte
+}
+
+type memoryFollow struct {
+	followerID int64
+	followeeID int64
+	createdAt  time.Time
+}
+
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		lastID:        map[string]int64{},
+		users:         map[int64]*models.User{},
+		tokens:        map[string]*memoryToken{},
+		workouts:      map[int64]*models.Workout{},
+		records:       map[int64]*memoryRecord{},
+		exercises:     map[int64]*models.Exercise{},
+		aliases:       map[string]int64{},
+		templates:     map[int64]*models.WorkoutTemplate{},
+		programs:      map[int64]*models.Program{},
+		enrollments:   map[int64]*models.ProgramEnrollment{},
+		comments:      map[int64]*models.Comment{},
+		coachLinks:    map[int64]*models.CoachLink{},
+		organizations: map[int64]*models.Organization{},
+	}
+}
+
+// nextID hands out identity values per table, starting at 1.
+func (db *MemoryDB) nextID(table string) int64 {
+	db.lastID[table]++
+	return db.lastID[table]
+}
+
+// now returns the current time at the microsecond precision of Postgres
+// timestamps.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// errMissingRow is what a foreign key violation turns into in memory.
+func errMissingRow(table string) error {
+	return fmt.Errorf("memory store: referenced %s row does not exist", table)
+}
+
+// errCheckViolation is what a failed CHECK constraint turns into in memory.
+func errCheckViolation(constraint string) error {
+	return fmt.Errorf("memory store: row violates check constraint %q", constraint)
+}
+
+// sameOrganization compares organization ids the way IS NOT DISTINCT FROM
+// does, so two nils are equal.
+func sameOrganization(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// CompletedWorkouts returns copies of the user's completed workouts in the
+// organization, or the personal space when organizationID is nil, oldest
+// first. It backs the in-memory statistics.
+func (db *MemoryDB) CompletedWorkouts(userID int64, organizationID *int64) []*models.Workout {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	workouts := []*models.Workout{}
+	for _, w := range db.workouts {
+		if w.UserID == userID && w.Status == models.StatusCompleted && sameOrganization(w.OrganizationID, organizationID) {
+			workouts = append(workouts, cloneWorkout(w))
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workouts[i].CreatedAt.Before(workouts[j].CreatedAt)
+	})
+	return workouts
+}
+
+// Exercise returns a copy of the exercise with the given id, or nil.
+func (db *MemoryDB) Exercise(id int64) *models.Exercise {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	exercise, ok := db.exercises[id]
+	if !ok {
+		return nil
+	}
+	return db.cloneExercise(exercise)
+}
+
+// deleteUser removes the user with everything that references them, like
+// the ON DELETE clauses of the schema do.
+func (db *MemoryDB) deleteUser(id int64) {
+	delete(db.users, id)
+
+	for key, token := range db.tokens {
+		if token.userID == id {
+			delete(db.tokens, key)
+		}
+	}
+	for workoutID, workout := range db.workouts {
+		if workout.UserID == id {
+			db.deleteWorkout(workoutID)
+		}
+	}
+	for programID, program := range db.programs {
+		if program.UserID == id {
+			db.deleteProgram(programID)
+		}
+	}
+	for enrollmentID, enrollment := range db.enrollments {
+		if enrollment.UserID == id {
+			db.deleteEnrollment(enrollmentID)
+		}
+	}
+	for templateID, template := range db.templates {
+		if template.UserID == id {
+			db.deleteTemplate(templateID)
+		}
+	}
+	for recordID, record := range db.records {
+		if record.UserID == id {
+			delete(db.records, recordID)
+		}
+	}
+	db.follows = filter(db.follows, func(f memoryFollow) bool {
+		return f.followerID != id && f.followeeID != id
+	})
+	for commentID, comment := range db.comments {
+		if comment.UserID == id {
+			delete(db.comments, commentID)
+		}
+	}
+	db.reactions = filter(db.reactions, func(r models.Reaction) bool {
+		return r.UserID != id
+	})
+	for _, action := range db.adminActions {
+		if action.AdminID != nil && *action.AdminID == id {
+			action.AdminID = nil
+		}
+	}
+	for linkID, link := range db.coachLinks {
+		if link.CoachID == id || link.AthleteID == id {
+			delete(db.coachLinks, linkID)
+		}
+	}
+	db.members = filter(db.members, func(m models.OrganizationMember) bool {
+		return m.UserID != id
+	})
+}
+
+// deleteWorkout removes the workout with its records, comments and
+// reactions.
+func (db *MemoryDB) deleteWorkout(id int64) {
+	delete(db.workouts, id)
+
+	for recordID, record := range db.records {
+		if record.WorkoutID == id {
+			delete(db.records, recordID)
+		}
+	}
+	for commentID, comment := range db.comments {
+		if comment.WorkoutID == id {
+			delete(db.comments, commentID)
+		}
+	}
+	db.reactions = filter(db.reactions, func(r models.Reaction) bool {
+		return r.WorkoutID != id
+	})
+}
+
+// deleteTemplate unlinks the workouts started from the template. Callers
+// check first that no program schedules it.
+func (db *MemoryDB) deleteTemplate(id int64) {
+	delete(db.templates, id)
+
+	for _, workout := range db.workouts {
+		if workout.TemplateID != nil && *workout.TemplateID == id {
+			workout.TemplateID = nil
+		}
+	}
+}
+
+// deleteProgram removes the program with its enrollments and unlinks the
+// workouts logged for its sessions.
+func (db *MemoryDB) deleteProgram(id int64) {
+	program, ok := db.programs[id]
+	if !ok {
+		return
+	}
+	delete(db.programs, id)
+
+	sessions := map[int64]bool{}
+	for _, session := range program.Sessions {
+		sessions[session.ID] = true
+	}
+	for _, workout := range db.workouts {
+		if workout.ProgramSessionID != nil && sessions[*workout.ProgramSessionID] {
+			workout.ProgramSessionID = nil
+		}
+	}
+	for enrollmentID, enrollment := range db.enrollments {
+		if enrollment.ProgramID == id {
+			db.deleteEnrollment(enrollmentID)
+		}
+	}
+}
+
+func (db *MemoryDB) deleteEnrollment(id int64) {
+	delete(db.enrollments, id)
+
+	for _, workout := range db.workouts {
+		if workout.EnrollmentID != nil && *workout.EnrollmentID == id {
+			workout.EnrollmentID = nil
+		}
+	}
+}
+
+// isFollowing reports whether followerID follows followeeID.
+func (db *MemoryDB) isFollowing(followerID, followeeID int64) bool {
+	for _, f := range db.follows {
+		if f.followerID == followerID && f.followeeID == followeeID {
+			return true
+		}
+	}
+	return false
+}
+
+// isCoach reports whether coachID has an accepted link to athleteID.
+func (db *MemoryDB) isCoach(coachID, athleteID int64) bool {
+	for _, link := range db.coachLinks {
+		if link.CoachID == coachID && link.AthleteID == athleteID && link.Status == models.CoachLinkActive {
+			return true
+		}
+	}
+	return false
+}
+
+// publicProfile builds the profile of the user, counting only completed
+// public workouts of the personal space.
+func (db *MemoryDB) publicProfile(user *models.User) models.PublicProfile {
+	profile := models.PublicProfile{
+		ID:       user.ID,
+		Username: user.Username,
+		Bio:      user.Bio,
+		JoinedAt: user.CreatedAt,
+	}
+	for _, w := range db.workouts {
+		if w.UserID == user.ID && w.Visibility == models.VisibilityPublic && w.Status == models.StatusCompleted &&
+			w.OrganizationID == nil {
+			profile.PublicWorkoutCount++
+		}
+	}
+	for _, f := range db.follows {
+		if f.followeeID == user.ID {
+			profile.FollowerCount++
+		}
+		if f.followerID == user.ID {
+			profile.FollowingCount++
+		}
+	}
+	return profile
+}
+
+func (db *MemoryDB) username(userID int64) string {
+	if user, ok := db.users[userID]; ok {
+		return user.Username
+	}
+	return ""
+}
+
+func (db *MemoryDB) cloneExercise(e *models.Exercise) *models.Exercise {
+	exercise := *e
+	exercise.PrimaryMuscles = append([]string{}, e.PrimaryMuscles...)
+	exercise.SecondaryMuscles = append([]string{}, e.SecondaryMuscles...)
+	exercise.OrganizationID = clonePtr(e.OrganizationID)
+	exercise.Aliases = []string{}
+	for alias, exerciseID := range db.aliases {
+		if exerciseID == e.ID {
+			exercise.Aliases = append(exercise.Aliases, alias)
+		}
+	}
+	sort.Strings(exercise.Aliases)
+	return &exercise
+}
+
+// The stored rows never leave the MemoryDB; callers get copies, just like
+// rows scanned from Postgres, so changing them can't bypass the store.
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func cloneUser(u *models.User) *models.User {
+	user := *u
+	user.PasswordHash.Hash = append([]byte(nil), u.PasswordHash.Hash...)
+	return &user
+}
+
+// cloneWorkout copies the workout with its entries and sets. Like rows
+// loaded from Postgres, a workout without entries has nil Entries.
+func cloneWorkout(w *models.Workout) *models.Workout {
+	workout := *w
+	workout.OrganizationID = clonePtr(w.OrganizationID)
+	workout.TemplateID = clonePtr(w.TemplateID)
+	workout.EnrollmentID = clonePtr(w.EnrollmentID)
+	workout.ProgramSessionID = clonePtr(w.ProgramSessionID)
+	workout.NewRecords = nil
+	workout.Entries = nil
+	for _, e := range w.Entries {
+		entry := e
+		entry.ExerciseID = clonePtr(e.ExerciseID)
+		entry.Reps = clonePtr(e.Reps)
+		entry.DurationSeconds = clonePtr(e.DurationSeconds)
+		entry.Weight = clonePtr(e.Weight)
+		entry.Sets = nil
+		for _, s := range e.Sets {
+			set := s
+			set.Reps = clonePtr(s.Reps)
+			set.Weight = clonePtr(s.Weight)
+			set.DurationSeconds = clonePtr(s.DurationSeconds)
+			set.DistanceMeters = clonePtr(s.DistanceMeters)
+			set.RPE = clonePtr(s.RPE)
+			set.RestSeconds = clonePtr(s.RestSeconds)
+			entry.Sets = append(entry.Sets, set)
+		}
+		workout.Entries = append(workout.Entries, entry)
+	}
+	return &workout
+}
+
+// loadWorkout copies the stored workout with its entries in order and
+// counts its comments and reactions.
+func (db *MemoryDB) loadWorkout(w *models.Workout) *models.Workout {
+	workout := cloneWorkout(w)
+	sort.SliceStable(workout.Entries, func(i, j int) bool {
+		return workout.Entries[i].OrderIndex < workout.Entries[j].OrderIndex
+	})
+	workout.CommentCount = 0
+	workout.ReactionCounts = map[string]int{}
+	for _, c := range db.comments {
+		if c.WorkoutID == w.ID {
+			workout.CommentCount++
+		}
+	}
+	for _, r := range db.reactions {
+		if r.WorkoutID == w.ID {
+			workout.ReactionCounts[r.Kind]++
+		}
+	}
+	return workout
+}
+
+func cloneTemplate(t *models.WorkoutTemplate) *models.WorkoutTemplate {
+	template := *t
+	template.OrganizationID = clonePtr(t.OrganizationID)
+	template.Entries = nil
+	for _, e := range t.Entries {
+		entry := e
+		entry.ExerciseID = clonePtr(e.ExerciseID)
+		entry.TargetReps = clonePtr(e.TargetReps)
+		entry.TargetWeight = clonePtr(e.TargetWeight)
+		entry.TargetDurationSeconds = clonePtr(e.TargetDurationSeconds)
+		entry.RestSeconds = clonePtr(e.RestSeconds)
+		entry.WeightIncrement = clonePtr(e.WeightIncrement)
+		template.Entries = append(template.Entries, entry)
+	}
+	return &template
+}
+
+func cloneProgram(p *models.Program) *models.Program {
+	program := *p
+	program.DeloadWeeks = append([]int{}, p.DeloadWeeks...)
+	program.Sessions = make([]models.ProgramSession, len(p.Sessions))
+	for i, s := range p.Sessions {
+		session := s
+		session.IntensityPercentage = clonePtr(s.IntensityPercentage)
+		program.Sessions[i] = session
+	}
+	return &program
+}
+
+func cloneCoachLink(db *MemoryDB, l *models.CoachLink) *models.CoachLink {
+	link := *l
+	link.CoachUsername = db.username(l.CoachID)
+	link.AthleteUsername = db.username(l.AthleteID)
+	link.AcceptedAt = clonePtr(l.AcceptedAt)
+	return &link
+}
+
+// filter returns the items keep returns true for, reusing the backing array.
+func filter[T any](items []T, keep func(T) bool) []T {
+	kept := items[:0]
+	for _, item := range items {
+		if keep(item) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// page applies LIMIT and OFFSET to an already sorted result.
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// containsFold is ILIKE '%' || substr || '%'.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryAdminStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryAdminStore(db *MemoryDB) *MemoryAdminStore {
+	return &MemoryAdminStore{
+		db: db,
+	}
+}
+
+func (m *MemoryAdminStore) RecordAction(ctx context.Context, action *models.AdminAction) error {
+	if action.Details == nil {
+		action.Details = map[string]any{}
+	}
+	details, err := json.Marshal(action.Details)
+	if err != nil {
+		return err
+	}
+
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if action.AdminID != nil {
+		if _, ok := m.db.users[*action.AdminID]; !ok {
+			return errMissingRow("users")
+		}
+	}
+
+	action.ID = m.db.nextID("admin_actions")
+	action.CreatedAt = now()
+
+	stored := &memoryAdminAction{AdminAction: *action, details: details}
+	stored.AdminID = clonePtr(action.AdminID)
+	stored.Details = nil
+	m.db.adminActions = append(m.db.adminActions, stored)
+	return nil
+}
+
+// ListActions returns the admin log, most recent first.
+func (m *MemoryAdminStore) ListActions(ctx context.Context, limit, offset int) ([]*models.AdminAction, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	stored := append([]*memoryAdminAction{}, m.db.adminActions...)
+	sort.Slice(stored, func(i, j int) bool {
+		a, b := stored[i], stored[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+
+	actions := []*models.AdminAction{}
+	for _, s := range page(stored, limit, offset) {
+		action := s.AdminAction
+		action.AdminID = clonePtr(s.AdminID)
+		if err := json.Unmarshal(s.details, &action.Details); err != nil {
+			return nil, err
+		}
+		actions = append(actions, &action)
+	}
+	return actions, nil
+}
+
+// DeleteWorkout deletes the workout whichever organization it belongs to.
+// Workout stores only ever see one organization.
+func (m *MemoryAdminStore) DeleteWorkout(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.workouts[id]; !ok {
+		return sql.ErrNoRows
+	}
+	m.db.deleteWorkout(id)
+	return nil
+}
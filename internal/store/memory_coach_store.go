@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryCoachStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryCoachStore(db *MemoryDB) *MemoryCoachStore {
+	return &MemoryCoachStore{
+		db: db,
+	}
+}
+
+// CreateInvitation stores a pending link. It returns ErrCoachLinkExists if
+// the coach already invited or coaches the athlete.
+func (m *MemoryCoachStore) CreateInvitation(ctx context.Context, link *models.CoachLink) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if link.CoachID == link.AthleteID {
+		return errCheckViolation("no_self_coaching")
+	}
+	for _, id := range []int64{link.CoachID, link.AthleteID} {
+		if _, ok := m.db.users[id]; !ok {
+			return errMissingRow("users")
+		}
+	}
+	for _, other := range m.db.coachLinks {
+		if other.CoachID == link.CoachID && other.AthleteID == link.AthleteID {
+			return ErrCoachLinkExists
+		}
+	}
+
+	link.ID = m.db.nextID("coach_links")
+	link.Status = models.CoachLinkPending
+	link.CreatedAt = now()
+
+	m.db.coachLinks[link.ID] = &models.CoachLink{
+		ID:        link.ID,
+		CoachID:   link.CoachID,
+		AthleteID: link.AthleteID,
+		Status:    link.Status,
+		CreatedAt: link.CreatedAt,
+	}
+	return nil
+}
+
+// ListAthletes returns the links the user has as a coach.
+func (m *MemoryCoachStore) ListAthletes(ctx context.Context, coachID int64) ([]*models.CoachLink, error) {
+	return m.listLinks(
+		func(l *models.CoachLink) bool { return l.CoachID == coachID },
+		func(l *models.CoachLink) string { return l.AthleteUsername },
+	)
+}
+
+// ListCoaches returns the links the user has as an athlete, including
+// invitations still waiting for an answer.
+func (m *MemoryCoachStore) ListCoaches(ctx context.Context, athleteID int64) ([]*models.CoachLink, error) {
+	return m.listLinks(
+		func(l *models.CoachLink) bool { return l.AthleteID == athleteID },
+		func(l *models.CoachLink) string { return l.CoachUsername },
+	)
+}
+
+// listLinks returns the matching links ordered by status and then by the
+// username of the other side.
+func (m *MemoryCoachStore) listLinks(match func(l *models.CoachLink) bool, other func(l *models.CoachLink) string) ([]*models.CoachLink, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	links := []*models.CoachLink{}
+	for _, l := range m.db.coachLinks {
+		if match(l) {
+			links = append(links, cloneCoachLink(m.db, l))
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Status != links[j].Status {
+			return links[i].Status < links[j].Status
+		}
+		return other(links[i]) < other(links[j])
+	})
+
+	return links, nil
+}
+
+// AcceptInvitation activates a pending link addressed to the athlete. It
+// returns sql.ErrNoRows if there is no such invitation.
+func (m *MemoryCoachStore) AcceptInvitation(ctx context.Context, id, athleteID int64) (*models.CoachLink, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	link, ok := m.db.coachLinks[id]
+	if !ok || link.AthleteID != athleteID || link.Status != models.CoachLinkPending {
+		return nil, sql.ErrNoRows
+	}
+
+	acceptedAt := now()
+	link.Status = models.CoachLinkActive
+	link.AcceptedAt = &acceptedAt
+	return cloneCoachLink(m.db, link), nil
+}
+
+// DeleteLink removes a link the user is either side of. It returns
+// sql.ErrNoRows if there is no such link.
+func (m *MemoryCoachStore) DeleteLink(ctx context.Context, id, userID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	link, ok := m.db.coachLinks[id]
+	if !ok || (link.CoachID != userID && link.AthleteID != userID) {
+		return sql.ErrNoRows
+	}
+	delete(m.db.coachLinks, id)
+	return nil
+}
+
+// IsCoach reports whether coachID has an accepted link to athleteID.
+func (m *MemoryCoachStore) IsCoach(ctx context.Context, coachID, athleteID int64) (bool, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return m.db.isCoach(coachID, athleteID), nil
+}
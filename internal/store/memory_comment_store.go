@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryCommentStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryCommentStore(db *MemoryDB) *MemoryCommentStore {
+	return &MemoryCommentStore{
+		db: db,
+	}
+}
+
+func (m *MemoryCommentStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.workouts[comment.WorkoutID]; !ok {
+		return errMissingRow("workouts")
+	}
+	user, ok := m.db.users[comment.UserID]
+	if !ok {
+		return errMissingRow("users")
+	}
+
+	comment.ID = m.db.nextID("workout_comments")
+	comment.Username = user.Username
+	comment.CreatedAt = now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	m.db.comments[comment.ID] = clonePtr(comment)
+	return nil
+}
+
+func (m *MemoryCommentStore) GetCommentByID(ctx context.Context, id int64) (*models.Comment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comment, ok := m.db.comments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return m.load(comment), nil
+}
+
+// ListComments returns the comments on a workout, oldest first.
+func (m *MemoryCommentStore) ListComments(ctx context.Context, workoutID int64, limit, offset int) ([]models.Comment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, c := range m.db.comments {
+		if c.WorkoutID == workoutID {
+			comments = append(comments, *m.load(c))
+		}
+	}
+	sort.Slice(comments, func(i, j int) bool {
+		a, b := comments[i], comments[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+
+	return page(comments, limit, offset), nil
+}
+
+// load copies the comment with the author's current username.
+func (m *MemoryCommentStore) load(c *models.Comment) *models.Comment {
+	comment := clonePtr(c)
+	comment.Username = m.db.username(c.UserID)
+	return comment
+}
+
+func (m *MemoryCommentStore) DeleteCommentByID(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.comments[id]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(m.db.comments, id)
+	return nil
+}
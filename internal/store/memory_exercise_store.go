@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryExerciseStore struct {
+	db *MemoryDB
+	// organizationID adds the organization's own exercises to the shared
+	// catalog. nil sees the catalog only.
+	organizationID *int64
+}
+
+func NewMemoryExerciseStore(db *MemoryDB) *MemoryExerciseStore {
+	return &MemoryExerciseStore{
+		db: db,
+	}
+}
+
+// ForOrganization returns a store that sees the shared catalog plus the
+// custom exercises of the organization.
+func (m *MemoryExerciseStore) ForOrganization(organizationID *int64) ExerciseStore {
+	return &MemoryExerciseStore{
+		db:             m.db,
+		organizationID: organizationID,
+	}
+}
+
+// inCatalog reports whether the exercise is visible to the store's
+// organization.
+func (m *MemoryExerciseStore) inCatalog(e *models.Exercise) bool {
+	return e.OrganizationID == nil || sameOrganization(e.OrganizationID, m.organizationID)
+}
+
+// UpsertExercises adds the catalog exercises, updating the ones that exist
+// already by name.
+func (m *MemoryExerciseStore) UpsertExercises(ctx context.Context, exercises []models.Exercise) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for i := range exercises {
+		exercise := &exercises[i]
+
+		stored := m.findByName(nil, exercise.Name)
+		if stored == nil {
+			stored = &models.Exercise{ID: m.db.nextID("exercises"), Name: exercise.Name}
+			m.db.exercises[stored.ID] = stored
+		}
+		stored.PrimaryMuscles = append([]string{}, exercise.PrimaryMuscles...)
+		stored.SecondaryMuscles = append([]string{}, exercise.SecondaryMuscles...)
+		stored.Equipment = exercise.Equipment
+		stored.MovementType = exercise.MovementType
+		exercise.ID = stored.ID
+
+		for _, alias := range exercise.Aliases {
+			alias = models.NormalizeExerciseName(alias)
+			if _, ok := m.db.aliases[alias]; !ok {
+				m.db.aliases[alias] = exercise.ID
+			}
+		}
+	}
+
+	return nil
+}
+
+// findByName returns the exercise of the organization, or of the catalog
+// when organizationID is nil, whose name matches case-insensitively.
+func (m *MemoryExerciseStore) findByName(organizationID *int64, name string) *models.Exercise {
+	for _, e := range m.db.exercises {
+		if sameOrganization(e.OrganizationID, organizationID) && strings.EqualFold(e.Name, name) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (m *MemoryExerciseStore) SearchExercises(ctx context.Context, filter ExerciseFilter) ([]models.Exercise, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	matchesQuery := func(e *models.Exercise) bool {
+		if filter.Query == "" || containsFold(e.Name, filter.Query) {
+			return true
+		}
+		for alias, id := range m.db.aliases {
+			if id == e.ID && containsFold(alias, filter.Query) {
+				return true
+			}
+		}
+		return false
+	}
+	matchesMuscle := func(e *models.Exercise) bool {
+		if filter.Muscle == "" {
+			return true
+		}
+		for _, muscles := range [][]string{e.PrimaryMuscles, e.SecondaryMuscles} {
+			for _, muscle := range muscles {
+				if muscle == filter.Muscle {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	exercises := []models.Exercise{}
+	for _, e := range m.db.exercises {
+		if !m.inCatalog(e) || !matchesQuery(e) || !matchesMuscle(e) {
+			continue
+		}
+		if filter.Equipment != "" && e.Equipment != filter.Equipment {
+			continue
+		}
+		exercises = append(exercises, *m.db.cloneExercise(e))
+	}
+
+	query := strings.ToLower(filter.Query)
+	sort.Slice(exercises, func(i, j int) bool {
+		a, b := strings.ToLower(exercises[i].Name), strings.ToLower(exercises[j].Name)
+		if (a == query) != (b == query) {
+			return a == query
+		}
+		if strings.HasPrefix(a, query) != strings.HasPrefix(b, query) {
+			return strings.HasPrefix(a, query)
+		}
+		return exercises[i].Name < exercises[j].Name
+	})
+
+	return page(exercises, filter.Limit, 0), nil
+}
+
+func (m *MemoryExerciseStore) GetExerciseByID(ctx context.Context, id int64) (*models.Exercise, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	exercise, ok := m.db.exercises[id]
+	if !ok || !m.inCatalog(exercise) {
+		return nil, sql.ErrNoRows
+	}
+	return m.db.cloneExercise(exercise), nil
+}
+
+// ResolveExerciseIDs maps free-text exercise names onto catalog ids by
+// matching canonical names and aliases. Names that match nothing are left
+// out of the result. An organization's own exercise wins over a catalog
+// exercise of the same name.
+func (m *MemoryExerciseStore) ResolveExerciseIDs(ctx context.Context, names []string) (map[string]int64, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	ids := map[string]int64{}
+	for _, name := range names {
+		normalized := models.NormalizeExerciseName(name)
+
+		if id, ok := m.db.aliases[normalized]; ok {
+			ids[normalized] = id
+		}
+		if e := m.findByName(nil, normalized); e != nil {
+			ids[normalized] = e.ID
+		}
+		if m.organizationID != nil {
+			if e := m.findByName(m.organizationID, normalized); e != nil {
+				ids[normalized] = e.ID
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+// CreateExercise adds a custom exercise to the store's organization. It
+// returns ErrDuplicateExercise if the organization already has one by that
+// name.
+func (m *MemoryExerciseStore) CreateExercise(ctx context.Context, exercise *models.Exercise) error {
+	if m.organizationID == nil {
+		return errors.New("custom exercises need an organization")
+	}
+
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.organizations[*m.organizationID]; !ok {
+		return errMissingRow("organizations")
+	}
+	if m.findByName(m.organizationID, exercise.Name) != nil {
+		return ErrDuplicateExercise
+	}
+
+	exercise.ID = m.db.nextID("exercises")
+	exercise.OrganizationID = clonePtr(m.organizationID)
+	exercise.Aliases = []string{}
+	exercise.PrimaryMuscles = nonNil(exercise.PrimaryMuscles)
+	exercise.SecondaryMuscles = nonNil(exercise.SecondaryMuscles)
+
+	stored := *exercise
+	m.db.exercises[exercise.ID] = m.db.cloneExercise(&stored)
+	return nil
+}
@@ -0,0 +1,102 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryFollowStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryFollowStore(db *MemoryDB) *MemoryFollowStore {
+	return &MemoryFollowStore{
+		db: db,
+	}
+}
+
+// Follow is idempotent; following someone twice is not an error.
+func (m *MemoryFollowStore) Follow(ctx context.Context, followerID, followeeID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if followerID == followeeID {
+		return errCheckViolation("no_self_follow")
+	}
+	for _, id := range []int64{followerID, followeeID} {
+		if _, ok := m.db.users[id]; !ok {
+			return errMissingRow("users")
+		}
+	}
+	if m.db.isFollowing(followerID, followeeID) {
+		return nil
+	}
+
+	m.db.follows = append(m.db.follows, memoryFollow{
+		followerID: followerID,
+		followeeID: followeeID,
+		createdAt:  now(),
+	})
+	return nil
+}
+
+// Unfollow returns sql.ErrNoRows if the follower wasn't following.
+func (m *MemoryFollowStore) Unfollow(ctx context.Context, followerID, followeeID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !m.db.isFollowing(followerID, followeeID) {
+		return sql.ErrNoRows
+	}
+	m.db.follows = filter(m.db.follows, func(f memoryFollow) bool {
+		return f.followerID != followerID || f.followeeID != followeeID
+	})
+	return nil
+}
+
+// ListFollowers returns the users following userID, most recent first.
+func (m *MemoryFollowStore) ListFollowers(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error) {
+	return m.listProfiles(limit, offset, func(f memoryFollow) (int64, bool) {
+		return f.followerID, f.followeeID == userID
+	})
+}
+
+// ListFollowing returns the users userID follows, most recent first.
+func (m *MemoryFollowStore) ListFollowing(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error) {
+	return m.listProfiles(limit, offset, func(f memoryFollow) (int64, bool) {
+		return f.followeeID, f.followerID == userID
+	})
+}
+
+// listProfiles returns the profiles of the users that other picks from the
+// follows it matches.
+func (m *MemoryFollowStore) listProfiles(limit, offset int, other func(f memoryFollow) (int64, bool)) ([]models.PublicProfile, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	follows := []memoryFollow{}
+	for _, f := range m.db.follows {
+		if _, ok := other(f); ok {
+			follows = append(follows, f)
+		}
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		a, b := follows[i], follows[j]
+		if !a.createdAt.Equal(b.createdAt) {
+			return a.createdAt.After(b.createdAt)
+		}
+		idA, _ := other(a)
+		idB, _ := other(b)
+		return idA > idB
+	})
+
+	profiles := []models.PublicProfile{}
+	for _, f := range page(follows, limit, offset) {
+		id, _ := other(f)
+		profiles = append(profiles, m.db.publicProfile(m.db.users[id]))
+	}
+	return profiles, nil
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryOrganizationStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryOrganizationStore(db *MemoryDB) *MemoryOrganizationStore {
+	return &MemoryOrganizationStore{
+		db: db,
+	}
+}
+
+// CreateOrganization stores the organization and makes ownerID its owner.
+func (m *MemoryOrganizationStore) CreateOrganization(ctx context.Context, organization *models.Organization, ownerID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for _, other := range m.db.organizations {
+		if other.Slug == organization.Slug {
+			return ErrDuplicateSlug
+		}
+	}
+	if _, ok := m.db.users[ownerID]; !ok {
+		return errMissingRow("users")
+	}
+
+	organization.ID = m.db.nextID("organizations")
+	organization.CreatedAt = now()
+	organization.UpdatedAt = organization.CreatedAt
+
+	m.db.organizations[organization.ID] = clonePtr(organization)
+	m.db.members = append(m.db.members, models.OrganizationMember{
+		OrganizationID: organization.ID,
+		UserID:         ownerID,
+		Role:           models.OrganizationRoleOwner,
+		JoinedAt:       organization.CreatedAt,
+	})
+	return nil
+}
+
+// GetMembership returns the organization with the given slug as seen by
+// userID, and sql.ErrNoRows if it doesn't exist or userID isn't a member.
+func (m *MemoryOrganizationStore) GetMembership(ctx context.Context, slug string, userID int64) (*models.Membership, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, member := range m.db.members {
+		organization := m.db.organizations[member.OrganizationID]
+		if member.UserID == userID && organization.Slug == slug {
+			return &models.Membership{Organization: *organization, Role: member.Role}, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryOrganizationStore) ListMemberships(ctx context.Context, userID int64) ([]*models.Membership, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	memberships := []*models.Membership{}
+	for _, member := range m.db.members {
+		if member.UserID == userID {
+			organization := m.db.organizations[member.OrganizationID]
+			memberships = append(memberships, &models.Membership{Organization: *organization, Role: member.Role})
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i].Organization, memberships[j].Organization
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+
+	return memberships, nil
+}
+
+func (m *MemoryOrganizationStore) ListMembers(ctx context.Context, organizationID int64) ([]models.OrganizationMember, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	members := []models.OrganizationMember{}
+	for _, member := range m.db.members {
+		if member.OrganizationID == organizationID {
+			member.Username = m.db.username(member.UserID)
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Username < members[j].Username
+	})
+
+	return members, nil
+}
+
+// AddMember returns ErrAlreadyMember if the user already belongs to the
+// organization.
+func (m *MemoryOrganizationStore) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !models.IsValidOrganizationRole(member.Role) {
+		return errCheckViolation("valid_member_role")
+	}
+	for _, other := range m.db.members {
+		if other.OrganizationID == member.OrganizationID && other.UserID == member.UserID {
+			return ErrAlreadyMember
+		}
+	}
+	if _, ok := m.db.organizations[member.OrganizationID]; !ok {
+		return errMissingRow("organizations")
+	}
+	if _, ok := m.db.users[member.UserID]; !ok {
+		return errMissingRow("users")
+	}
+
+	member.JoinedAt = now()
+	stored := *member
+	stored.Username = ""
+	m.db.members = append(m.db.members, stored)
+	return nil
+}
+
+// RemoveMember never removes owners. It returns sql.ErrNoRows if there is no
+// such non-owner member.
+func (m *MemoryOrganizationStore) RemoveMember(ctx context.Context, organizationID, userID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	before := len(m.db.members)
+	m.db.members = filter(m.db.members, func(member models.OrganizationMember) bool {
+		return member.OrganizationID != organizationID || member.UserID != userID ||
+			member.Role == models.OrganizationRoleOwner
+	})
+	if len(m.db.members) == before {
+		return sql.ErrNoRows
+	}
+	return nil
+}
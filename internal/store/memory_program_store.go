@@ -0,0 +1,231 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryProgramStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryProgramStore(db *MemoryDB) *MemoryProgramStore {
+	return &MemoryProgramStore{
+		db: db,
+	}
+}
+
+func (m *MemoryProgramStore) CreateProgram(ctx context.Context, program *models.Program) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if err := m.db.checkProgram(program); err != nil {
+		return err
+	}
+
+	program.ID = m.db.nextID("programs")
+	program.CreatedAt = now()
+	program.UpdatedAt = program.CreatedAt
+	for i := range program.Sessions {
+		session := &program.Sessions[i]
+		session.ID = m.db.nextID("program_sessions")
+		session.ProgramID = program.ID
+	}
+
+	stored := cloneProgram(program)
+	sort.Ints(stored.DeloadWeeks)
+	sort.Slice(stored.Sessions, func(i, j int) bool {
+		a, b := stored.Sessions[i], stored.Sessions[j]
+		if a.Week != b.Week {
+			return a.Week < b.Week
+		}
+		return a.Day < b.Day
+	})
+	m.db.programs[program.ID] = stored
+	return nil
+}
+
+// checkProgram enforces the constraints of programs, program_deload_weeks
+// and program_sessions.
+func (db *MemoryDB) checkProgram(program *models.Program) error {
+	if _, ok := db.users[program.UserID]; !ok {
+		return errMissingRow("users")
+	}
+	if program.Weeks <= 0 || program.DeloadPercentage <= 0 || program.DeloadPercentage > 100 {
+		return errCheckViolation("valid_program")
+	}
+
+	deloadWeeks := map[int]bool{}
+	for _, week := range program.DeloadWeeks {
+		if deloadWeeks[week] {
+			return errors.New("memory store: duplicate deload week")
+		}
+		deloadWeeks[week] = true
+	}
+
+	type slot struct{ week, day int }
+	slots := map[slot]bool{}
+	for _, session := range program.Sessions {
+		if session.Week <= 0 || session.Day < 1 || session.Day > 7 ||
+			(session.IntensityPercentage != nil && (*session.IntensityPercentage <= 0 || *session.IntensityPercentage > 100)) {
+			return errCheckViolation("valid_program_session")
+		}
+		if _, ok := db.templates[session.TemplateID]; !ok {
+			return errMissingRow("workout_templates")
+		}
+		if slots[slot{session.Week, session.Day}] {
+			return errors.New("memory store: duplicate program session")
+		}
+		slots[slot{session.Week, session.Day}] = true
+	}
+	return nil
+}
+
+// GetProgramByID returns the program if it is public, owned by viewerID or
+// one viewerID is enrolled in, and sql.ErrNoRows otherwise.
+func (m *MemoryProgramStore) GetProgramByID(ctx context.Context, id, viewerID int64) (*models.Program, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	program, ok := m.db.programs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if program.UserID != viewerID && !program.IsPublic && !m.enrolled(id, viewerID) {
+		return nil, sql.ErrNoRows
+	}
+	return cloneProgram(program), nil
+}
+
+func (m *MemoryProgramStore) enrolled(programID, userID int64) bool {
+	for _, enrollment := range m.db.enrollments {
+		if enrollment.ProgramID == programID && enrollment.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ListPrograms returns the user's own programs followed by public programs
+// of other users.
+func (m *MemoryProgramStore) ListPrograms(ctx context.Context, userID int64) ([]*models.Program, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	programs := []*models.Program{}
+	for _, p := range m.db.programs {
+		if p.UserID == userID || p.IsPublic {
+			programs = append(programs, cloneProgram(p))
+		}
+	}
+	sort.Slice(programs, func(i, j int) bool {
+		a, b := programs[i], programs[j]
+		if (a.UserID == userID) != (b.UserID == userID) {
+			return a.UserID == userID
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+
+	return programs, nil
+}
+
+func (m *MemoryProgramStore) DeleteProgramByID(ctx context.Context, id, userID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	program, ok := m.db.programs[id]
+	if !ok || program.UserID != userID {
+		return sql.ErrNoRows
+	}
+	m.db.deleteProgram(id)
+	return nil
+}
+
+// programSession returns the stored session with the given id, or nil.
+func (db *MemoryDB) programSession(id int64) *models.ProgramSession {
+	for _, program := range db.programs {
+		for i := range program.Sessions {
+			if program.Sessions[i].ID == id {
+				return &program.Sessions[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (m *MemoryProgramStore) CreateEnrollment(ctx context.Context, enrollment *models.ProgramEnrollment) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.users[enrollment.UserID]; !ok {
+		return errMissingRow("users")
+	}
+	if _, ok := m.db.programs[enrollment.ProgramID]; !ok {
+		return errMissingRow("programs")
+	}
+
+	enrollment.ID = m.db.nextID("program_enrollments")
+	enrollment.Status = models.EnrollmentActive
+	enrollment.CreatedAt = now()
+	enrollment.UpdatedAt = enrollment.CreatedAt
+
+	stored := *enrollment
+	// start_date is a DATE, which comes back as midnight UTC
+	year, month, day := enrollment.StartDate.Date()
+	stored.StartDate = time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	m.db.enrollments[enrollment.ID] = &stored
+	return nil
+}
+
+func (m *MemoryProgramStore) GetEnrollmentByID(ctx context.Context, id, userID int64) (*models.ProgramEnrollment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	enrollment, ok := m.db.enrollments[id]
+	if !ok || enrollment.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return clonePtr(enrollment), nil
+}
+
+func (m *MemoryProgramStore) ListEnrollments(ctx context.Context, userID int64) ([]*models.ProgramEnrollment, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	enrollments := []*models.ProgramEnrollment{}
+	for _, e := range m.db.enrollments {
+		if e.UserID == userID {
+			enrollments = append(enrollments, clonePtr(e))
+		}
+	}
+	sort.Slice(enrollments, func(i, j int) bool {
+		a, b := enrollments[i], enrollments[j]
+		if !a.StartDate.Equal(b.StartDate) {
+			return a.StartDate.After(b.StartDate)
+		}
+		return a.ID > b.ID
+	})
+
+	return enrollments, nil
+}
+
+func (m *MemoryProgramStore) CancelEnrollment(ctx context.Context, id, userID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	enrollment, ok := m.db.enrollments[id]
+	if !ok || enrollment.UserID != userID || enrollment.Status != models.EnrollmentActive {
+		return sql.ErrNoRows
+	}
+	enrollment.Status = models.EnrollmentCancelled
+	enrollment.UpdatedAt = now()
+	return nil
+}
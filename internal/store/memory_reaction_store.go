@@ -0,0 +1,93 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryReactionStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryReactionStore(db *MemoryDB) *MemoryReactionStore {
+	return &MemoryReactionStore{
+		db: db,
+	}
+}
+
+// AddReaction is idempotent; reacting twice with the same kind keeps the
+// first reaction.
+func (m *MemoryReactionStore) AddReaction(ctx context.Context, reaction *models.Reaction) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if !models.IsValidReaction(reaction.Kind) {
+		return errCheckViolation("valid_reaction_kind")
+	}
+	if _, ok := m.db.workouts[reaction.WorkoutID]; !ok {
+		return errMissingRow("workouts")
+	}
+	if _, ok := m.db.users[reaction.UserID]; !ok {
+		return errMissingRow("users")
+	}
+
+	for _, r := range m.db.reactions {
+		if r.WorkoutID == reaction.WorkoutID && r.UserID == reaction.UserID && r.Kind == reaction.Kind {
+			reaction.CreatedAt = r.CreatedAt
+			return nil
+		}
+	}
+
+	reaction.CreatedAt = now()
+	m.db.reactions = append(m.db.reactions, models.Reaction{
+		WorkoutID: reaction.WorkoutID,
+		UserID:    reaction.UserID,
+		Kind:      reaction.Kind,
+		CreatedAt: reaction.CreatedAt,
+	})
+	return nil
+}
+
+// RemoveReaction returns sql.ErrNoRows if the user hadn't reacted with kind.
+func (m *MemoryReactionStore) RemoveReaction(ctx context.Context, workoutID, userID int64, kind string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	before := len(m.db.reactions)
+	m.db.reactions = filter(m.db.reactions, func(r models.Reaction) bool {
+		return r.WorkoutID != workoutID || r.UserID != userID || r.Kind != kind
+	})
+	if len(m.db.reactions) == before {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListReactions returns the reactions on a workout, most recent first.
+func (m *MemoryReactionStore) ListReactions(ctx context.Context, workoutID int64) ([]models.Reaction, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	reactions := []models.Reaction{}
+	for _, r := range m.db.reactions {
+		if r.WorkoutID == workoutID {
+			r.Username = m.db.username(r.UserID)
+			reactions = append(reactions, r)
+		}
+	}
+	sort.Slice(reactions, func(i, j int) bool {
+		a, b := reactions[i], reactions[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		if a.UserID != b.UserID {
+			return a.UserID < b.UserID
+		}
+		return a.Kind < b.Kind
+	})
+
+	return reactions, nil
+}
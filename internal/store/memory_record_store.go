@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+)
+
+type MemoryRecordStore struct {
+	db             *MemoryDB
+	organizationID *int64
+}
+
+func NewMemoryRecordStore(db *MemoryDB) *MemoryRecordStore {
+	return &MemoryRecordStore{
+		db: db,
+	}
+}
+
+// ForOrganization returns a store that only reads the records set by workouts
+// of the organization, or of the personal space when organizationID is nil.
+func (m *MemoryRecordStore) ForOrganization(organizationID *int64) RecordStore {
+	return &MemoryRecordStore{
+		db:             m.db,
+		organizationID: organizationID,
+	}
+}
+
+// GetCurrentRecords returns the best record of every type for each exercise
+// the user has logged. Rep records are kept per weight.
+func (m *MemoryRecordStore) GetCurrentRecords(ctx context.Context, userID int64) ([]models.PersonalRecord, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	best := map[recordKey]*memoryRecord{}
+	for _, record := range m.db.records {
+		if record.UserID != userID || !m.db.recordInOrganization(record, m.organizationID) {
+			continue
+		}
+		key := recordKey{exercise: record.exerciseKey, recordType: record.RecordType}
+		if record.RecordType == records.TypeMaxReps {
+			key.weight = *record.Weight
+		}
+		current, ok := best[key]
+		if !ok || record.Value > current.Value ||
+			(record.Value == current.Value && record.AchievedAt.Before(current.AchievedAt)) {
+			best[key] = record
+		}
+	}
+
+	keys := make([]recordKey, 0, len(best))
+	for key := range best {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.exercise != b.exercise {
+			return a.exercise < b.exercise
+		}
+		if a.recordType != b.recordType {
+			return a.recordType < b.recordType
+		}
+		return a.weight < b.weight
+	})
+
+	result := make([]models.PersonalRecord, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, cloneRecord(best[key]))
+	}
+	return result, nil
+}
+
+// GetRecordHistory returns every record the user set for the exercise, in the
+// order they were achieved.
+func (m *MemoryRecordStore) GetRecordHistory(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) ([]models.PersonalRecord, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	history := []models.PersonalRecord{}
+	for _, record := range m.db.records {
+		if record.UserID == userID && matchesExercise(record, exerciseKey, exerciseID) &&
+			m.db.recordInOrganization(record, m.organizationID) {
+			history = append(history, cloneRecord(record))
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		a, b := history[i], history[j]
+		if !a.AchievedAt.Equal(b.AchievedAt) {
+			return a.AchievedAt.Before(b.AchievedAt)
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.ID < b.ID
+	})
+
+	return history, nil
+}
+
+// GetBestEstimated1RM returns the user's best Epley one-rep max estimate for
+// the exercise, or sql.ErrNoRows if they never logged it with weight.
+func (m *MemoryRecordStore) GetBestEstimated1RM(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) (float64, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var (
+		best  float64
+		found bool
+	)
+	for _, record := range m.db.records {
+		if record.UserID != userID || record.RecordType != records.TypeEpley1RM || !matchesExercise(record, exerciseKey, exerciseID) ||
+			!m.db.recordInOrganization(record, m.organizationID) {
+			continue
+		}
+		if !found || record.Value > best {
+			best, found = record.Value, true
+		}
+	}
+	if !found {
+		return 0, sql.ErrNoRows
+	}
+	return best, nil
+}
+
+// matchesExercise reports whether the record is for the exercise, by name or
+// by catalog id.
+func matchesExercise(record *memoryRecord, exerciseKey string, exerciseID *int64) bool {
+	if record.exerciseKey == exerciseKey {
+		return true
+	}
+	return exerciseID != nil && record.ExerciseID != nil && *record.ExerciseID == *exerciseID
+}
+
+// recordInOrganization reports whether the record was set by a workout of the
+// organization, or of the personal space when organizationID is nil.
+func (db *MemoryDB) recordInOrganization(record *memoryRecord, organizationID *int64) bool {
+	workout, ok := db.workouts[record.WorkoutID]
+	return ok && sameOrganization(workout.OrganizationID, organizationID)
+}
+
+func cloneRecord(record *memoryRecord) models.PersonalRecord {
+	r := record.PersonalRecord
+	r.ExerciseID = clonePtr(r.ExerciseID)
+	r.Weight = clonePtr(r.Weight)
+	r.Reps = clonePtr(r.Reps)
+	r.PreviousValue = nil
+	return r
+}
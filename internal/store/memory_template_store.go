@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type MemoryTemplateStore struct {
+	db *MemoryDB
+	// organizationID is the tenant every call is restricted to, nil being
+	// the personal space.
+	organizationID *int64
+}
+
+func NewMemoryTemplateStore(db *MemoryDB) *MemoryTemplateStore {
+	return &MemoryTemplateStore{
+		db: db,
+	}
+}
+
+// ForOrganization returns a store restricted to the organization's
+// templates, or to personal templates when organizationID is nil.
+func (m *MemoryTemplateStore) ForOrganization(organizationID *int64) TemplateStore {
+	return &MemoryTemplateStore{
+		db:             m.db,
+		organizationID: organizationID,
+	}
+}
+
+// find returns the stored template if it belongs to userID and the store's
+// organization.
+func (m *MemoryTemplateStore) find(id, userID int64) (*models.WorkoutTemplate, bool) {
+	template, ok := m.db.templates[id]
+	if !ok || template.UserID != userID || !sameOrganization(template.OrganizationID, m.organizationID) {
+		return nil, false
+	}
+	return template, true
+}
+
+func (m *MemoryTemplateStore) CreateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.users[template.UserID]; !ok {
+		return errMissingRow("users")
+	}
+	if m.organizationID != nil {
+		if _, ok := m.db.organizations[*m.organizationID]; !ok {
+			return errMissingRow("organizations")
+		}
+	}
+	if err := m.db.checkTemplateEntries(template.Entries); err != nil {
+		return err
+	}
+
+	template.ID = m.db.nextID("workout_templates")
+	template.OrganizationID = clonePtr(m.organizationID)
+	template.CreatedAt = now()
+	template.UpdatedAt = template.CreatedAt
+	m.db.stampTemplateEntries(template)
+
+	m.db.templates[template.ID] = cloneTemplate(template)
+	return nil
+}
+
+// GetTemplateByID returns the template if it belongs to userID and
+// sql.ErrNoRows otherwise. Templates are always private.
+func (m *MemoryTemplateStore) GetTemplateByID(ctx context.Context, id, userID int64) (*models.WorkoutTemplate, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	template, ok := m.find(id, userID)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return loadTemplate(template), nil
+}
+
+func (m *MemoryTemplateStore) ListTemplates(ctx context.Context, userID int64) ([]*models.WorkoutTemplate, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	templates := []*models.WorkoutTemplate{}
+	for _, t := range m.db.templates {
+		if t.UserID == userID && sameOrganization(t.OrganizationID, m.organizationID) {
+			templates = append(templates, loadTemplate(t))
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Name != templates[j].Name {
+			return templates[i].Name < templates[j].Name
+		}
+		return templates[i].ID < templates[j].ID
+	})
+
+	return templates, nil
+}
+
+func (m *MemoryTemplateStore) UpdateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.find(template.ID, template.UserID)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := m.db.checkTemplateEntries(template.Entries); err != nil {
+		return err
+	}
+
+	stored.Name = template.Name
+	stored.Description = template.Description
+	stored.UpdatedAt = now()
+	template.UpdatedAt = stored.UpdatedAt
+
+	m.db.stampTemplateEntries(template)
+	stored.Entries = cloneTemplate(template).Entries
+	return nil
+}
+
+// DeleteTemplateByID returns ErrTemplateInUse while a program schedules the
+// template.
+func (m *MemoryTemplateStore) DeleteTemplateByID(ctx context.Context, id, userID int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.find(id, userID); !ok {
+		return sql.ErrNoRows
+	}
+	for _, program := range m.db.programs {
+		for _, session := range program.Sessions {
+			if session.TemplateID == id {
+				return ErrTemplateInUse
+			}
+		}
+	}
+
+	m.db.deleteTemplate(id)
+	return nil
+}
+
+// checkTemplateEntries enforces valid_template_entry and the exercise
+// references of the entries.
+func (db *MemoryDB) checkTemplateEntries(entries []models.WorkoutTemplateEntry) error {
+	for _, entry := range entries {
+		if entry.ExerciseID != nil {
+			if _, ok := db.exercises[*entry.ExerciseID]; !ok {
+				return errMissingRow("exercises")
+			}
+		}
+		if entry.TargetSets <= 0 || (entry.TargetReps == nil) == (entry.TargetDurationSeconds == nil) {
+			return errCheckViolation("valid_template_entry")
+		}
+	}
+	return nil
+}
+
+func (db *MemoryDB) stampTemplateEntries(template *models.WorkoutTemplate) {
+	now := now()
+	for i := range template.Entries {
+		entry := &template.Entries[i]
+		entry.ID = db.nextID("workout_template_entries")
+		entry.TemplateID = template.ID
+		entry.CreatedAt = now
+		entry.UpdatedAt = now
+	}
+}
+
+// loadTemplate copies the stored template with its entries in order.
+func loadTemplate(t *models.WorkoutTemplate) *models.WorkoutTemplate {
+	template := cloneTemplate(t)
+	sort.SliceStable(template.Entries, func(i, j int) bool {
+		return template.Entries[i].OrderIndex < template.Entries[j].OrderIndex
+	})
+	return template
+}
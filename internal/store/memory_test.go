@@ -0,0 +1,29 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/store/storetest"
+)
+
+func TestMemoryStores(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		db := store.NewMemoryDB()
+		return storetest.Stores{
+			Users:         store.NewMemoryUserStore(db),
+			Workouts:      store.NewMemoryWorkoutStore(db),
+			Tokens:        store.NewMemoryTokenStore(db, storetest.TTLs),
+			Templates:     store.NewMemoryTemplateStore(db),
+			Exercises:     store.NewMemoryExerciseStore(db),
+			Programs:      store.NewMemoryProgramStore(db),
+			Follows:       store.NewMemoryFollowStore(db),
+			Coaches:       store.NewMemoryCoachStore(db),
+			Organizations: store.NewMemoryOrganizationStore(db),
+			Admin:         store.NewMemoryAdminStore(db),
+			Records:       store.NewMemoryRecordStore(db),
+			Analytics:     analytics.NewMemoryStore(db),
+		}
+	})
+}
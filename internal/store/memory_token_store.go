@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+type MemoryTokenStore struct {
+	db   *MemoryDB
+	ttls tokens.TTLs
+}
+
+func NewMemoryTokenStore(db *MemoryDB, ttls tokens.TTLs) *MemoryTokenStore {
+	return &MemoryTokenStore{
+		db:   db,
+		ttls: ttls,
+	}
+}
+
+func (m *MemoryTokenStore) CreateNewToken(ctx context.Context, userID int64, scope string) (*tokens.Token, error) {
+	token, err := tokens.GenerateToken(userID, m.ttls.For(scope), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Insert(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (m *MemoryTokenStore) Insert(ctx context.Context, token *tokens.Token) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	return m.insert(token)
+}
+
+func (m *MemoryTokenStore) insert(token *tokens.Token) error {
+	if _, ok := m.db.users[token.UserID]; !ok {
+		return errMissingRow("users")
+	}
+	key := string(token.Hash)
+	if _, ok := m.db.tokens[key]; ok {
+		return errors.New("memory store: duplicate token hash")
+	}
+
+	m.db.tokens[key] = &memoryToken{
+		id:     m.db.nextID("tokens"),
+		hash:   append([]byte(nil), token.Hash...),
+		userID: token.UserID,
+		// the expiry column is TIMESTAMP(0), which rounds to the second
+		expiry:    token.Expiry.Round(time.Second),
+		scope:     token.Scope,
+		createdAt: now(),
+		userAgent: token.UserAgent,
+		ip:        token.IP,
+		familyID:  token.FamilyID,
+	}
+	return nil
+}
+
+// CreateTokenPair starts a new token family for a login.
+func (m *MemoryTokenStore) CreateTokenPair(ctx context.Context, userID int64, userAgent, ip string) (*tokens.Pair, error) {
+	familyID, err := tokens.NewFamilyID()
+	if err != nil {
+		return nil, err
+	}
+
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	return m.insertPair(userID, familyID, userAgent, ip)
+}
+
+// RotateRefreshToken trades a refresh token for a new pair in the same
+// family. Presenting a used one revokes the family and returns
+// ErrRefreshTokenReused. Unknown or expired tokens give sql.ErrNoRows.
+func (m *MemoryTokenStore) RotateRefreshToken(ctx context.Context, plaintextToken, userAgent, ip string) (*tokens.Pair, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	now := now()
+	token, ok := m.db.tokens[string(tokens.Hash(plaintextToken))]
+	if !ok || token.scope != tokens.ScopeRefresh || !token.expiry.After(now) {
+		return nil, sql.ErrNoRows
+	}
+
+	if token.usedAt != nil {
+		m.deleteFamily(token.familyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	token.usedAt = &now
+	token.lastUsedAt = &now
+
+	return m.insertPair(token.userID, token.familyID, userAgent, ip)
+}
+
+func (m *MemoryTokenStore) insertPair(userID int64, familyID, userAgent, ip string) (*tokens.Pair, error) {
+	pair, err := tokens.GeneratePair(userID, familyID, m.ttls)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range []*tokens.Token{pair.Access, pair.Refresh} {
+		token.UserAgent = userAgent
+		token.IP = ip
+		if err := m.insert(token); err != nil {
+			return nil, err
+		}
+	}
+
+	return pair, nil
+}
+
+// deleteFamily removes every token of the family. Tokens without a family
+// don't belong to one, like NULL never equals NULL.
+func (m *MemoryTokenStore) deleteFamily(familyID string) {
+	if familyID == "" {
+		return
+	}
+
+	for key, token := range m.db.tokens {
+		if token.familyID == familyID {
+			delete(m.db.tokens, key)
+		}
+	}
+}
+
+func (m *MemoryTokenStore) DeleteAllTokensForUser(ctx context.Context, userID int64, scope string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	for key, token := range m.db.tokens {
+		if token.scope == scope && token.userID == userID {
+			delete(m.db.tokens, key)
+		}
+	}
+	return nil
+}
+
+// DeleteToken revokes a token together with the rest of its family. It
+// returns sql.ErrNoRows if the token doesn't exist.
+func (m *MemoryTokenStore) DeleteToken(ctx context.Context, scope, plaintextToken string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	key := string(tokens.Hash(plaintextToken))
+	token, ok := m.db.tokens[key]
+	if !ok || token.scope != scope {
+		return sql.ErrNoRows
+	}
+
+	delete(m.db.tokens, key)
+	m.deleteFamily(token.familyID)
+	return nil
+}
+
+// ListSessions returns the user's active logins, most recently used first.
+// Tokens of one family form one session; tokens without a family are a
+// session of their own.
+func (m *MemoryTokenStore) ListSessions(ctx context.Context, userID int64, currentToken string) ([]tokens.Session, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	current := string(tokens.Hash(currentToken))
+	now := time.Now()
+
+	type group struct {
+		session tokens.Session
+		// newest is the id of the latest token, whose user agent and ip
+		// describe the session
+		newest int64
+	}
+	groups := map[string]*group{}
+	for key, token := range m.db.tokens {
+		if token.userID != userID || !token.expiry.After(now) || token.usedAt != nil {
+			continue
+		}
+
+		groupKey := token.familyID
+		if groupKey == "" {
+			groupKey = "hash:" + key
+		}
+		g, ok := groups[groupKey]
+		if !ok {
+			g = &group{session: tokens.Session{ID: token.id, CreatedAt: token.createdAt, Expiry: token.expiry}}
+			groups[groupKey] = g
+		}
+
+		s := &g.session
+		if token.id < s.ID {
+			s.ID = token.id
+		}
+		if token.createdAt.Before(s.CreatedAt) {
+			s.CreatedAt = token.createdAt
+		}
+		if token.lastUsedAt != nil && (s.LastUsedAt == nil || token.lastUsedAt.After(*s.LastUsedAt)) {
+			s.LastUsedAt = clonePtr(token.lastUsedAt)
+		}
+		if token.expiry.After(s.Expiry) {
+			s.Expiry = token.expiry
+		}
+		if token.id > g.newest {
+			g.newest = token.id
+			s.UserAgent = token.userAgent
+			s.IP = token.ip
+		}
+		if key == current {
+			s.Current = true
+		}
+	}
+
+	sessions := []tokens.Session{}
+	for _, g := range groups {
+		sessions = append(sessions, g.session)
+	}
+	lastActive := func(s tokens.Session) time.Time {
+		if s.LastUsedAt != nil {
+			return *s.LastUsedAt
+		}
+		return s.CreatedAt
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		a, b := lastActive(sessions[i]), lastActive(sessions[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// TouchToken records that the token was just used, at most once per
+// interval.
+func (m *MemoryTokenStore) TouchToken(ctx context.Context, plaintextToken string, interval time.Duration) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	token, ok := m.db.tokens[string(tokens.Hash(plaintextToken))]
+	if !ok {
+		return nil
+	}
+	now := now()
+	if token.lastUsedAt == nil || token.lastUsedAt.Before(now.Add(-interval)) {
+		token.lastUsedAt = &now
+	}
+	return nil
+}
+
+// DeleteExpiredTokens removes tokens of every scope whose expiry has passed
+// and returns how many there were.
+func (m *MemoryTokenStore) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	var deleted int64
+	now := time.Now()
+	for key, token := range m.db.tokens {
+		if token.expiry.Before(now) {
+			delete(m.db.tokens, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
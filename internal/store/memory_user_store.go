@@ -0,0 +1,355 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+type MemoryUserStore struct {
+	db *MemoryDB
+}
+
+func NewMemoryUserStore(db *MemoryDB) *MemoryUserStore {
+	return &MemoryUserStore{
+		db: db,
+	}
+}
+
+func (m *MemoryUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if err := m.checkUnique(user); err != nil {
+		return err
+	}
+
+	if user.Timezone == "" {
+		user.Timezone = "UTC"
+	}
+	user.ID = m.db.nextID("users")
+	user.Activated = false
+	user.Role = models.RoleUser
+	user.Disabled = false
+	user.CreatedAt = now()
+	user.UpdatedAt = user.CreatedAt
+
+	m.db.users[user.ID] = cloneUser(user)
+	return nil
+}
+
+// checkUnique mirrors the unique username and email constraints. Usernames
+// compare exactly, emails ignore case like the index on lower(email).
+func (m *MemoryUserStore) checkUnique(user *models.User) error {
+	for _, other := range m.db.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Username == user.Username {
+			return ErrDuplicateUsername
+		}
+	}
+	for _, other := range m.db.users {
+		if other.ID != user.ID && strings.EqualFold(other.Email, user.Email) {
+			return ErrDuplicateEmail
+		}
+	}
+	return nil
+}
+
+func (m *MemoryUserStore) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	user, ok := m.db.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneUser(user), nil
+}
+
+func (m *MemoryUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return m.findUser(func(u *models.User) bool {
+		return u.Username == username
+	})
+}
+
+// GetUserByEmail matches the address case-insensitively.
+func (m *MemoryUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	return m.findUser(func(u *models.User) bool {
+		return strings.EqualFold(u.Email, email)
+	})
+}
+
+func (m *MemoryUserStore) findUser(match func(u *models.User) bool) (*models.User, error) {
+	for _, user := range m.db.users {
+		if match(user) {
+			return cloneUser(user), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// ListUsers returns every account, oldest first, for the admin API.
+func (m *MemoryUserStore) ListUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	users := []*models.User{}
+	for _, user := range m.db.users {
+		users = append(users, cloneUser(user))
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	return page(users, limit, offset), nil
+}
+
+// SearchUsersByUsername finds users whose username starts with query or is
+// similar to it. Prefix matches come first, the rest are ranked by trigram
+// similarity, like pg_trgm does in Postgres.
+func (m *MemoryUserStore) SearchUsersByUsername(ctx context.Context, query string, limit, offset int) ([]models.PublicProfile, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	q := strings.ToLower(query)
+
+	type match struct {
+		profile    models.PublicProfile
+		prefix     bool
+		similarity float64
+	}
+	matches := []match{}
+	for _, user := range m.db.users {
+		username := strings.ToLower(user.Username)
+		prefix := strings.HasPrefix(username, q)
+		similarity := trigramSimilarity(username, q)
+		if !prefix && similarity <= trigramThreshold {
+			continue
+		}
+		matches = append(matches, match{m.db.publicProfile(user), prefix, similarity})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.similarity != b.similarity {
+			return a.similarity > b.similarity
+		}
+		return a.profile.Username < b.profile.Username
+	})
+
+	profiles := []models.PublicProfile{}
+	for _, match := range page(matches, limit, offset) {
+		profiles = append(profiles, match.profile)
+	}
+	return profiles, nil
+}
+
+// trigramThreshold is the default pg_trgm.similarity_threshold used by the
+// % operator.
+const trigramThreshold = 0.3
+
+// trigramSimilarity computes pg_trgm's similarity: the share of trigrams
+// the two strings have in common.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+// trigrams splits s into words the way pg_trgm does and returns the
+// trigrams of every word padded with two spaces in front and one behind.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+func (m *MemoryUserStore) GetPublicProfile(ctx context.Context, username string) (*models.PublicProfile, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, user := range m.db.users {
+		if user.Username == username {
+			profile := m.db.publicProfile(user)
+			return &profile, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryUserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.db.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := m.checkUnique(user); err != nil {
+		return err
+	}
+
+	stored.Username = user.Username
+	stored.Email = user.Email
+	stored.Bio = user.Bio
+	stored.Timezone = user.Timezone
+	stored.Activated = user.Activated
+	stored.UpdatedAt = now()
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// DeleteUser removes the account together with everything the user owns.
+func (m *MemoryUserStore) DeleteUser(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.db.users[id]; !ok {
+		return sql.ErrNoRows
+	}
+	m.db.deleteUser(id)
+	return nil
+}
+
+func (m *MemoryUserStore) UpdatePassword(ctx context.Context, user *models.User) error {
+	return m.update(user, func(stored *models.User) {
+		stored.PasswordHash.Hash = append([]byte(nil), user.PasswordHash.Hash...)
+	})
+}
+
+// ResetPassword consumes the password reset token, stores the user's new
+// password hash and logs out every session, all or nothing. It returns
+// sql.ErrNoRows if the token was already used.
+func (m *MemoryUserStore) ResetPassword(ctx context.Context, user *models.User, plaintextToken string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	token, ok := m.db.tokens[string(tokens.Hash(plaintextToken))]
+	if !ok || token.scope != tokens.ScopePasswordReset || token.userID != user.ID {
+		return sql.ErrNoRows
+	}
+	stored, ok := m.db.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	stored.PasswordHash.Hash = append([]byte(nil), user.PasswordHash.Hash...)
+	stored.UpdatedAt = now()
+	user.UpdatedAt = stored.UpdatedAt
+
+	for key, t := range m.db.tokens {
+		if t.userID == user.ID && slices.Contains(resetRevokedScopes, t.scope) {
+			delete(m.db.tokens, key)
+		}
+	}
+	return nil
+}
+
+// ChangePassword stores the user's new password hash and logs out every
+// session but the one currentToken belongs to, together with any reset
+// links.
+func (m *MemoryUserStore) ChangePassword(ctx context.Context, user *models.User, currentToken string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.db.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	stored.PasswordHash.Hash = append([]byte(nil), user.PasswordHash.Hash...)
+	stored.UpdatedAt = now()
+	user.UpdatedAt = stored.UpdatedAt
+
+	current := string(tokens.Hash(currentToken))
+	var familyID string
+	if token, ok := m.db.tokens[current]; ok {
+		familyID = token.familyID
+	}
+	for key, t := range m.db.tokens {
+		// tokens without a family are sessions of their own
+		sameSession := key == current || (familyID != "" && t.familyID == familyID)
+		if t.userID == user.ID && slices.Contains(resetRevokedScopes, t.scope) && !sameSession {
+			delete(m.db.tokens, key)
+		}
+	}
+	return nil
+}
+
+// UpdateRole changes the user's role. It returns sql.ErrNoRows if the user
+// doesn't exist.
+func (m *MemoryUserStore) UpdateRole(ctx context.Context, user *models.User) error {
+	return m.update(user, func(stored *models.User) {
+		stored.Role = user.Role
+	})
+}
+
+// UpdateDisabled enables or disables the account. It returns sql.ErrNoRows
+// if the user doesn't exist.
+func (m *MemoryUserStore) UpdateDisabled(ctx context.Context, user *models.User) error {
+	return m.update(user, func(stored *models.User) {
+		stored.Disabled = user.Disabled
+	})
+}
+
+func (m *MemoryUserStore) update(user *models.User, set func(stored *models.User)) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.db.users[user.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	set(stored)
+	stored.UpdatedAt = now()
+	user.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (m *MemoryUserStore) GetUserByToken(ctx context.Context, scope, plaintextToken string) (*models.User, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	token, ok := m.db.tokens[string(tokens.Hash(plaintextToken))]
+	if !ok || token.scope != scope || !token.expiry.After(time.Now()) {
+		return nil, sql.ErrNoRows
+	}
+	user, ok := m.db.users[token.userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return cloneUser(user), nil
+}
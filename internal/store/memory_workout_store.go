@@ -0,0 +1,599 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+)
+
+type MemoryWorkoutStore struct {
+	db *MemoryDB
+	// organizationID is the tenant every call is restricted to, nil being
+	// the personal space.
+	organizationID *int64
+}
+
+func NewMemoryWorkoutStore(db *MemoryDB) *MemoryWorkoutStore {
+	return &MemoryWorkoutStore{
+		db: db,
+	}
+}
+
+// ForOrganization returns a store that only reads and writes the workouts of
+// the organization, or the personal space when organizationID is nil.
+func (m *MemoryWorkoutStore) ForOrganization(organizationID *int64) WorkoutStore {
+	return &MemoryWorkoutStore{
+		db:             m.db,
+		organizationID: organizationID,
+	}
+}
+
+// find returns the stored workout if it is in the store's organization.
+func (m *MemoryWorkoutStore) find(id int64) (*models.Workout, bool) {
+	workout, ok := m.db.workouts[id]
+	if !ok || !sameOrganization(workout.OrganizationID, m.organizationID) {
+		return nil, false
+	}
+	return workout, true
+}
+
+// visibleTo reports whether the viewer may see the workout: they own it, it
+// is public, it is shared with followers and they follow its owner, or they
+// actively coach its owner.
+func (db *MemoryDB) visibleTo(w *models.Workout, viewerID int64) bool {
+	switch {
+	case w.UserID == viewerID, w.Visibility == models.VisibilityPublic:
+		return true
+	case w.Visibility == models.VisibilityFollowers && db.isFollowing(viewerID, w.UserID):
+		return true
+	}
+	return db.isCoach(viewerID, w.UserID)
+}
+
+// GetWorkoutByID returns the workout only if viewerID is allowed to see it.
+// Workouts hidden from the viewer are reported as sql.ErrNoRows.
+func (m *MemoryWorkoutStore) GetWorkoutByID(ctx context.Context, id, viewerID int64) (*models.Workout, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	stored, ok := m.find(id)
+	if !ok || !m.db.visibleTo(stored, viewerID) {
+		return nil, sql.ErrNoRows
+	}
+
+	workout := m.db.loadWorkout(stored)
+	if workout.UserID != viewerID {
+		workout.ShareToken = ""
+	}
+	return workout, nil
+}
+
+func (m *MemoryWorkoutStore) GetWorkoutByShareToken(ctx context.Context, token string) (*models.Workout, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	for _, w := range m.db.workouts {
+		if w.ShareToken != "" && w.ShareToken == token && w.Visibility == models.VisibilityUnlisted &&
+			sameOrganization(w.OrganizationID, m.organizationID) {
+			return m.db.loadWorkout(w), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// GetLastCompletedFromTemplate returns the user's most recent completed
+// workout that was started from the template.
+func (m *MemoryWorkoutStore) GetLastCompletedFromTemplate(ctx context.Context, userID, templateID int64) (*models.Workout, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	var last *models.Workout
+	for _, w := range m.db.workouts {
+		if w.UserID != userID || w.TemplateID == nil || *w.TemplateID != templateID ||
+			w.Status != models.StatusCompleted || !sameOrganization(w.OrganizationID, m.organizationID) {
+			continue
+		}
+		if last == nil || w.CreatedAt.After(last.CreatedAt) {
+			last = w
+		}
+	}
+	if last == nil {
+		return nil, sql.ErrNoRows
+	}
+	return m.db.loadWorkout(last), nil
+}
+
+func (m *MemoryWorkoutStore) CreateWorkout(ctx context.Context, workout *models.Workout) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if err := m.db.checkWorkout(workout); err != nil {
+		return err
+	}
+	if err := m.db.checkWorkoutReferences(workout, m.organizationID); err != nil {
+		return err
+	}
+
+	workout.OrganizationID = clonePtr(m.organizationID)
+	workout.CommentCount = 0
+	workout.ReactionCounts = map[string]int{}
+
+	// never trust a share token coming from the client
+	workout.ShareToken = ""
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
+	workout.ID = m.db.nextID("workouts")
+	workout.CreatedAt = now()
+	workout.UpdatedAt = workout.CreatedAt
+	m.db.stampEntries(workout)
+
+	m.db.workouts[workout.ID] = cloneWorkout(workout)
+	workout.NewRecords = m.db.detectRecords(workout)
+	return nil
+}
+
+func (m *MemoryWorkoutStore) UpdateWorkoutByID(ctx context.Context, workout *models.Workout) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	stored, ok := m.find(workout.ID)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if err := m.db.checkWorkout(workout); err != nil {
+		return err
+	}
+
+	// a workout that stays unlisted keeps its stored link; callers who
+	// can't see the token, like coaches, would otherwise replace it
+	if workout.Visibility == models.VisibilityUnlisted && stored.ShareToken != "" {
+		workout.ShareToken = stored.ShareToken
+	}
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
+	stored.Title = workout.Title
+	stored.Description = workout.Description
+	stored.DurationMinutes = workout.DurationMinutes
+	stored.CaloriesBurned = workout.CaloriesBurned
+	stored.Visibility = workout.Visibility
+	stored.ShareToken = workout.ShareToken
+	stored.Status = workout.Status
+	stored.UpdatedAt = now()
+	workout.UpdatedAt = stored.UpdatedAt
+
+	m.db.stampEntries(workout)
+	stored.Entries = cloneWorkout(workout).Entries
+
+	workout.NewRecords = m.db.rebuildRecords(stored)
+	return nil
+}
+
+// checkWorkout enforces the CHECK constraints of workouts, workout_entries
+// and workout_sets, and the exercise references of the entries, before
+// anything is written.
+func (db *MemoryDB) checkWorkout(workout *models.Workout) error {
+	if !models.IsValidVisibility(workout.Visibility) {
+		return errCheckViolation("valid_workout_visibility")
+	}
+	if !models.IsValidStatus(workout.Status) {
+		return errCheckViolation("valid_workout_status")
+	}
+	for _, entry := range workout.Entries {
+		if entry.ExerciseID != nil {
+			if _, ok := db.exercises[*entry.ExerciseID]; !ok {
+				return errMissingRow("exercises")
+			}
+		}
+		// never both reps and duration, and one of them unless there are sets
+		if entry.Reps != nil && entry.DurationSeconds != nil {
+			return errCheckViolation("valid_workout_entry")
+		}
+		if len(entry.Sets) == 0 && entry.Reps == nil && entry.DurationSeconds == nil {
+			return errCheckViolation("valid_workout_entry")
+		}
+		for _, set := range entry.Sets {
+			if set.Reps == nil && set.DurationSeconds == nil && set.DistanceMeters == nil {
+				return errCheckViolation("valid_workout_set")
+			}
+			if set.RPE != nil && (*set.RPE < 1 || *set.RPE > 10) {
+				return errCheckViolation("valid_workout_set_rpe")
+			}
+		}
+	}
+	return nil
+}
+
+// checkWorkoutReferences stands in for the foreign keys of a new workout.
+func (db *MemoryDB) checkWorkoutReferences(workout *models.Workout, organizationID *int64) error {
+	if _, ok := db.users[workout.UserID]; !ok {
+		return errMissingRow("users")
+	}
+	if organizationID != nil {
+		if _, ok := db.organizations[*organizationID]; !ok {
+			return errMissingRow("organizations")
+		}
+	}
+	if workout.TemplateID != nil {
+		if _, ok := db.templates[*workout.TemplateID]; !ok {
+			return errMissingRow("workout_templates")
+		}
+	}
+	if workout.EnrollmentID != nil {
+		if _, ok := db.enrollments[*workout.EnrollmentID]; !ok {
+			return errMissingRow("program_enrollments")
+		}
+	}
+	if workout.ProgramSessionID != nil && db.programSession(*workout.ProgramSessionID) == nil {
+		return errMissingRow("program_sessions")
+	}
+	return nil
+}
+
+// stampEntries gives the entries and sets of the workout fresh ids and
+// timestamps, as inserting them again does in Postgres.
+func (db *MemoryDB) stampEntries(workout *models.Workout) {
+	now := now()
+	for i := range workout.Entries {
+		entry := &workout.Entries[i]
+		entry.ID = db.nextID("workout_entries")
+		entry.WorkoutID = workout.ID
+		entry.CreatedAt = now
+		entry.UpdatedAt = now
+
+		for j := range entry.Sets {
+			set := &entry.Sets[j]
+			set.ID = db.nextID("workout_sets")
+			set.WorkoutEntryID = entry.ID
+			set.SetNumber = j + 1
+			set.CreatedAt = now
+			set.UpdatedAt = now
+		}
+	}
+}
+
+// detectRecords recomputes the personal records set by the workout and
+// returns the new ones, like its Postgres counterpart.
+func (db *MemoryDB) detectRecords(workout *models.Workout) []models.PersonalRecord {
+	for id, record := range db.records {
+		if record.WorkoutID == workout.ID {
+			delete(db.records, id)
+		}
+	}
+	if workout.Status != models.StatusCompleted {
+		return nil
+	}
+
+	order, candidates := bestCandidates(workout, db.catalogNames(workout))
+
+	newRecords := []models.PersonalRecord{}
+	for _, key := range order {
+		record := candidates[key]
+
+		var previous *float64
+		for _, r := range db.records {
+			if r.UserID != workout.UserID || r.exerciseKey != key.exercise || r.RecordType != key.recordType ||
+				!db.recordInOrganization(r, workout.OrganizationID) {
+				continue
+			}
+			if key.recordType == records.TypeMaxReps && (r.Weight == nil || *r.Weight != key.weight) {
+				continue
+			}
+			if previous == nil || r.Value > *previous {
+				previous = clonePtr(&r.Value)
+			}
+		}
+		if previous != nil && *previous >= record.Value {
+			continue
+		}
+		record.PreviousValue = previous
+
+		record.ID = db.nextID("personal_records")
+		db.storeRecord(record, key.exercise)
+
+		newRecords = append(newRecords, record)
+	}
+
+	return newRecords
+}
+
+// catalogNames looks up the canonical names of the exercises referenced by
+// the workout's entries.
+func (db *MemoryDB) catalogNames(workout *models.Workout) map[int64]string {
+	names := map[int64]string{}
+	for _, entry := range workout.Entries {
+		if entry.ExerciseID == nil {
+			continue
+		}
+		if exercise, ok := db.exercises[*entry.ExerciseID]; ok {
+			names[exercise.ID] = exercise.Name
+		}
+	}
+	return names
+}
+
+// storeRecord inserts a copy of the record under its exercise key.
+func (db *MemoryDB) storeRecord(record models.PersonalRecord, exerciseKey string) {
+	stored := record
+	stored.ExerciseID = clonePtr(record.ExerciseID)
+	stored.Weight = clonePtr(record.Weight)
+	stored.Reps = clonePtr(record.Reps)
+	stored.PreviousValue = nil
+	db.records[record.ID] = &memoryRecord{PersonalRecord: stored, exerciseKey: exerciseKey}
+}
+
+// rebuildRecords recomputes the user's records for every exercise the
+// workout had or now has records for by replaying the completed workouts of
+// its organization oldest first, like its Postgres counterpart.
+func (db *MemoryDB) rebuildRecords(workout *models.Workout) []models.PersonalRecord {
+	affected := map[string]bool{}
+	for _, record := range db.records {
+		if record.WorkoutID == workout.ID {
+			affected[record.exerciseKey] = true
+		}
+	}
+	if workout.Status == models.StatusCompleted {
+		order, _ := bestCandidates(workout, db.catalogNames(workout))
+		for _, key := range order {
+			affected[key.exercise] = true
+		}
+	}
+
+	for id, record := range db.records {
+		if record.UserID == workout.UserID && affected[record.exerciseKey] &&
+			db.recordInOrganization(record, workout.OrganizationID) {
+			delete(db.records, id)
+		}
+	}
+
+	history := []*models.Workout{}
+	for _, w := range db.workouts {
+		if w.UserID == workout.UserID && w.Status == models.StatusCompleted &&
+			sameOrganization(w.OrganizationID, workout.OrganizationID) {
+			history = append(history, w)
+		}
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].CreatedAt.Equal(history[j].CreatedAt) {
+			return history[i].CreatedAt.Before(history[j].CreatedAt)
+		}
+		return history[i].ID < history[j].ID
+	})
+
+	held := []models.PersonalRecord{}
+	best := map[recordKey]float64{}
+	for _, w := range history {
+		order, candidates := bestCandidates(w, db.catalogNames(w))
+		for _, key := range order {
+			if !affected[key.exercise] {
+				continue
+			}
+			record := candidates[key]
+
+			previous, ok := best[key]
+			if ok && previous >= record.Value {
+				continue
+			}
+			if ok {
+				record.PreviousValue = &previous
+			}
+			best[key] = record.Value
+
+			record.ID = db.nextID("personal_records")
+			db.storeRecord(record, key.exercise)
+			if w.ID == workout.ID {
+				held = append(held, record)
+			}
+		}
+	}
+
+	return held
+}
+
+// ListEnrollmentWorkouts returns the workouts logged for the sessions of a
+// program enrollment.
+func (m *MemoryWorkoutStore) ListEnrollmentWorkouts(ctx context.Context, enrollmentID int64) ([]models.SessionWorkout, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	workouts := []models.SessionWorkout{}
+	for _, w := range m.db.workouts {
+		if w.EnrollmentID == nil || *w.EnrollmentID != enrollmentID || w.ProgramSessionID == nil ||
+			!sameOrganization(w.OrganizationID, m.organizationID) {
+			continue
+		}
+		workouts = append(workouts, models.SessionWorkout{
+			WorkoutID:        w.ID,
+			ProgramSessionID: *w.ProgramSessionID,
+			Status:           w.Status,
+			CreatedAt:        w.CreatedAt,
+		})
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workouts[i].CreatedAt.Before(workouts[j].CreatedAt)
+	})
+
+	return workouts, nil
+}
+
+func (m *MemoryWorkoutStore) DeleteWorkoutByID(ctx context.Context, id int64) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+
+	if _, ok := m.find(id); !ok {
+		return sql.ErrNoRows
+	}
+	m.db.deleteWorkout(id)
+	return nil
+}
+
+// GetVisibleWorkoutOwner returns the owner of the workout if the viewer may
+// see it, and sql.ErrNoRows otherwise.
+func (m *MemoryWorkoutStore) GetVisibleWorkoutOwner(ctx context.Context, workoutID, viewerID int64) (int64, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	workout, ok := m.find(workoutID)
+	if !ok || !m.db.visibleTo(workout, viewerID) {
+		return 0, sql.ErrNoRows
+	}
+	return workout.UserID, nil
+}
+
+func (m *MemoryWorkoutStore) ListWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, string, error) {
+	if _, err := workoutSortExpr(filter.Sort); err != nil {
+		return nil, "", err
+	}
+
+	var after *models.Workout
+	if filter.Cursor != "" {
+		key, id, err := decodeWorkoutCursor(filter.Sort, filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursorWorkout(filter.Sort, key, id)
+	}
+
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	matches := func(w *models.Workout) bool {
+		switch {
+		case w.UserID != filter.UserID, !sameOrganization(w.OrganizationID, m.organizationID):
+			return false
+		case filter.From != nil && w.CreatedAt.Before(*filter.From):
+			return false
+		case filter.To != nil && !w.CreatedAt.Before(*filter.To):
+			return false
+		case filter.Title != "" && !containsFold(w.Title, filter.Title):
+			return false
+		case filter.MinDuration != nil && w.DurationMinutes < *filter.MinDuration:
+			return false
+		case filter.MaxDuration != nil && w.DurationMinutes > *filter.MaxDuration:
+			return false
+		case filter.Status != "" && w.Status != filter.Status:
+			return false
+		case filter.Visibility != "" && w.Visibility != filter.Visibility:
+			return false
+		case after != nil && !workoutBefore(filter.Sort, after, w):
+			return false
+		}
+		if filter.Exercise == "" {
+			return true
+		}
+		for _, entry := range w.Entries {
+			if containsFold(entry.ExerciseName, filter.Exercise) {
+				return true
+			}
+		}
+		return false
+	}
+
+	workouts := []*models.Workout{}
+	for _, w := range m.db.workouts {
+		if matches(w) {
+			workouts = append(workouts, w)
+		}
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workoutBefore(filter.Sort, workouts[i], workouts[j])
+	})
+
+	return m.db.workoutPage(workouts, filter.Sort, filter.Limit)
+}
+
+// ListFeed returns completed workouts of the users the viewer follows,
+// newest first.
+func (m *MemoryWorkoutStore) ListFeed(ctx context.Context, viewerID int64, cursor string, limit int) ([]*models.Workout, string, error) {
+	var after *models.Workout
+	if cursor != "" {
+		key, id, err := decodeWorkoutCursor(WorkoutSortNewest, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = cursorWorkout(WorkoutSortNewest, key, id)
+	}
+
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	workouts := []*models.Workout{}
+	for _, w := range m.db.workouts {
+		if !m.db.isFollowing(viewerID, w.UserID) || w.Status != models.StatusCompleted ||
+			(w.Visibility != models.VisibilityPublic && w.Visibility != models.VisibilityFollowers) ||
+			!sameOrganization(w.OrganizationID, m.organizationID) {
+			continue
+		}
+		if after != nil && !workoutBefore(WorkoutSortNewest, after, w) {
+			continue
+		}
+		workouts = append(workouts, w)
+	}
+	sort.Slice(workouts, func(i, j int) bool {
+		return workoutBefore(WorkoutSortNewest, workouts[i], workouts[j])
+	})
+
+	page, nextCursor, err := m.db.workoutPage(workouts, WorkoutSortNewest, limit)
+	for _, workout := range page {
+		workout.ShareToken = ""
+	}
+	return page, nextCursor, err
+}
+
+// workoutPage loads the first limit of the sorted workouts and returns the
+// cursor of the next page, if there is one.
+func (db *MemoryDB) workoutPage(workouts []*models.Workout, sort string, limit int) ([]*models.Workout, string, error) {
+	nextCursor := ""
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+		nextCursor = encodeWorkoutCursor(sort, workouts[len(workouts)-1])
+	}
+
+	page := make([]*models.Workout, 0, len(workouts))
+	for _, w := range workouts {
+		page = append(page, db.loadWorkout(w))
+	}
+	return page, nextCursor, nil
+}
+
+// workoutBefore reports whether a comes before b when ordering by the sort
+// key and id, both descending.
+func workoutBefore(sort string, a, b *models.Workout) bool {
+	switch sort {
+	case WorkoutSortLongest:
+		if a.DurationMinutes != b.DurationMinutes {
+			return a.DurationMinutes > b.DurationMinutes
+		}
+	case WorkoutSortCalories:
+		if a.CaloriesBurned != b.CaloriesBurned {
+			return a.CaloriesBurned > b.CaloriesBurned
+		}
+	default:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	}
+	return a.ID > b.ID
+}
+
+// cursorWorkout turns a decoded cursor back into a workout with just the
+// sort key and id set, so it can be compared with workoutBefore.
+func cursorWorkout(sort string, key any, id int64) *models.Workout {
+	w := &models.Workout{ID: id}
+	switch sort {
+	case WorkoutSortNewest:
+		w.CreatedAt = key.(time.Time)
+	case WorkoutSortLongest:
+		w.DurationMinutes = key.(int)
+	case WorkoutSortCalories:
+		w.CaloriesBurned = key.(int)
+	}
+	return w
+}
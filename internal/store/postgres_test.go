@@ -0,0 +1,82 @@
+package store_test
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/store/storetest"
+	"github.com/agkmw/workout-service/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// TestPostgresStores runs the conformance suite against the database at
+// WORKOUT_TEST_DSN. The tables go into a fresh schema that is dropped
+// afterwards, so the database may be shared with other data.
+func TestPostgresStores(t *testing.T) {
+	dsn := os.Getenv("WORKOUT_TEST_DSN")
+	if dsn == "" {
+		t.Skip("WORKOUT_TEST_DSN is not set")
+	}
+
+	db := openTestSchema(t, dsn)
+	if err := store.MigrateFS(db, migrations.FS, "."); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		return storetest.Stores{
+			Users:         store.NewPostgresUserStore(db, 5*time.Second),
+			Workouts:      store.NewPostgresWorkoutStore(db, 5*time.Second),
+			Tokens:        store.NewPostgresTokenStore(db, 5*time.Second, storetest.TTLs),
+			Templates:     store.NewPostgresTemplateStore(db, 5*time.Second),
+			Exercises:     store.NewPostgresExerciseStore(db, 5*time.Second),
+			Programs:      store.NewPostgresProgramStore(db, 5*time.Second),
+			Follows:       store.NewPostgresFollowStore(db, 5*time.Second),
+			Coaches:       store.NewPostgresCoachStore(db, 5*time.Second),
+			Organizations: store.NewPostgresOrganizationStore(db, 5*time.Second),
+			Admin:         store.NewPostgresAdminStore(db, 5*time.Second),
+			Records:       store.NewPostgresRecordStore(db, 5*time.Second),
+			Analytics:     analytics.NewPostgresStore(db, 5*time.Second),
+		}
+	})
+}
+
+// openTestSchema creates a schema of its own in the database at dsn and
+// returns a connection pool that creates its tables there. Extensions are
+// still found in public.
+func openTestSchema(t *testing.T, dsn string) *sql.DB {
+	t.Helper()
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("storetest_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+	})
+
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		t.Fatalf("parse dsn: %v", err)
+	}
+	cfg.RuntimeParams["search_path"] = schema + ", public"
+
+	db := stdlib.OpenDB(*cfg)
+	// registered after the schema's cleanup, so it runs first
+	t.Cleanup(func() { db.Close() })
+	return db
+}
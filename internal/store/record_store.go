@@ -0,0 +1,494 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+)
+
+type PostgresRecordStore struct {
+	db             *sql.DB
+	queryTimeout   time.Duration
+	organizationID *int64
+}
+
+func NewPostgresRecordStore(db *sql.DB, queryTimeout time.Duration) *PostgresRecordStore {
+	return &PostgresRecordStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store that only reads the records set by workouts
+// of the organization, or of the personal space when organizationID is nil.
+func (pg *PostgresRecordStore) ForOrganization(organizationID *int64) RecordStore {
+	return &PostgresRecordStore{
+		db:             pg.db,
+		queryTimeout:   pg.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+// recordInOrganization is the condition restricting personal_records to the
+// records set by workouts of the organization bound to the placeholder.
+func recordInOrganization(placeholder string) string {
+	return `EXISTS (
+		SELECT 1 FROM workouts w
+		WHERE w.id = personal_records.workout_id AND ` + inOrganization("w", placeholder) + `
+	)`
+}
+
+// GetCurrentRecords returns the best record of every type for each exercise
+// the user has logged. Rep records are kept per weight.
+func (pg *PostgresRecordStore) GetCurrentRecords(ctx context.Context, userID int64) ([]models.PersonalRecord, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT DISTINCT ON (exercise_key, record_type, CASE WHEN record_type = 'max_reps' THEN weight END)
+			id, user_id, workout_id, exercise_id, exercise_name, record_type,
+			value, weight, reps, achieved_at
+		FROM personal_records
+		WHERE user_id = $1 AND ` + recordInOrganization("$2") + `
+		ORDER BY
+			exercise_key, record_type, CASE WHEN record_type = 'max_reps' THEN weight END,
+			value DESC, achieved_at
+	`
+	return pg.queryRecords(ctx, query, userID, pg.organizationID)
+}
+
+// GetRecordHistory returns every record the user set for the exercise, in the
+// order they were achieved.
+func (pg *PostgresRecordStore) GetRecordHistory(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) ([]models.PersonalRecord, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, user_id, workout_id, exercise_id, exercise_name, record_type,
+			value, weight, reps, achieved_at
+		FROM personal_records
+		WHERE user_id = $1 AND (exercise_key = $2 OR exercise_id = $3)
+		AND ` + recordInOrganization("$4") + `
+		ORDER BY achieved_at, record_type
+	`
+	return pg.queryRecords(ctx, query, userID, exerciseKey, exerciseID, pg.organizationID)
+}
+
+// GetBestEstimated1RM returns the user's best Epley one-rep max estimate for
+// the exercise, or sql.ErrNoRows if they never logged it with weight.
+func (pg *PostgresRecordStore) GetBestEstimated1RM(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) (float64, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT MAX(value)::float8
+		FROM personal_records
+		WHERE user_id = $1 AND (exercise_key = $2 OR exercise_id = $3) AND record_type = $4
+		AND ` + recordInOrganization("$5") + `
+	`
+	var best sql.NullFloat64
+	if err := pg.db.QueryRowContext(ctx, query, userID, exerciseKey, exerciseID, records.TypeEpley1RM, pg.organizationID).Scan(&best); err != nil {
+		return 0, err
+	}
+	if !best.Valid {
+		return 0, sql.ErrNoRows
+	}
+	return best.Float64, nil
+}
+
+func (pg *PostgresRecordStore) queryRecords(ctx context.Context, query string, args ...any) ([]models.PersonalRecord, error) {
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.PersonalRecord{}
+	for rows.Next() {
+		record := models.PersonalRecord{}
+		if err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.WorkoutID,
+			&record.ExerciseID,
+			&record.ExerciseName,
+			&record.RecordType,
+			&record.Value,
+			&record.Weight,
+			&record.Reps,
+			&record.AchievedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	return result, rows.Err()
+}
+
+// catalogNamesFunc looks up the canonical names of the exercises referenced
+// by the entries, in the SQL dialect of a store.
+type catalogNamesFunc func(ctx context.Context, tx *sql.Tx, entries []models.WorkoutEntry) (map[int64]string, error)
+
+// detectRecords recomputes the personal records set by the workout and
+// returns the new ones. Records previously credited to the workout are
+// dropped first so an update can't leave stale records behind. Records are
+// only compared within the workout's organization. Planned workouts never set
+// records.
+func detectRecords(ctx context.Context, tx *sql.Tx, workout *models.Workout, catalogNames catalogNamesFunc) ([]models.PersonalRecord, error) {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM personal_records WHERE workout_id = $1`, workout.ID); err != nil {
+		return nil, err
+	}
+	if workout.Status != models.StatusCompleted {
+		return nil, nil
+	}
+
+	names, err := catalogNames(ctx, tx, workout.Entries)
+	if err != nil {
+		return nil, err
+	}
+
+	order, candidates := bestCandidates(workout, names)
+
+	bestQuery := `
+		SELECT MAX(value)
+		FROM personal_records
+		WHERE user_id = $1 AND exercise_key = $2 AND record_type = $3
+		AND ($3 <> 'max_reps' OR weight = $4)
+		AND ` + recordInOrganization("$5") + `
+	`
+	insertRecord := `
+		INSERT INTO personal_records
+		(
+			user_id, workout_id, exercise_id, exercise_key, exercise_name,
+			record_type, value, weight, reps, achieved_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`
+	newRecords := []models.PersonalRecord{}
+	for _, key := range order {
+		record := candidates[key]
+
+		var previous sql.NullFloat64
+		if err := tx.QueryRowContext(ctx, bestQuery, workout.UserID, key.exercise, key.recordType, key.weight, workout.OrganizationID).Scan(&previous); err != nil {
+			return nil, err
+		}
+		if previous.Valid && previous.Float64 >= record.Value {
+			continue
+		}
+		if previous.Valid {
+			record.PreviousValue = &previous.Float64
+		}
+
+		if err := tx.QueryRowContext(
+			ctx,
+			insertRecord,
+			record.UserID,
+			record.WorkoutID,
+			record.ExerciseID,
+			key.exercise,
+			record.ExerciseName,
+			record.RecordType,
+			record.Value,
+			record.Weight,
+			record.Reps,
+			record.AchievedAt,
+		).Scan(&record.ID); err != nil {
+			return nil, err
+		}
+		newRecords = append(newRecords, record)
+	}
+
+	return newRecords, nil
+}
+
+// rebuildRecords recomputes the user's records for every exercise the
+// workout had or now has records for, replaying the completed workouts of
+// its organization oldest first. Unlike detectRecords it is right for
+// workouts in the past: an edit can take records from, or hand them back
+// to, the workouts logged after it. It returns the records the workout
+// holds afterwards.
+func rebuildRecords(ctx context.Context, tx *sql.Tx, workout *models.Workout, catalogNames catalogNamesFunc) ([]models.PersonalRecord, error) {
+	affected, err := recordedExercises(ctx, tx, workout.ID)
+	if err != nil {
+		return nil, err
+	}
+	if workout.Status == models.StatusCompleted {
+		names, err := catalogNames(ctx, tx, workout.Entries)
+		if err != nil {
+			return nil, err
+		}
+		order, _ := bestCandidates(workout, names)
+		for _, key := range order {
+			affected[key.exercise] = true
+		}
+	}
+
+	deleteRecords := `
+		DELETE FROM personal_records
+		WHERE user_id = $1 AND exercise_key = $2 AND ` + recordInOrganization("$3") + `
+	`
+	for exercise := range affected {
+		if _, err := tx.ExecContext(ctx, deleteRecords, workout.UserID, exercise, workout.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	history, names, err := completedHistory(ctx, tx, workout.UserID, workout.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// achieved_at is copied from the workout in SQL, so the history doesn't
+	// have to scan timestamps, which each dialect stores differently
+	insertRecord := `
+		INSERT INTO personal_records
+		(
+			user_id, workout_id, exercise_id, exercise_key, exercise_name,
+			record_type, value, weight, reps, achieved_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, (SELECT created_at FROM workouts WHERE id = $2))
+		RETURNING id
+	`
+	held := []models.PersonalRecord{}
+	best := map[recordKey]float64{}
+	for _, w := range history {
+		order, candidates := bestCandidates(w, names)
+		for _, key := range order {
+			if !affected[key.exercise] {
+				continue
+			}
+			record := candidates[key]
+
+			previous, ok := best[key]
+			if ok && previous >= record.Value {
+				continue
+			}
+			if ok {
+				record.PreviousValue = &previous
+			}
+			best[key] = record.Value
+
+			if err := tx.QueryRowContext(
+				ctx,
+				insertRecord,
+				record.UserID,
+				record.WorkoutID,
+				record.ExerciseID,
+				key.exercise,
+				record.ExerciseName,
+				record.RecordType,
+				record.Value,
+				record.Weight,
+				record.Reps,
+			).Scan(&record.ID); err != nil {
+				return nil, err
+			}
+			if w.ID == workout.ID {
+				record.AchievedAt = workout.CreatedAt
+				held = append(held, record)
+			}
+		}
+	}
+
+	return held, nil
+}
+
+// recordedExercises returns the exercise keys of the records credited to the
+// workout.
+func recordedExercises(ctx context.Context, tx *sql.Tx, workoutID int64) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT DISTINCT exercise_key FROM personal_records WHERE workout_id = $1`, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := map[string]bool{}
+	for rows.Next() {
+		var exercise string
+		if err := rows.Scan(&exercise); err != nil {
+			return nil, err
+		}
+		exercises[exercise] = true
+	}
+
+	return exercises, rows.Err()
+}
+
+// completedHistory loads what records are computed from, the entries and
+// sets of the user's completed workouts in the organization, oldest first,
+// along with the catalog names of the exercises they reference. Only the
+// ids of the workouts are filled in.
+func completedHistory(ctx context.Context, tx *sql.Tx, userID int64, organizationID *int64) ([]*models.Workout, map[int64]string, error) {
+	entriesQuery := `
+		SELECT w.id, e.id, e.exercise_id, e.exercise_name, x.name, e.reps, e.duration_seconds, e.weight
+		FROM workouts w
+		INNER JOIN workout_entries e ON e.workout_id = w.id
+		LEFT JOIN exercises x ON x.id = e.exercise_id
+		WHERE w.user_id = $1 AND w.status = 'completed' AND ` + inOrganization("w", "$2") + `
+		ORDER BY w.created_at, w.id, e.order_index
+	`
+	rows, err := tx.QueryContext(ctx, entriesQuery, userID, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	history := []*models.Workout{}
+	names := map[int64]string{}
+	for rows.Next() {
+		var (
+			e           models.WorkoutEntry
+			catalogName sql.NullString
+		)
+		if err := rows.Scan(
+			&e.WorkoutID,
+			&e.ID,
+			&e.ExerciseID,
+			&e.ExerciseName,
+			&catalogName,
+			&e.Reps,
+			&e.DurationSeconds,
+			&e.Weight,
+		); err != nil {
+			return nil, nil, err
+		}
+		if e.ExerciseID != nil && catalogName.Valid {
+			names[*e.ExerciseID] = catalogName.String
+		}
+		if len(history) == 0 || history[len(history)-1].ID != e.WorkoutID {
+			history = append(history, &models.Workout{ID: e.WorkoutID, UserID: userID})
+		}
+		w := history[len(history)-1]
+		w.Entries = append(w.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows.Close()
+
+	entries := map[int64]*models.WorkoutEntry{}
+	for _, w := range history {
+		for i := range w.Entries {
+			entries[w.Entries[i].ID] = &w.Entries[i]
+		}
+	}
+
+	setsQuery := `
+		SELECT s.workout_entry_id, s.reps, s.weight, s.duration_seconds, s.failed
+		FROM workout_sets s
+		INNER JOIN workout_entries e ON e.id = s.workout_entry_id
+		INNER JOIN workouts w ON w.id = e.workout_id
+		WHERE w.user_id = $1 AND w.status = 'completed' AND ` + inOrganization("w", "$2") + `
+		ORDER BY s.workout_entry_id, s.set_number
+	`
+	setRows, err := tx.QueryContext(ctx, setsQuery, userID, organizationID)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer setRows.Close()
+
+	for setRows.Next() {
+		set := models.WorkoutSet{}
+		if err := setRows.Scan(&set.WorkoutEntryID, &set.Reps, &set.Weight, &set.DurationSeconds, &set.Failed); err != nil {
+			return nil, nil, err
+		}
+		entry := entries[set.WorkoutEntryID]
+		entry.Sets = append(entry.Sets, set)
+	}
+
+	return history, names, setRows.Err()
+}
+
+// recordKey identifies the record a candidate competes for. Rep records are
+// kept per weight.
+type recordKey struct {
+	exercise   string
+	recordType string
+	weight     float64
+}
+
+// bestCandidates returns the best candidate for every record the workout may
+// have set, keeping the better one in case an exercise was logged twice.
+// names maps exercise ids to their catalog names.
+func bestCandidates(workout *models.Workout, names map[int64]string) ([]recordKey, map[recordKey]models.PersonalRecord) {
+	candidates := map[recordKey]models.PersonalRecord{}
+	order := []recordKey{}
+	for _, entry := range workout.Entries {
+		name := entry.ExerciseName
+		if entry.ExerciseID != nil {
+			name = names[*entry.ExerciseID]
+		}
+
+		for _, candidate := range records.Candidates(entry) {
+			candidate.UserID = workout.UserID
+			candidate.WorkoutID = workout.ID
+			candidate.ExerciseID = entry.ExerciseID
+			candidate.ExerciseName = name
+			candidate.AchievedAt = workout.CreatedAt
+
+			key := recordKey{exercise: models.NormalizeExerciseName(name), recordType: candidate.RecordType}
+			if candidate.RecordType == records.TypeMaxReps {
+				key.weight = *candidate.Weight
+			}
+
+			current, ok := candidates[key]
+			if !ok {
+				order = append(order, key)
+			}
+			if !ok || current.Value < candidate.Value {
+				candidates[key] = candidate
+			}
+		}
+	}
+	return order, candidates
+}
+
+// catalogNames looks up the canonical names of the exercises referenced by
+// the entries, so records are grouped by exercise rather than by spelling.
+func catalogNames(ctx context.Context, tx *sql.Tx, entries []models.WorkoutEntry) (map[int64]string, error) {
+	ids := entryExerciseIDs(entries)
+	if len(ids) == 0 {
+		return map[int64]string{}, nil
+	}
+	return queryCatalogNames(ctx, tx, `SELECT id, name FROM exercises WHERE id = ANY($1)`, ids)
+}
+
+func entryExerciseIDs(entries []models.WorkoutEntry) []int64 {
+	ids := []int64{}
+	for _, entry := range entries {
+		if entry.ExerciseID != nil {
+			ids = append(ids, *entry.ExerciseID)
+		}
+	}
+	return ids
+}
+
+// queryCatalogNames runs a query selecting the id and name of exercises,
+// with ids bound to its only placeholder.
+func queryCatalogNames(ctx context.Context, tx *sql.Tx, query string, ids any) (map[int64]string, error) {
+	rows, err := tx.QueryContext(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := map[int64]string{}
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/config"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeFormat is how timestamps are stored in SQLite, which has no time
+// type of its own: as UTC text with a fixed number of fractional digits, so
+// that comparing two of them as strings orders them in time. The column
+// defaults in migrations/sqlite produce the same format.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000+00:00"
+
+func init() {
+	// stands in for pg_trgm's similarity in username search
+	err := sqlite.RegisterDeterministicScalarFunction("similarity", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		a, _ := args[0].(string)
+		b, _ := args[1].(string)
+		return trigramSimilarity(a, b), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// OpenSQLite opens the database file at cfg.Path, creating it if needed.
+// Foreign keys are enforced, and transactions take the write lock as they
+// begin so that concurrent ones wait for each other instead of failing.
+func OpenSQLite(cfg config.SQLite) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Set("_txlock", "immediate")
+	// the path is part of a URI, where ? and # would end it early
+	path := (&url.URL{Path: cfg.Path}).EscapedPath()
+	dsn := "file:" + path + "?" + params.Encode()
+
+	// sql.Open doesn't connect, it only hands out the driver registered by
+	// modernc.org/sqlite, which carries the similarity function
+	base, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("db open: %w", err)
+	}
+	defer base.Close()
+
+	db := sql.OpenDB(sqliteConnector{driver: base.Driver(), dsn: dsn})
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("db ping: %w", err)
+	}
+
+	return db, nil
+}
+
+// sqliteConnector opens connections that store time.Time arguments in
+// sqliteTimeFormat.
+type sqliteConnector struct {
+	driver driver.Driver
+	dsn    string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sqliteConn{conn.(sqliteDriverConn)}, nil
+}
+
+func (c sqliteConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sqliteDriverConn is what the modernc.org/sqlite connections implement.
+type sqliteDriverConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type sqliteConn struct {
+	sqliteDriverConn
+}
+
+// CheckNamedValue converts arguments the way database/sql would, then turns
+// times into text in sqliteTimeFormat.
+func (c sqliteConn) CheckNamedValue(nv *driver.NamedValue) error {
+	value, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(sqliteTimeFormat)
+	}
+	nv.Value = value
+	return nil
+}
+
+func MigrateSQLiteFS(db *sql.DB, migrationsFS fs.FS, dir string) error {
+	return migrateFS(db, "sqlite3", migrationsFS, dir)
+}
+
+// sqliteTime scans a timestamp computed by an expression, such as MAX of a
+// column, which SQLite returns as text since only columns have a declared
+// type. dst is a *time.Time, or a **time.Time if the value may be NULL.
+type sqliteTime struct {
+	dst any
+}
+
+func (s sqliteTime) Scan(src any) error {
+	var t time.Time
+	switch v := src.(type) {
+	case nil:
+		if dst, ok := s.dst.(**time.Time); ok {
+			*dst = nil
+			return nil
+		}
+		return errors.New("sqlite: NULL timestamp")
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := time.Parse(sqliteTimeFormat, v)
+		if err != nil {
+			return fmt.Errorf("sqlite: %w", err)
+		}
+		t = parsed
+	default:
+		return fmt.Errorf("sqlite: can't scan %T into a timestamp", src)
+	}
+
+	switch dst := s.dst.(type) {
+	case *time.Time:
+		*dst = t
+	case **time.Time:
+		*dst = &t
+	default:
+		return fmt.Errorf("sqlite: can't scan a timestamp into %T", s.dst)
+	}
+	return nil
+}
+
+// SQLiteTime returns a scanner like sqliteTime for packages that query the
+// SQLite database themselves.
+func SQLiteTime(dst any) sql.Scanner {
+	return sqliteTime{dst}
+}
+
+// sqliteArray encodes values as a JSON array. SQLite has no arrays, so this
+// is how array columns are stored and how lists are bound, to be unpacked
+// with json_each where Postgres has `= ANY($1)`.
+func sqliteArray[T any](values []T) string {
+	if values == nil {
+		return "[]"
+	}
+	encoded, _ := json.Marshal(values)
+	return string(encoded)
+}
+
+// sqliteJSON scans JSON text, which stands in for arrays and JSONB, into
+// dst.
+type sqliteJSON struct {
+	dst any
+}
+
+func (s sqliteJSON) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), s.dst)
+	case []byte:
+		return json.Unmarshal(v, s.dst)
+	default:
+		return fmt.Errorf("sqlite: can't scan %T as JSON", src)
+	}
+}
+
+// sqliteConstraintError reports whether err is a violation of the kind of
+// constraint given by code, e.g. sqlite3.SQLITE_CONSTRAINT_UNIQUE.
+func sqliteConstraintError(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	if code == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		// primary keys are unique constraints too, but have a code of their own
+		return sqliteErr.Code() == code || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return sqliteErr.Code() == code
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type SQLiteAdminStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteAdminStore(db *sql.DB, queryTimeout time.Duration) *SQLiteAdminStore {
+	return &SQLiteAdminStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (s *SQLiteAdminStore) RecordAction(ctx context.Context, action *models.AdminAction) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if action.Details == nil {
+		action.Details = map[string]any{}
+	}
+	details, err := json.Marshal(action.Details)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO admin_actions (admin_id, action, target_type, target_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		action.AdminID,
+		action.Action,
+		action.TargetType,
+		action.TargetID,
+		string(details),
+	).Scan(
+		&action.ID,
+		&action.CreatedAt,
+	)
+}
+
+// ListActions returns the admin log, most recent first.
+func (s *SQLiteAdminStore) ListActions(ctx context.Context, limit, offset int) ([]*models.AdminAction, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, admin_id, action, target_type, target_id, details, created_at
+		FROM admin_actions
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []*models.AdminAction{}
+	for rows.Next() {
+		action := &models.AdminAction{}
+		var details string
+		if err := rows.Scan(
+			&action.ID,
+			&action.AdminID,
+			&action.Action,
+			&action.TargetType,
+			&action.TargetID,
+			&details,
+			&action.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(details), &action.Details); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// DeleteWorkout deletes the workout whichever organization it belongs to.
+// Workout stores only ever see one organization.
+func (s *SQLiteAdminStore) DeleteWorkout(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM workouts WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
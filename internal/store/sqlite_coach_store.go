@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteCoachStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteCoachStore(db *sql.DB, queryTimeout time.Duration) *SQLiteCoachStore {
+	return &SQLiteCoachStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// CreateInvitation stores a pending link. It returns ErrCoachLinkExists if
+// the coach already invited or coaches the athlete.
+func (s *SQLiteCoachStore) CreateInvitation(ctx context.Context, link *models.CoachLink) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO coach_links (coach_id, athlete_id)
+		VALUES ($1, $2)
+		RETURNING id, status, created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, link.CoachID, link.AthleteID).Scan(
+		&link.ID,
+		&link.Status,
+		&link.CreatedAt,
+	)
+	if sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return ErrCoachLinkExists
+	}
+	return err
+}
+
+// ListAthletes returns the links the user has as a coach.
+func (s *SQLiteCoachStore) ListAthletes(ctx context.Context, coachID int64) ([]*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + coachLinkColumns + coachLinkFrom + `
+		WHERE cl.coach_id = $1
+		ORDER BY cl.status, a.username
+	`
+	return s.queryLinks(ctx, query, coachID)
+}
+
+// ListCoaches returns the links the user has as an athlete, including
+// invitations still waiting for an answer.
+func (s *SQLiteCoachStore) ListCoaches(ctx context.Context, athleteID int64) ([]*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + coachLinkColumns + coachLinkFrom + `
+		WHERE cl.athlete_id = $1
+		ORDER BY cl.status, c.username
+	`
+	return s.queryLinks(ctx, query, athleteID)
+}
+
+func (s *SQLiteCoachStore) queryLinks(ctx context.Context, query string, args ...any) ([]*models.CoachLink, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*models.CoachLink{}
+	for rows.Next() {
+		link, err := scanCoachLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// AcceptInvitation activates a pending link addressed to the athlete. It
+// returns sql.ErrNoRows if there is no such invitation. SQLite has no UPDATE
+// in WITH, so the usernames are looked up by the RETURNING clause.
+func (s *SQLiteCoachStore) AcceptInvitation(ctx context.Context, id, athleteID int64) (*models.CoachLink, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE coach_links
+		SET status = 'active', accepted_at = $3
+		WHERE id = $1 AND athlete_id = $2 AND status = 'pending'
+		RETURNING
+			id, coach_id, (SELECT username FROM users WHERE id = coach_id),
+			athlete_id, (SELECT username FROM users WHERE id = athlete_id),
+			status, created_at, accepted_at
+	`
+	return scanCoachLink(s.db.QueryRowContext(ctx, query, id, athleteID, time.Now()))
+}
+
+// DeleteLink removes a link the user is either side of, which covers a coach
+// withdrawing an invitation, an athlete declining one and either of them
+// ending the relationship. It returns sql.ErrNoRows if there is no such link.
+func (s *SQLiteCoachStore) DeleteLink(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM coach_links WHERE id = $1 AND (coach_id = $2 OR athlete_id = $2)`,
+		id,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// IsCoach reports whether coachID has an accepted link to athleteID.
+func (s *SQLiteCoachStore) IsCoach(ctx context.Context, coachID, athleteID int64) (bool, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM coach_links
+			WHERE coach_id = $1 AND athlete_id = $2 AND status = 'active'
+		)
+	`
+	err := s.db.QueryRowContext(ctx, query, coachID, athleteID).Scan(&exists)
+	return exists, err
+}
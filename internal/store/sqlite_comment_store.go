@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type SQLiteCommentStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteCommentStore(db *sql.DB, queryTimeout time.Duration) *SQLiteCommentStore {
+	return &SQLiteCommentStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// CreateComment looks the username up in the RETURNING clause, SQLite has
+// no INSERT in WITH.
+func (s *SQLiteCommentStore) CreateComment(ctx context.Context, comment *models.Comment) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO workout_comments (workout_id, user_id, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, (SELECT username FROM users WHERE id = user_id), created_at, updated_at
+	`
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		comment.WorkoutID,
+		comment.UserID,
+		comment.Body,
+	).Scan(
+		&comment.ID,
+		&comment.Username,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+}
+
+func (s *SQLiteCommentStore) GetCommentByID(ctx context.Context, id int64) (*models.Comment, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.workout_id, c.user_id, u.username, c.body, c.created_at, c.updated_at
+		FROM workout_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.id = $1
+	`
+	comment := &models.Comment{}
+	if err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&comment.ID,
+		&comment.WorkoutID,
+		&comment.UserID,
+		&comment.Username,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListComments returns the comments on a workout, oldest first.
+func (s *SQLiteCommentStore) ListComments(ctx context.Context, workoutID int64, limit, offset int) ([]models.Comment, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT c.id, c.workout_id, c.user_id, u.username, c.body, c.created_at, c.updated_at
+		FROM workout_comments c
+		INNER JOIN users u ON u.id = c.user_id
+		WHERE c.workout_id = $1
+		ORDER BY c.created_at, c.id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := s.db.QueryContext(ctx, query, workoutID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.WorkoutID,
+			&comment.UserID,
+			&comment.Username,
+			&comment.Body,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (s *SQLiteCommentStore) DeleteCommentByID(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM workout_comments WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteExerciseStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	// organizationID adds the organization's own exercises to the shared
+	// catalog. nil sees the catalog only.
+	organizationID *int64
+}
+
+func NewSQLiteExerciseStore(db *sql.DB, queryTimeout time.Duration) *SQLiteExerciseStore {
+	return &SQLiteExerciseStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store that sees the shared catalog plus the
+// custom exercises of the organization.
+func (s *SQLiteExerciseStore) ForOrganization(organizationID *int64) ExerciseStore {
+	return &SQLiteExerciseStore{
+		db:             s.db,
+		queryTimeout:   s.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+// sqliteExerciseColumns is exerciseColumns with the muscles and aliases as
+// JSON arrays.
+const sqliteExerciseColumns = `
+	e.id, e.name, e.primary_muscles, e.secondary_muscles,
+	COALESCE(e.equipment, ''), COALESCE(e.movement_type, ''),
+	(
+		SELECT json_group_array(a.alias ORDER BY a.alias)
+		FROM exercise_aliases a
+		WHERE a.exercise_id = e.id
+	),
+	e.organization_id
+`
+
+// UpsertExercises runs without the query timeout, the whole catalog can
+// take a while on a fresh database.
+func (s *SQLiteExerciseStore) UpsertExercises(ctx context.Context, exercises []models.Exercise) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	upsertExercise := `
+		INSERT INTO exercises
+		(name, primary_muscles, secondary_muscles, equipment, movement_type)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (lower(name)) WHERE organization_id IS NULL DO UPDATE
+		SET
+			primary_muscles = excluded.primary_muscles,
+			secondary_muscles = excluded.secondary_muscles,
+			equipment = excluded.equipment,
+			movement_type = excluded.movement_type,
+			updated_at = $6
+		RETURNING id
+	`
+	insertAlias := `
+		INSERT INTO exercise_aliases (exercise_id, alias)
+		VALUES ($1, $2)
+		ON CONFLICT (lower(alias)) DO NOTHING
+	`
+	now := time.Now()
+	for i := range exercises {
+		exercise := &exercises[i]
+		if err := tx.QueryRowContext(
+			ctx,
+			upsertExercise,
+			exercise.Name,
+			sqliteArray(exercise.PrimaryMuscles),
+			sqliteArray(exercise.SecondaryMuscles),
+			exercise.Equipment,
+			exercise.MovementType,
+			now,
+		).Scan(&exercise.ID); err != nil {
+			return err
+		}
+
+		for _, alias := range exercise.Aliases {
+			if _, err := tx.ExecContext(ctx, insertAlias, exercise.ID, models.NormalizeExerciseName(alias)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteExerciseStore) SearchExercises(ctx context.Context, filter ExerciseFilter) ([]models.Exercise, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + sqliteExerciseColumns + `
+		FROM exercises e
+		WHERE
+			` + inCatalog("$6") + `
+			AND (
+				$1 = ''
+				OR e.name LIKE '%' || $2 || '%' ESCAPE '\'
+				OR EXISTS (
+					SELECT 1 FROM exercise_aliases a
+					WHERE a.exercise_id = e.id AND a.alias LIKE '%' || $2 || '%' ESCAPE '\'
+				)
+			)
+			AND (
+				$3 = ''
+				OR $3 IN (SELECT value FROM json_each(e.primary_muscles))
+				OR $3 IN (SELECT value FROM json_each(e.secondary_muscles))
+			)
+			AND ($4 = '' OR e.equipment = $4)
+		ORDER BY
+			lower(e.name) = lower($1) DESC,
+			e.name LIKE $2 || '%' ESCAPE '\' DESC,
+			e.name
+		LIMIT $5
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		filter.Query,
+		escapeLike(filter.Query),
+		filter.Muscle,
+		filter.Equipment,
+		filter.Limit,
+		s.organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exercises := []models.Exercise{}
+	for rows.Next() {
+		exercise, err := scanSQLiteExercise(rows)
+		if err != nil {
+			return nil, err
+		}
+		exercises = append(exercises, *exercise)
+	}
+
+	return exercises, rows.Err()
+}
+
+func (s *SQLiteExerciseStore) GetExerciseByID(ctx context.Context, id int64) (*models.Exercise, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + sqliteExerciseColumns + `
+		FROM exercises e
+		WHERE e.id = $1 AND ` + inCatalog("$2") + `
+	`
+	return scanSQLiteExercise(s.db.QueryRowContext(ctx, query, id, s.organizationID))
+}
+
+// ResolveExerciseIDs maps free-text exercise names onto catalog ids by
+// matching canonical names and aliases. Names that match nothing are left
+// out of the result. An organization's own exercise wins over a catalog
+// exercise of the same name.
+func (s *SQLiteExerciseStore) ResolveExerciseIDs(ctx context.Context, names []string) (map[string]int64, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		normalized = append(normalized, models.NormalizeExerciseName(name))
+	}
+
+	query := `
+		SELECT name, id FROM (
+			SELECT lower(e.name) AS name, e.id, e.organization_id
+			FROM exercises e
+			WHERE lower(e.name) IN (SELECT value FROM json_each($1)) AND ` + inCatalog("$2") + `
+			UNION
+			SELECT lower(alias), exercise_id, NULL
+			FROM exercise_aliases
+			WHERE lower(alias) IN (SELECT value FROM json_each($1))
+		) matches
+		ORDER BY organization_id NULLS FIRST
+	`
+	rows, err := s.db.QueryContext(ctx, query, sqliteArray(normalized), s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int64{}
+	for rows.Next() {
+		var (
+			name string
+			id   int64
+		)
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, err
+		}
+		ids[name] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// CreateExercise adds a custom exercise to the store's organization. It
+// returns ErrDuplicateExercise if the organization already has one by that
+// name.
+func (s *SQLiteExerciseStore) CreateExercise(ctx context.Context, exercise *models.Exercise) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	if s.organizationID == nil {
+		return errors.New("custom exercises need an organization")
+	}
+
+	query := `
+		INSERT INTO exercises
+		(organization_id, name, primary_muscles, secondary_muscles, equipment, movement_type)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id
+	`
+	exercise.OrganizationID = s.organizationID
+	exercise.Aliases = []string{}
+	exercise.PrimaryMuscles = nonNil(exercise.PrimaryMuscles)
+	exercise.SecondaryMuscles = nonNil(exercise.SecondaryMuscles)
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		exercise.OrganizationID,
+		exercise.Name,
+		sqliteArray(exercise.PrimaryMuscles),
+		sqliteArray(exercise.SecondaryMuscles),
+		exercise.Equipment,
+		exercise.MovementType,
+	).Scan(&exercise.ID)
+	if sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return ErrDuplicateExercise
+	}
+	return err
+}
+
+func scanSQLiteExercise(row rowScanner) (*models.Exercise, error) {
+	exercise := &models.Exercise{}
+	if err := row.Scan(
+		&exercise.ID,
+		&exercise.Name,
+		sqliteJSON{&exercise.PrimaryMuscles},
+		sqliteJSON{&exercise.SecondaryMuscles},
+		&exercise.Equipment,
+		&exercise.MovementType,
+		sqliteJSON{&exercise.Aliases},
+		&exercise.OrganizationID,
+	); err != nil {
+		return nil, err
+	}
+	return exercise, nil
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteOrganizationStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteOrganizationStore(db *sql.DB, queryTimeout time.Duration) *SQLiteOrganizationStore {
+	return &SQLiteOrganizationStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// CreateOrganization stores the organization and makes ownerID its owner.
+func (s *SQLiteOrganizationStore) CreateOrganization(ctx context.Context, organization *models.Organization, ownerID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO organizations (name, slug)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query, organization.Name, organization.Slug).Scan(
+		&organization.ID,
+		&organization.CreatedAt,
+		&organization.UpdatedAt,
+	)
+	if sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return ErrDuplicateSlug
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)`,
+		organization.ID,
+		ownerID,
+		models.OrganizationRoleOwner,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetMembership returns the organization with the given slug as seen by
+// userID, and sql.ErrNoRows if it doesn't exist or userID isn't a member.
+func (s *SQLiteOrganizationStore) GetMembership(ctx context.Context, slug string, userID int64) (*models.Membership, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		INNER JOIN organization_members m ON m.organization_id = o.id
+		WHERE o.slug = $1 AND m.user_id = $2
+	`
+	return scanMembership(s.db.QueryRowContext(ctx, query, slug, userID))
+}
+
+func (s *SQLiteOrganizationStore) ListMemberships(ctx context.Context, userID int64) ([]*models.Membership, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT o.id, o.name, o.slug, o.created_at, o.updated_at, m.role
+		FROM organizations o
+		INNER JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1
+		ORDER BY o.name, o.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []*models.Membership{}
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (s *SQLiteOrganizationStore) ListMembers(ctx context.Context, organizationID int64) ([]models.OrganizationMember, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT m.organization_id, m.user_id, u.username, m.role, m.created_at
+		FROM organization_members m
+		INNER JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1
+		ORDER BY u.username
+	`
+	rows, err := s.db.QueryContext(ctx, query, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.OrganizationMember{}
+	for rows.Next() {
+		var member models.OrganizationMember
+		if err := rows.Scan(
+			&member.OrganizationID,
+			&member.UserID,
+			&member.Username,
+			&member.Role,
+			&member.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// AddMember returns ErrAlreadyMember if the user already belongs to the
+// organization.
+func (s *SQLiteOrganizationStore) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`
+	err := s.db.QueryRowContext(ctx, query, member.OrganizationID, member.UserID, member.Role).Scan(&member.JoinedAt)
+	if sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return ErrAlreadyMember
+	}
+	return err
+}
+
+// RemoveMember never removes owners. It returns sql.ErrNoRows if there is no
+// such non-owner member.
+func (s *SQLiteOrganizationStore) RemoveMember(ctx context.Context, organizationID, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND role <> 'owner'`,
+		organizationID,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
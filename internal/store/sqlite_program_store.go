@@ -0,0 +1,375 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type SQLiteProgramStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteProgramStore(db *sql.DB, queryTimeout time.Duration) *SQLiteProgramStore {
+	return &SQLiteProgramStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (s *SQLiteProgramStore) CreateProgram(ctx context.Context, program *models.Program) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO programs (user_id, name, description, weeks, deload_percentage, is_public)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		program.UserID,
+		program.Name,
+		program.Description,
+		program.Weeks,
+		program.DeloadPercentage,
+		program.IsPublic,
+	).Scan(
+		&program.ID,
+		&program.CreatedAt,
+		&program.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	for _, week := range program.DeloadWeeks {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO program_deload_weeks (program_id, week_number) VALUES ($1, $2)`,
+			program.ID,
+			week,
+		); err != nil {
+			return err
+		}
+	}
+
+	insertSession := `
+		INSERT INTO program_sessions
+		(program_id, week_number, day_number, template_id, intensity_percentage)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`
+	for i := range program.Sessions {
+		session := &program.Sessions[i]
+		session.ProgramID = program.ID
+		if err := tx.QueryRowContext(
+			ctx,
+			insertSession,
+			session.ProgramID,
+			session.Week,
+			session.Day,
+			session.TemplateID,
+			session.IntensityPercentage,
+		).Scan(&session.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetProgramByID returns the program if it is public, owned by viewerID or
+// one viewerID is enrolled in, and sql.ErrNoRows otherwise. Enrolled users
+// keep access after the owner makes a program private.
+func (s *SQLiteProgramStore) GetProgramByID(ctx context.Context, id, viewerID int64) (*models.Program, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, name, description, weeks, deload_percentage, is_public, created_at, updated_at
+		FROM programs p
+		WHERE id = $1 AND (
+			user_id = $2 OR is_public OR EXISTS (
+				SELECT 1 FROM program_enrollments pe
+				WHERE pe.program_id = p.id AND pe.user_id = $2
+			)
+		)
+	`
+	program := &models.Program{}
+	if err := s.db.QueryRowContext(ctx, query, id, viewerID).Scan(
+		&program.ID,
+		&program.UserID,
+		&program.Name,
+		&program.Description,
+		&program.Weeks,
+		&program.DeloadPercentage,
+		&program.IsPublic,
+		&program.CreatedAt,
+		&program.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadSchedules(ctx, []*models.Program{program}); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
+
+// ListPrograms returns the user's own programs followed by public programs
+// of other users.
+func (s *SQLiteProgramStore) ListPrograms(ctx context.Context, userID int64) ([]*models.Program, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, name, description, weeks, deload_percentage, is_public, created_at, updated_at
+		FROM programs
+		WHERE user_id = $1 OR is_public
+		ORDER BY user_id = $1 DESC, name, id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	programs := []*models.Program{}
+	for rows.Next() {
+		program := &models.Program{}
+		if err := rows.Scan(
+			&program.ID,
+			&program.UserID,
+			&program.Name,
+			&program.Description,
+			&program.Weeks,
+			&program.DeloadPercentage,
+			&program.IsPublic,
+			&program.CreatedAt,
+			&program.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		programs = append(programs, program)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadSchedules(ctx, programs); err != nil {
+		return nil, err
+	}
+	return programs, nil
+}
+
+func (s *SQLiteProgramStore) DeleteProgramByID(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM programs WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// loadSchedules fetches the deload weeks and sessions of all given programs
+// with one query each.
+func (s *SQLiteProgramStore) loadSchedules(ctx context.Context, programs []*models.Program) error {
+	if len(programs) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(programs))
+	byID := make(map[int64]*models.Program, len(programs))
+	for _, p := range programs {
+		p.DeloadWeeks = []int{}
+		p.Sessions = []models.ProgramSession{}
+		ids = append(ids, p.ID)
+		byID[p.ID] = p
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT program_id, week_number FROM program_deload_weeks WHERE program_id IN (SELECT value FROM json_each($1)) ORDER BY week_number`,
+		sqliteArray(ids),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			programID int64
+			week      int
+		)
+		if err := rows.Scan(&programID, &week); err != nil {
+			return err
+		}
+		program := byID[programID]
+		program.DeloadWeeks = append(program.DeloadWeeks, week)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	query := `
+		SELECT id, program_id, week_number, day_number, template_id, intensity_percentage
+		FROM program_sessions
+		WHERE program_id IN (SELECT value FROM json_each($1))
+		ORDER BY program_id, week_number, day_number
+	`
+	sessionRows, err := s.db.QueryContext(ctx, query, sqliteArray(ids))
+	if err != nil {
+		return err
+	}
+	defer sessionRows.Close()
+
+	for sessionRows.Next() {
+		session := models.ProgramSession{}
+		if err := sessionRows.Scan(
+			&session.ID,
+			&session.ProgramID,
+			&session.Week,
+			&session.Day,
+			&session.TemplateID,
+			&session.IntensityPercentage,
+		); err != nil {
+			return err
+		}
+		program := byID[session.ProgramID]
+		program.Sessions = append(program.Sessions, session)
+	}
+
+	return sessionRows.Err()
+}
+
+// CreateEnrollment stores the start date as a plain date, like the DATE
+// column in Postgres, rather than as a timestamp.
+func (s *SQLiteProgramStore) CreateEnrollment(ctx context.Context, enrollment *models.ProgramEnrollment) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO program_enrollments (user_id, program_id, start_date)
+		VALUES ($1, $2, $3)
+		RETURNING id, status, created_at, updated_at
+	`
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		enrollment.UserID,
+		enrollment.ProgramID,
+		enrollment.StartDate.Format(time.DateOnly),
+	).Scan(
+		&enrollment.ID,
+		&enrollment.Status,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+	)
+}
+
+func (s *SQLiteProgramStore) GetEnrollmentByID(ctx context.Context, id, userID int64) (*models.ProgramEnrollment, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, program_id, start_date, status, created_at, updated_at
+		FROM program_enrollments
+		WHERE id = $1 AND user_id = $2
+	`
+	enrollment := &models.ProgramEnrollment{}
+	if err := s.db.QueryRowContext(ctx, query, id, userID).Scan(
+		&enrollment.ID,
+		&enrollment.UserID,
+		&enrollment.ProgramID,
+		&enrollment.StartDate,
+		&enrollment.Status,
+		&enrollment.CreatedAt,
+		&enrollment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return enrollment, nil
+}
+
+func (s *SQLiteProgramStore) ListEnrollments(ctx context.Context, userID int64) ([]*models.ProgramEnrollment, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, program_id, start_date, status, created_at, updated_at
+		FROM program_enrollments
+		WHERE user_id = $1
+		ORDER BY start_date DESC, id DESC
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := []*models.ProgramEnrollment{}
+	for rows.Next() {
+		enrollment := &models.ProgramEnrollment{}
+		if err := rows.Scan(
+			&enrollment.ID,
+			&enrollment.UserID,
+			&enrollment.ProgramID,
+			&enrollment.StartDate,
+			&enrollment.Status,
+			&enrollment.CreatedAt,
+			&enrollment.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+
+	return enrollments, rows.Err()
+}
+
+func (s *SQLiteProgramStore) CancelEnrollment(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE program_enrollments
+		SET status = 'cancelled', updated_at = $3
+		WHERE id = $1 AND user_id = $2 AND status = 'active'
+	`
+	result, err := s.db.ExecContext(ctx, query, id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
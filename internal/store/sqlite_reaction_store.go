@@ -0,0 +1,105 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type SQLiteReactionStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteReactionStore(db *sql.DB, queryTimeout time.Duration) *SQLiteReactionStore {
+	return &SQLiteReactionStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// AddReaction is idempotent; reacting twice with the same kind keeps the
+// first reaction.
+func (s *SQLiteReactionStore) AddReaction(ctx context.Context, reaction *models.Reaction) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO workout_reactions (workout_id, user_id, kind)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (workout_id, user_id, kind) DO UPDATE SET kind = EXCLUDED.kind
+		RETURNING created_at
+	`
+	return s.db.QueryRowContext(
+		ctx,
+		query,
+		reaction.WorkoutID,
+		reaction.UserID,
+		reaction.Kind,
+	).Scan(&reaction.CreatedAt)
+}
+
+// RemoveReaction returns sql.ErrNoRows if the user hadn't reacted with kind.
+func (s *SQLiteReactionStore) RemoveReaction(ctx context.Context, workoutID, userID int64, kind string) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM workout_reactions WHERE workout_id = $1 AND user_id = $2 AND kind = $3`,
+		workoutID,
+		userID,
+		kind,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListReactions returns the reactions on a workout, most recent first.
+func (s *SQLiteReactionStore) ListReactions(ctx context.Context, workoutID int64) ([]models.Reaction, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT r.workout_id, r.user_id, u.username, r.kind, r.created_at
+		FROM workout_reactions r
+		INNER JOIN users u ON u.id = r.user_id
+		WHERE r.workout_id = $1
+		ORDER BY r.created_at DESC, r.user_id, r.kind
+	`
+	rows, err := s.db.QueryContext(ctx, query, workoutID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reactions := []models.Reaction{}
+	for rows.Next() {
+		var reaction models.Reaction
+		if err := rows.Scan(
+			&reaction.WorkoutID,
+			&reaction.UserID,
+			&reaction.Username,
+			&reaction.Kind,
+			&reaction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	return reactions, rows.Err()
+}
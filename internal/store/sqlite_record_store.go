@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+)
+
+type SQLiteRecordStore struct {
+	db             *sql.DB
+	queryTimeout   time.Duration
+	organizationID *int64
+}
+
+func NewSQLiteRecordStore(db *sql.DB, queryTimeout time.Duration) *SQLiteRecordStore {
+	return &SQLiteRecordStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store that only reads the records set by workouts
+// of the organization, or of the personal space when organizationID is nil.
+func (s *SQLiteRecordStore) ForOrganization(organizationID *int64) RecordStore {
+	return &SQLiteRecordStore{
+		db:             s.db,
+		queryTimeout:   s.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+// GetCurrentRecords returns the best record of every type for each exercise
+// the user has logged. Rep records are kept per weight. SQLite has no
+// DISTINCT ON, so the best record of each group is picked by rank.
+func (s *SQLiteRecordStore) GetCurrentRecords(ctx context.Context, userID int64) ([]models.PersonalRecord, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, user_id, workout_id, exercise_id, exercise_name, record_type,
+			value, weight, reps, achieved_at
+		FROM (
+			SELECT
+				*,
+				CASE WHEN record_type = 'max_reps' THEN weight END AS rep_weight,
+				ROW_NUMBER() OVER (
+					PARTITION BY exercise_key, record_type, CASE WHEN record_type = 'max_reps' THEN weight END
+					ORDER BY value DESC, achieved_at
+				) AS rank
+			FROM personal_records
+			WHERE user_id = $1 AND ` + recordInOrganization("$2") + `
+		)
+		WHERE rank = 1
+		ORDER BY exercise_key, record_type, rep_weight
+	`
+	return s.queryRecords(ctx, query, userID, s.organizationID)
+}
+
+// GetRecordHistory returns every record the user set for the exercise, in the
+// order they were achieved.
+func (s *SQLiteRecordStore) GetRecordHistory(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) ([]models.PersonalRecord, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, user_id, workout_id, exercise_id, exercise_name, record_type,
+			value, weight, reps, achieved_at
+		FROM personal_records
+		WHERE user_id = $1 AND (exercise_key = $2 OR exercise_id = $3)
+		AND ` + recordInOrganization("$4") + `
+		ORDER BY achieved_at, record_type
+	`
+	return s.queryRecords(ctx, query, userID, exerciseKey, exerciseID, s.organizationID)
+}
+
+// GetBestEstimated1RM returns the user's best Epley one-rep max estimate for
+// the exercise, or sql.ErrNoRows if they never logged it with weight.
+func (s *SQLiteRecordStore) GetBestEstimated1RM(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) (float64, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT MAX(value)
+		FROM personal_records
+		WHERE user_id = $1 AND (exercise_key = $2 OR exercise_id = $3) AND record_type = $4
+		AND ` + recordInOrganization("$5") + `
+	`
+	var best sql.NullFloat64
+	if err := s.db.QueryRowContext(ctx, query, userID, exerciseKey, exerciseID, records.TypeEpley1RM, s.organizationID).Scan(&best); err != nil {
+		return 0, err
+	}
+	if !best.Valid {
+		return 0, sql.ErrNoRows
+	}
+	return best.Float64, nil
+}
+
+func (s *SQLiteRecordStore) queryRecords(ctx context.Context, query string, args ...any) ([]models.PersonalRecord, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []models.PersonalRecord{}
+	for rows.Next() {
+		record := models.PersonalRecord{}
+		if err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.WorkoutID,
+			&record.ExerciseID,
+			&record.ExerciseName,
+			&record.RecordType,
+			&record.Value,
+			&record.Weight,
+			&record.Reps,
+			&record.AchievedAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, record)
+	}
+
+	return result, rows.Err()
+}
+
+// sqliteCatalogNames is catalogNames for SQLite, which has no arrays.
+func sqliteCatalogNames(ctx context.Context, tx *sql.Tx, entries []models.WorkoutEntry) (map[int64]string, error) {
+	ids := entryExerciseIDs(entries)
+	if len(ids) == 0 {
+		return map[int64]string{}, nil
+	}
+	query := `SELECT id, name FROM exercises WHERE id IN (SELECT value FROM json_each($1))`
+	return queryCatalogNames(ctx, tx, query, sqliteArray(ids))
+}
@@ -0,0 +1,269 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteTemplateStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	// organizationID is the tenant every query is restricted to, nil being
+	// the personal space.
+	organizationID *int64
+}
+
+func NewSQLiteTemplateStore(db *sql.DB, queryTimeout time.Duration) *SQLiteTemplateStore {
+	return &SQLiteTemplateStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store restricted to the organization's
+// templates, or to personal templates when organizationID is nil.
+func (s *SQLiteTemplateStore) ForOrganization(organizationID *int64) TemplateStore {
+	return &SQLiteTemplateStore{
+		db:             s.db,
+		queryTimeout:   s.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+func (s *SQLiteTemplateStore) CreateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO workout_templates (user_id, organization_id, name, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	template.OrganizationID = s.organizationID
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		template.UserID,
+		template.OrganizationID,
+		template.Name,
+		template.Description,
+	).Scan(
+		&template.ID,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	if err := insertTemplateEntries(ctx, tx, template); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTemplateByID returns the template if it belongs to userID and
+// sql.ErrNoRows otherwise. Templates are always private.
+func (s *SQLiteTemplateStore) GetTemplateByID(ctx context.Context, id, userID int64) (*models.WorkoutTemplate, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT t.id, t.user_id, t.organization_id, t.name, t.description, t.created_at, t.updated_at
+		FROM workout_templates t
+		WHERE t.id = $1 AND t.user_id = $2 AND ` + inOrganization("t", "$3") + `
+	`
+	template := &models.WorkoutTemplate{}
+	if err := s.db.QueryRowContext(ctx, query, id, userID, s.organizationID).Scan(
+		&template.ID,
+		&template.UserID,
+		&template.OrganizationID,
+		&template.Name,
+		&template.Description,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadTemplateEntries(ctx, []*models.WorkoutTemplate{template}); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *SQLiteTemplateStore) ListTemplates(ctx context.Context, userID int64) ([]*models.WorkoutTemplate, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT t.id, t.user_id, t.organization_id, t.name, t.description, t.created_at, t.updated_at
+		FROM workout_templates t
+		WHERE t.user_id = $1 AND ` + inOrganization("t", "$2") + `
+		ORDER BY t.name, t.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*models.WorkoutTemplate{}
+	for rows.Next() {
+		template := &models.WorkoutTemplate{}
+		if err := rows.Scan(
+			&template.ID,
+			&template.UserID,
+			&template.OrganizationID,
+			&template.Name,
+			&template.Description,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadTemplateEntries(ctx, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (s *SQLiteTemplateStore) UpdateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE workout_templates
+		SET name = $1, description = $2, updated_at = $3
+		WHERE id = $4 AND user_id = $5 AND organization_id IS NOT DISTINCT FROM $6
+		RETURNING updated_at
+	`
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		template.Name,
+		template.Description,
+		time.Now(),
+		template.ID,
+		template.UserID,
+		s.organizationID,
+	).Scan(&template.UpdatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workout_template_entries WHERE template_id = $1`, template.ID); err != nil {
+		return err
+	}
+	if err := insertTemplateEntries(ctx, tx, template); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteTemplateStore) DeleteTemplateByID(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM workout_templates AS t WHERE t.id = $1 AND t.user_id = $2 AND `+inOrganization("t", "$3"),
+		id,
+		userID,
+		s.organizationID,
+	)
+	if err != nil {
+		if sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY) {
+			return ErrTemplateInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// loadTemplateEntries fetches the entries of all given templates in a single
+// query.
+func (s *SQLiteTemplateStore) loadTemplateEntries(ctx context.Context, templates []*models.WorkoutTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(templates))
+	byID := make(map[int64]*models.WorkoutTemplate, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.ID)
+		byID[t.ID] = t
+	}
+
+	query := `
+		SELECT
+			id, template_id, exercise_id, exercise_name, target_sets, target_reps,
+			target_weight, target_duration_seconds, rest_seconds, weight_increment,
+			notes, order_index, created_at, updated_at
+		FROM workout_template_entries
+		WHERE template_id IN (SELECT value FROM json_each($1))
+		ORDER BY template_id, order_index
+	`
+	rows, err := s.db.QueryContext(ctx, query, sqliteArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := models.WorkoutTemplateEntry{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.TemplateID,
+			&e.ExerciseID,
+			&e.ExerciseName,
+			&e.TargetSets,
+			&e.TargetReps,
+			&e.TargetWeight,
+			&e.TargetDurationSeconds,
+			&e.RestSeconds,
+			&e.WeightIncrement,
+			&e.Notes,
+			&e.OrderIndex,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		template := byID[e.TemplateID]
+		template.Entries = append(template.Entries, e)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,66 @@
+package store_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/config"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/store/storetest"
+	sqlitemigrations "github.com/agkmw/workout-service/migrations/sqlite"
+)
+
+func TestSQLiteStores(t *testing.T) {
+	db := openSQLite(t, filepath.Join(t.TempDir(), "workouts.db"))
+
+	storetest.Run(t, func(t *testing.T) storetest.Stores {
+		return storetest.Stores{
+			Users:         store.NewSQLiteUserStore(db, 5*time.Second),
+			Workouts:      store.NewSQLiteWorkoutStore(db, 5*time.Second),
+			Tokens:        store.NewSQLiteTokenStore(db, 5*time.Second, storetest.TTLs),
+			Templates:     store.NewSQLiteTemplateStore(db, 5*time.Second),
+			Exercises:     store.NewSQLiteExerciseStore(db, 5*time.Second),
+			Programs:      store.NewSQLiteProgramStore(db, 5*time.Second),
+			Follows:       store.NewSQLiteFollowStore(db, 5*time.Second),
+			Coaches:       store.NewSQLiteCoachStore(db, 5*time.Second),
+			Organizations: store.NewSQLiteOrganizationStore(db, 5*time.Second),
+			Admin:         store.NewSQLiteAdminStore(db, 5*time.Second),
+			Records:       store.NewSQLiteRecordStore(db, 5*time.Second),
+			Analytics:     analytics.NewSQLiteStore(db, 5*time.Second),
+		}
+	})
+}
+
+func TestOpenSQLiteEscapesPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "odd?dir#1 %20")
+	path := filepath.Join(dir, "workouts.db")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	openSQLite(t, path)
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database file not created at %s: %v", path, err)
+	}
+}
+
+// openSQLite opens and migrates the database file at path.
+func openSQLite(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := store.OpenSQLite(config.SQLite{Path: path})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := store.MigrateSQLiteFS(db, sqlitemigrations.FS, "."); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
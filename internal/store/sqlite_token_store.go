@@ -0,0 +1,290 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+type SQLiteTokenStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	ttls         tokens.TTLs
+}
+
+func NewSQLiteTokenStore(db *sql.DB, queryTimeout time.Duration, ttls tokens.TTLs) *SQLiteTokenStore {
+	return &SQLiteTokenStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+		ttls:         ttls,
+	}
+}
+
+func (t *SQLiteTokenStore) CreateNewToken(ctx context.Context, userID int64, scope string) (*tokens.Token, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	token, err := tokens.GenerateToken(userID, t.ttls.For(scope), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.Insert(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (t *SQLiteTokenStore) Insert(ctx context.Context, token *tokens.Token) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	return sqliteInsertToken(ctx, t.db, token)
+}
+
+// sqliteInsertToken rounds the expiry to whole seconds, which Postgres'
+// TIMESTAMP (0) column does by itself.
+func sqliteInsertToken(ctx context.Context, db execer, token *tokens.Token) error {
+	query := `
+		INSERT INTO tokens (hash, user_id, expiry, scope, user_agent, ip, family_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+	`
+	_, err := db.ExecContext(
+		ctx,
+		query,
+		token.Hash,
+		token.UserID,
+		token.Expiry.Round(time.Second),
+		token.Scope,
+		token.UserAgent,
+		token.IP,
+		token.FamilyID,
+	)
+	return err
+}
+
+// CreateTokenPair starts a new token family for a login.
+func (t *SQLiteTokenStore) CreateTokenPair(ctx context.Context, userID int64, userAgent, ip string) (*tokens.Pair, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	familyID, err := tokens.NewFamilyID()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pair, err := t.insertPair(ctx, tx, userID, familyID, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+// RotateRefreshToken trades a refresh token for a new pair in the same
+// family. Every refresh token works once: presenting a used one revokes the
+// family and returns ErrRefreshTokenReused. Unknown or expired tokens give
+// sql.ErrNoRows. There is no FOR UPDATE in SQLite, but transactions take
+// the write lock as they begin, so two rotations of one token can't
+// interleave.
+func (t *SQLiteTokenStore) RotateRefreshToken(ctx context.Context, plaintextToken, userAgent, ip string) (*tokens.Pair, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hash := tokens.Hash(plaintextToken)
+	now := time.Now()
+
+	var (
+		userID   int64
+		familyID sql.NullString
+		usedAt   sql.NullTime
+	)
+	lookup := `
+		SELECT user_id, family_id, used_at
+		FROM tokens
+		WHERE hash = $1 AND scope = $2 AND expiry > $3
+	`
+	if err := tx.QueryRowContext(ctx, lookup, hash, tokens.ScopeRefresh, now).Scan(&userID, &familyID, &usedAt); err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tokens WHERE family_id = $1`, familyID.String); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tokens SET used_at = $2, last_used_at = $2 WHERE hash = $1`, hash, now); err != nil {
+		return nil, err
+	}
+
+	pair, err := t.insertPair(ctx, tx, userID, familyID.String, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+func (t *SQLiteTokenStore) insertPair(ctx context.Context, tx *sql.Tx, userID int64, familyID, userAgent, ip string) (*tokens.Pair, error) {
+	pair, err := tokens.GeneratePair(userID, familyID, t.ttls)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range []*tokens.Token{pair.Access, pair.Refresh} {
+		token.UserAgent = userAgent
+		token.IP = ip
+		if err := sqliteInsertToken(ctx, tx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return pair, nil
+}
+
+func (t *SQLiteTokenStore) DeleteAllTokensForUser(ctx context.Context, userID int64, scope string) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM tokens
+		WHERE scope = $1 AND user_id = $2
+	`
+	_, err := t.db.ExecContext(ctx, query, scope, userID)
+	return err
+}
+
+// DeleteToken revokes a token together with the rest of its family, so
+// logging out also kills the refresh token. It returns sql.ErrNoRows if the
+// token doesn't exist.
+func (t *SQLiteTokenStore) DeleteToken(ctx context.Context, scope, plaintextToken string) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM tokens
+		WHERE (scope = $1 AND hash = $2)
+		OR family_id = (SELECT family_id FROM tokens WHERE scope = $1 AND hash = $2)
+	`
+	result, err := t.db.ExecContext(ctx, query, scope, tokens.Hash(plaintextToken))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ListSessions returns the user's active logins, most recently used first.
+// A login is a token family, so the access and refresh tokens rotated within
+// it show up as one session; tokens issued before refresh tokens existed
+// form a session of their own. The session holding currentToken is marked
+// current. The user agent and IP are those of the newest token, which
+// Postgres picks with array_agg and SQLite with a window function.
+func (t *SQLiteTokenStore) ListSessions(ctx context.Context, userID int64, currentToken string) ([]tokens.Session, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		WITH active AS (
+			SELECT
+				*,
+				COALESCE(family_id, hex(hash)) AS session,
+				ROW_NUMBER() OVER (PARTITION BY COALESCE(family_id, hex(hash)) ORDER BY id DESC) AS newest
+			FROM tokens
+			WHERE user_id = $1 AND expiry > $3 AND used_at IS NULL
+		)
+		SELECT
+			MIN(id),
+			MIN(created_at),
+			MAX(last_used_at),
+			MAX(expiry),
+			MAX(CASE WHEN newest = 1 THEN user_agent END),
+			MAX(CASE WHEN newest = 1 THEN ip END),
+			MAX(hash = $2)
+		FROM active
+		GROUP BY session
+		ORDER BY COALESCE(MAX(last_used_at), MIN(created_at)) DESC, MIN(id) DESC
+	`
+	rows, err := t.db.QueryContext(ctx, query, userID, tokens.Hash(currentToken), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []tokens.Session{}
+	for rows.Next() {
+		session := tokens.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			sqliteTime{&session.CreatedAt},
+			sqliteTime{&session.LastUsedAt},
+			sqliteTime{&session.Expiry},
+			&session.UserAgent,
+			&session.IP,
+			&session.Current,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchToken records that the token was just used. To keep authenticated
+// requests from writing on every call, last_used_at only moves once it is
+// older than interval.
+func (t *SQLiteTokenStore) TouchToken(ctx context.Context, plaintextToken string, interval time.Duration) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE tokens
+		SET last_used_at = $2
+		WHERE hash = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+	now := time.Now()
+	_, err := t.db.ExecContext(ctx, query, tokens.Hash(plaintextToken), now, now.Add(-interval))
+	return err
+}
+
+// DeleteExpiredTokens removes tokens of every scope whose expiry has passed
+// and returns how many there were.
+func (t *SQLiteTokenStore) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	result, err := t.db.ExecContext(ctx, `DELETE FROM tokens WHERE expiry < $1`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
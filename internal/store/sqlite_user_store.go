@@ -0,0 +1,394 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/tokens"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type SQLiteUserStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+func NewSQLiteUserStore(db *sql.DB, queryTimeout time.Duration) *SQLiteUserStore {
+	return &SQLiteUserStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+func (s *SQLiteUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO users
+		(username, email, password_hash, bio, timezone)
+		VALUES
+		($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'UTC'))
+		RETURNING id, timezone, activated, role, disabled, created_at, updated_at
+	`
+	if err := s.db.QueryRowContext(
+		ctx,
+		query,
+		user.Username,
+		user.Email,
+		user.PasswordHash.Hash,
+		user.Bio,
+		user.Timezone,
+	).Scan(
+		&user.ID,
+		&user.Timezone,
+		&user.Activated,
+		&user.Role,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	); err != nil {
+		return sqliteUniqueUserError(err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteUserStore) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE u.id = $1
+	`
+	return scanUser(s.db.QueryRowContext(ctx, query, id))
+}
+
+func (s *SQLiteUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE u.username = $1
+	`
+	return scanUser(s.db.QueryRowContext(ctx, query, username))
+}
+
+// GetUserByEmail matches the address case-insensitively.
+func (s *SQLiteUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE lower(u.email) = lower($1)
+	`
+	return scanUser(s.db.QueryRowContext(ctx, query, email))
+}
+
+// ListUsers returns every account, oldest first, for the admin API.
+func (s *SQLiteUserStore) ListUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		ORDER BY u.id
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// SearchUsersByUsername finds users whose username starts with query or is
+// similar to it. Prefix matches come first, the rest are ranked by the
+// similarity function registered in sqlite.go, which computes pg_trgm's.
+func (s *SQLiteUserStore) SearchUsersByUsername(ctx context.Context, query string, limit, offset int) ([]models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	search := `
+		SELECT ` + publicProfileColumns + `
+		FROM users u
+		WHERE lower(u.username) LIKE $2 || '%' ESCAPE '\' OR similarity(lower(u.username), $1) >= $5
+		ORDER BY
+			lower(u.username) LIKE $2 || '%' ESCAPE '\' DESC,
+			similarity(lower(u.username), $1) DESC,
+			u.username
+		LIMIT $3 OFFSET $4
+	`
+	q := strings.ToLower(query)
+	rows, err := s.db.QueryContext(ctx, search, q, escapeLike(q), limit, offset, trigramThreshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.PublicProfile{}
+	for rows.Next() {
+		profile, err := scanPublicProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+func (s *SQLiteUserStore) GetPublicProfile(ctx context.Context, username string) (*models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + publicProfileColumns + `
+		FROM users u
+		WHERE u.username = $1
+	`
+	profile, err := scanPublicProfile(s.db.QueryRowContext(ctx, query, username))
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (s *SQLiteUserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET
+			username = $1, email = $2, bio = $3, timezone = $4, activated = $5,
+			updated_at = $6
+		WHERE id = $7
+		RETURNING updated_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		user.Username,
+		user.Email,
+		user.Bio,
+		user.Timezone,
+		user.Activated,
+		time.Now(),
+		user.ID,
+	).Scan(
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return sqliteUniqueUserError(err)
+	}
+
+	return nil
+}
+
+// DeleteUser removes the account. Tokens, workouts, templates and everything
+// else the user owns go with it through ON DELETE CASCADE.
+func (s *SQLiteUserStore) DeleteUser(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// sqliteUniqueUserError maps violations of the unique username and email
+// constraints to ErrDuplicateUsername and ErrDuplicateEmail. SQLite names
+// the violated columns rather than the constraint, and expression indexes
+// by their name.
+func sqliteUniqueUserError(err error) error {
+	if !sqliteConstraintError(err, sqlite3.SQLITE_CONSTRAINT_UNIQUE) {
+		return err
+	}
+
+	switch {
+	case strings.Contains(err.Error(), "users.username"):
+		return ErrDuplicateUsername
+	case strings.Contains(err.Error(), "users.email"),
+		strings.Contains(err.Error(), "users_email_lower_key"):
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+func (s *SQLiteUserStore) UpdatePassword(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	return s.db.QueryRowContext(ctx, query, user.PasswordHash.Hash, time.Now(), user.ID).Scan(&user.UpdatedAt)
+}
+
+// ResetPassword consumes the password reset token, stores the user's new
+// password hash and logs out every session, all or nothing. It returns
+// sql.ErrNoRows if the token was already used.
+func (s *SQLiteUserStore) ResetPassword(ctx context.Context, user *models.User, plaintextToken string) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM tokens WHERE scope = $1 AND hash = $2 AND user_id = $3`,
+		tokens.ScopePasswordReset,
+		tokens.Hash(plaintextToken),
+		user.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	if err := tx.QueryRowContext(ctx, query, user.PasswordHash.Hash, time.Now(), user.ID).Scan(&user.UpdatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM tokens WHERE user_id = $1 AND scope IN (SELECT value FROM json_each($2))`,
+		user.ID,
+		sqliteArray(resetRevokedScopes),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ChangePassword stores the user's new password hash and logs out every
+// session but the one currentToken belongs to, together with any reset
+// links, all or nothing.
+func (s *SQLiteUserStore) ChangePassword(ctx context.Context, user *models.User, currentToken string) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	if err := tx.QueryRowContext(ctx, query, user.PasswordHash.Hash, time.Now(), user.ID).Scan(&user.UpdatedAt); err != nil {
+		return err
+	}
+
+	// tokens without a family are sessions of their own
+	revoke := `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND scope IN (SELECT value FROM json_each($2)) AND hash <> $3
+		AND NOT COALESCE(family_id = (SELECT family_id FROM tokens WHERE hash = $3), FALSE)
+	`
+	if _, err := tx.ExecContext(ctx, revoke, user.ID, sqliteArray(resetRevokedScopes), tokens.Hash(currentToken)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateRole changes the user's role. It returns sql.ErrNoRows if the user
+// doesn't exist.
+func (s *SQLiteUserStore) UpdateRole(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	return s.db.QueryRowContext(ctx, query, user.Role, time.Now(), user.ID).Scan(&user.UpdatedAt)
+}
+
+// UpdateDisabled enables or disables the account. It returns sql.ErrNoRows
+// if the user doesn't exist.
+func (s *SQLiteUserStore) UpdateDisabled(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET disabled = $1, updated_at = $2
+		WHERE id = $3
+		RETURNING updated_at
+	`
+	return s.db.QueryRowContext(ctx, query, user.Disabled, time.Now(), user.ID).Scan(&user.UpdatedAt)
+}
+
+func (s *SQLiteUserStore) GetUserByToken(ctx context.Context, scope, plaintextToken string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		INNER JOIN tokens t ON t.user_id = u.id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > $3
+	`
+	return scanUser(s.db.QueryRowContext(ctx, query, tokens.Hash(plaintextToken), scope, time.Now()))
+}
@@ -0,0 +1,603 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+type SQLiteWorkoutStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	// organizationID is the tenant every query is restricted to. nil is the
+	// personal space of workouts logged outside any organization.
+	organizationID *int64
+}
+
+func NewSQLiteWorkoutStore(db *sql.DB, queryTimeout time.Duration) *SQLiteWorkoutStore {
+	return &SQLiteWorkoutStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store that only reads and writes the workouts of
+// the organization, or the personal space when organizationID is nil.
+func (s *SQLiteWorkoutStore) ForOrganization(organizationID *int64) WorkoutStore {
+	return &SQLiteWorkoutStore{
+		db:             s.db,
+		queryTimeout:   s.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+// sqliteWorkoutColumns is workoutColumns with the reaction counts built by
+// json_group_object, which returns {} for no rows by itself.
+const sqliteWorkoutColumns = `
+	w.id, w.user_id, w.organization_id, w.template_id, w.enrollment_id, w.program_session_id,
+	w.title, w.description, w.duration_minutes,
+	COALESCE(w.calories_burned, 0), w.visibility, COALESCE(w.share_token, ''),
+	w.status, w.created_at, w.updated_at,
+	(SELECT COUNT(*) FROM workout_comments c WHERE c.workout_id = w.id),
+	(
+		SELECT json_group_object(kind, n)
+		FROM (
+			SELECT kind, COUNT(*) AS n FROM workout_reactions r
+			WHERE r.workout_id = w.id GROUP BY kind
+		) counts
+	)
+`
+
+// GetWorkoutByID returns the workout only if viewerID is allowed to see it.
+// Workouts hidden from the viewer are reported as sql.ErrNoRows so callers
+// can't tell them apart from workouts that don't exist.
+func (s *SQLiteWorkoutStore) GetWorkoutByID(ctx context.Context, id, viewerID int64) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + sqliteWorkoutColumns + `
+		FROM workouts w
+		WHERE w.id = $1 AND ` + visibleTo("$2") + ` AND ` + inOrganization("w", "$3") + `
+	`
+	workout, err := s.getWorkout(ctx, query, id, viewerID, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if workout.UserID != viewerID {
+		workout.ShareToken = ""
+	}
+	return workout, nil
+}
+
+func (s *SQLiteWorkoutStore) GetWorkoutByShareToken(ctx context.Context, token string) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + sqliteWorkoutColumns + `
+		FROM workouts w
+		WHERE w.share_token = $1 AND w.visibility = 'unlisted' AND ` + inOrganization("w", "$2") + `
+	`
+	return s.getWorkout(ctx, query, token, s.organizationID)
+}
+
+func (s *SQLiteWorkoutStore) getWorkout(ctx context.Context, query string, args ...any) (*models.Workout, error) {
+	workout, err := scanWorkout(s.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadEntries(ctx, []*models.Workout{workout}); err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+// GetLastCompletedFromTemplate returns the user's most recent completed
+// workout that was started from the template.
+func (s *SQLiteWorkoutStore) GetLastCompletedFromTemplate(ctx context.Context, userID, templateID int64) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + sqliteWorkoutColumns + `
+		FROM workouts w
+		WHERE w.user_id = $1 AND w.template_id = $2 AND w.status = 'completed'
+		AND ` + inOrganization("w", "$3") + `
+		ORDER BY w.created_at DESC
+		LIMIT 1
+	`
+	return s.getWorkout(ctx, query, userID, templateID, s.organizationID)
+}
+
+func (s *SQLiteWorkoutStore) CreateWorkout(ctx context.Context, workout *models.Workout) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	workout.OrganizationID = s.organizationID
+	workout.CommentCount = 0
+	workout.ReactionCounts = map[string]int{}
+
+	// never trust a share token coming from the client
+	workout.ShareToken = ""
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
+	insertWorkout := `
+		INSERT INTO workouts
+		(
+			user_id, organization_id, template_id, enrollment_id,
+			program_session_id, title, description, duration_minutes,
+			calories_burned, visibility, share_token, status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
+		RETURNING id, created_at, updated_at
+	`
+	if err := tx.QueryRowContext(
+		ctx,
+		insertWorkout,
+		workout.UserID,
+		workout.OrganizationID,
+		workout.TemplateID,
+		workout.EnrollmentID,
+		workout.ProgramSessionID,
+		workout.Title,
+		workout.Description,
+		workout.DurationMinutes,
+		workout.CaloriesBurned,
+		workout.Visibility,
+		workout.ShareToken,
+		workout.Status,
+	).Scan(
+		&workout.ID,
+		&workout.CreatedAt,
+		&workout.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	if err := insertEntries(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	workout.NewRecords, err = detectRecords(ctx, tx, workout, sqliteCatalogNames)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteWorkoutStore) UpdateWorkoutByID(ctx context.Context, workout *models.Workout) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
+	// a workout that stays unlisted keeps its stored link; callers who
+	// can't see the token, like coaches, would otherwise replace it
+	updateWorkout := `
+		UPDATE workouts
+		SET
+		title = $1,
+		description = $2,
+		duration_minutes = $3,
+		calories_burned = $4,
+		visibility = $5,
+		share_token = CASE WHEN $5 = 'unlisted' THEN COALESCE(share_token, NULLIF($6, '')) END,
+		status = $7,
+		updated_at = $8
+		WHERE id = $9 AND organization_id IS NOT DISTINCT FROM $10
+		RETURNING updated_at, COALESCE(share_token, '')
+	`
+	err = tx.QueryRowContext(
+		ctx,
+		updateWorkout,
+		workout.Title,
+		workout.Description,
+		workout.DurationMinutes,
+		workout.CaloriesBurned,
+		workout.Visibility,
+		workout.ShareToken,
+		workout.Status,
+		time.Now(),
+		workout.ID,
+		s.organizationID,
+	).Scan(
+		&workout.UpdatedAt,
+		&workout.ShareToken,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM workout_entries WHERE workout_id = $1", workout.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertEntries(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	workout.OrganizationID = s.organizationID
+	workout.NewRecords, err = rebuildRecords(ctx, tx, workout, sqliteCatalogNames)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListEnrollmentWorkouts returns the workouts logged for the sessions of a
+// program enrollment.
+func (s *SQLiteWorkoutStore) ListEnrollmentWorkouts(ctx context.Context, enrollmentID int64) ([]models.SessionWorkout, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT w.id, w.program_session_id, w.status, w.created_at
+		FROM workouts w
+		WHERE w.enrollment_id = $1 AND w.program_session_id IS NOT NULL
+		AND ` + inOrganization("w", "$2") + `
+		ORDER BY w.created_at
+	`
+	rows, err := s.db.QueryContext(ctx, query, enrollmentID, s.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []models.SessionWorkout{}
+	for rows.Next() {
+		w := models.SessionWorkout{}
+		if err := rows.Scan(&w.WorkoutID, &w.ProgramSessionID, &w.Status, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
+}
+
+func (s *SQLiteWorkoutStore) DeleteWorkoutByID(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM workouts AS w WHERE w.id = $1 AND `+inOrganization("w", "$2"),
+		id,
+		s.organizationID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetVisibleWorkoutOwner returns the owner of the workout if the viewer may
+// see it, and sql.ErrNoRows otherwise.
+func (s *SQLiteWorkoutStore) GetVisibleWorkoutOwner(ctx context.Context, workoutID, viewerID int64) (int64, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var userID int64
+
+	query := `
+		SELECT w.user_id
+		FROM workouts w
+		WHERE w.id = $1 AND ` + visibleTo("$2") + ` AND ` + inOrganization("w", "$3") + `
+	`
+	if err := s.db.QueryRowContext(ctx, query, workoutID, viewerID, s.organizationID).Scan(&userID); err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ListWorkouts is the Postgres query with ILIKE spelled as LIKE, which
+// SQLite already matches case-insensitively, given an explicit escape
+// character.
+func (s *SQLiteWorkoutStore) ListWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, string, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	sortExpr, err := workoutSortExpr(filter.Sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{"w.user_id = $1", inOrganization("w", "$2")}
+	args := []any{filter.UserID, s.organizationID}
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.From != nil {
+		addCondition("w.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("w.created_at < $%d", *filter.To)
+	}
+	if filter.Title != "" {
+		addCondition(`w.title LIKE '%%' || $%d || '%%' ESCAPE '\'`, escapeLike(filter.Title))
+	}
+	if filter.MinDuration != nil {
+		addCondition("w.duration_minutes >= $%d", *filter.MinDuration)
+	}
+	if filter.MaxDuration != nil {
+		addCondition("w.duration_minutes <= $%d", *filter.MaxDuration)
+	}
+	if filter.Exercise != "" {
+		addCondition(`EXISTS (
+			SELECT 1 FROM workout_entries e
+			WHERE e.workout_id = w.id AND e.exercise_name LIKE '%%' || $%d || '%%' ESCAPE '\'
+		)`, escapeLike(filter.Exercise))
+	}
+	if filter.Status != "" {
+		addCondition("w.status = $%d", filter.Status)
+	}
+	if filter.Visibility != "" {
+		addCondition("w.visibility = $%d", filter.Visibility)
+	}
+	if filter.Cursor != "" {
+		key, id, err := decodeWorkoutCursor(filter.Sort, filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, key, id)
+		conditions = append(conditions, fmt.Sprintf("(%s, w.id) < ($%d, $%d)", sortExpr, len(args)-1, len(args)))
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT `+sqliteWorkoutColumns+`
+		FROM workouts w
+		WHERE %s
+		ORDER BY %s DESC, w.id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), sortExpr, len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	workouts := []*models.Workout{}
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(workouts) > filter.Limit {
+		workouts = workouts[:filter.Limit]
+		nextCursor = encodeWorkoutCursor(filter.Sort, workouts[len(workouts)-1])
+	}
+
+	if err := s.loadEntries(ctx, workouts); err != nil {
+		return nil, "", err
+	}
+
+	return workouts, nextCursor, nil
+}
+
+// ListFeed returns completed workouts of the users the viewer follows,
+// newest first. Each followed user contributes at most a page worth of
+// workouts, as in Postgres, but ranked by a window function since SQLite has
+// no LATERAL joins.
+func (s *SQLiteWorkoutStore) ListFeed(ctx context.Context, viewerID int64, cursor string, limit int) ([]*models.Workout, string, error) {
+	ctx, cancel := WithTimeout(ctx, s.queryTimeout)
+	defer cancel()
+
+	var (
+		before   *time.Time
+		beforeID int64
+	)
+	if cursor != "" {
+		key, id, err := decodeWorkoutCursor(WorkoutSortNewest, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		createdAt := key.(time.Time)
+		before, beforeID = &createdAt, id
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	query := `
+		WITH recent AS (
+			SELECT
+				x.id,
+				ROW_NUMBER() OVER (PARTITION BY x.user_id ORDER BY x.created_at DESC, x.id DESC) AS n
+			FROM follows f
+			INNER JOIN workouts x ON x.user_id = f.followee_id
+			WHERE f.follower_id = $1
+			AND x.status = 'completed' AND x.visibility IN ('public', 'followers')
+			AND ` + inOrganization("x", "$5") + `
+			AND ($2 IS NULL OR (x.created_at, x.id) < ($2, $3))
+		)
+		SELECT ` + sqliteWorkoutColumns + `
+		FROM recent
+		INNER JOIN workouts w ON w.id = recent.id
+		WHERE recent.n <= $4
+		ORDER BY w.created_at DESC, w.id DESC
+		LIMIT $4
+	`
+	rows, err := s.db.QueryContext(ctx, query, viewerID, before, beforeID, limit+1, s.organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	workouts := []*models.Workout{}
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		workout.ShareToken = ""
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+		nextCursor = encodeWorkoutCursor(WorkoutSortNewest, workouts[len(workouts)-1])
+	}
+
+	if err := s.loadEntries(ctx, workouts); err != nil {
+		return nil, "", err
+	}
+
+	return workouts, nextCursor, nil
+}
+
+// loadEntries fetches the entries of all given workouts in a single query.
+func (s *SQLiteWorkoutStore) loadEntries(ctx context.Context, workouts []*models.Workout) error {
+	if len(workouts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(workouts))
+	byID := make(map[int64]*models.Workout, len(workouts))
+	for _, w := range workouts {
+		ids = append(ids, w.ID)
+		byID[w.ID] = w
+	}
+
+	query := `
+		SELECT
+			id, workout_id, exercise_id, exercise_name, reps, duration_seconds,
+			weight, notes, order_index, created_at, updated_at
+		FROM workout_entries
+		WHERE workout_id IN (SELECT value FROM json_each($1))
+		ORDER BY workout_id, order_index
+	`
+	rows, err := s.db.QueryContext(ctx, query, sqliteArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := models.WorkoutEntry{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.WorkoutID,
+			&e.ExerciseID,
+			&e.ExerciseName,
+			&e.Reps,
+			&e.DurationSeconds,
+			&e.Weight,
+			&e.Notes,
+			&e.OrderIndex,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		workout := byID[e.WorkoutID]
+		workout.Entries = append(workout.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return s.loadSets(ctx, ids, workouts)
+}
+
+// loadSets fetches the sets of every entry of the given workouts in a single
+// query. It expects the entries to be loaded already.
+func (s *SQLiteWorkoutStore) loadSets(ctx context.Context, workoutIDs []int64, workouts []*models.Workout) error {
+	entries := map[int64]*models.WorkoutEntry{}
+	for _, w := range workouts {
+		for i := range w.Entries {
+			entries[w.Entries[i].ID] = &w.Entries[i]
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT
+			s.id, s.workout_entry_id, s.set_number, s.reps, s.weight, s.duration_seconds,
+			s.distance_meters, s.rpe, s.rest_seconds, s.failed, s.created_at, s.updated_at
+		FROM workout_sets s
+		INNER JOIN workout_entries e ON e.id = s.workout_entry_id
+		WHERE e.workout_id IN (SELECT value FROM json_each($1))
+		ORDER BY s.workout_entry_id, s.set_number
+	`
+	rows, err := s.db.QueryContext(ctx, query, sqliteArray(workoutIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		set := models.WorkoutSet{}
+		if err := rows.Scan(
+			&set.ID,
+			&set.WorkoutEntryID,
+			&set.SetNumber,
+			&set.Reps,
+			&set.Weight,
+			&set.DurationSeconds,
+			&set.DistanceMeters,
+			&set.RPE,
+			&set.RestSeconds,
+			&set.Failed,
+			&set.CreatedAt,
+			&set.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		entry := entries[set.WorkoutEntryID]
+		entry.Sets = append(entry.Sets, set)
+	}
+
+	return rows.Err()
+}
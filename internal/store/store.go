@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"time"
 
 	"github.com/agkmw/workout-service/internal/models"
@@ -8,23 +9,124 @@ import (
 )
 
 type UserStore interface {
-	CreateUser(*models.User) error
-	SearchUsersByUsername(username string) ([]models.User, error)
-	GetUserByUsername(username string) (*models.User, error)
-	UpdateUser(*models.User) error
-	GetUserByToken(scope, plaintextToken string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	SearchUsersByUsername(ctx context.Context, query string, limit, offset int) ([]models.PublicProfile, error)
+	GetPublicProfile(ctx context.Context, username string) (*models.PublicProfile, error)
+	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	ListUsers(ctx context.Context, limit, offset int) ([]*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, user *models.User) error
+	ResetPassword(ctx context.Context, user *models.User, plaintextToken string) error
+	ChangePassword(ctx context.Context, user *models.User, currentToken string) error
+	UpdateRole(ctx context.Context, user *models.User) error
+	UpdateDisabled(ctx context.Context, user *models.User) error
+	DeleteUser(ctx context.Context, id int64) error
+	GetUserByToken(ctx context.Context, scope, plaintextToken string) (*models.User, error)
 }
 
 type WorkoutStore interface {
-	CreateWorkout(*models.Workout) error
-	GetWorkoutByID(id int64) (*models.Workout, error)
-	UpdateWorkoutByID(*models.Workout) error
-	DeleteWorkoutByID(id int64) error
-	GetWorkoutOwner(id int64) (int64, error)
+	ForOrganization(organizationID *int64) WorkoutStore
+	CreateWorkout(ctx context.Context, workout *models.Workout) error
+	GetWorkoutByID(ctx context.Context, id, viewerID int64) (*models.Workout, error)
+	GetWorkoutByShareToken(ctx context.Context, token string) (*models.Workout, error)
+	UpdateWorkoutByID(ctx context.Context, workout *models.Workout) error
+	DeleteWorkoutByID(ctx context.Context, id int64) error
+	GetVisibleWorkoutOwner(ctx context.Context, id, viewerID int64) (int64, error)
+	ListWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, string, error)
+	GetLastCompletedFromTemplate(ctx context.Context, userID, templateID int64) (*models.Workout, error)
+	ListEnrollmentWorkouts(ctx context.Context, enrollmentID int64) ([]models.SessionWorkout, error)
+	ListFeed(ctx context.Context, viewerID int64, cursor string, limit int) ([]*models.Workout, string, error)
+}
+
+type CommentStore interface {
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetCommentByID(ctx context.Context, id int64) (*models.Comment, error)
+	ListComments(ctx context.Context, workoutID int64, limit, offset int) ([]models.Comment, error)
+	DeleteCommentByID(ctx context.Context, id int64) error
+}
+
+type ReactionStore interface {
+	AddReaction(ctx context.Context, reaction *models.Reaction) error
+	RemoveReaction(ctx context.Context, workoutID, userID int64, kind string) error
+	ListReactions(ctx context.Context, workoutID int64) ([]models.Reaction, error)
+}
+
+type FollowStore interface {
+	Follow(ctx context.Context, followerID, followeeID int64) error
+	Unfollow(ctx context.Context, followerID, followeeID int64) error
+	ListFollowers(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error)
+	ListFollowing(ctx context.Context, userID int64, limit, offset int) ([]models.PublicProfile, error)
+}
+
+type ExerciseStore interface {
+	ForOrganization(organizationID *int64) ExerciseStore
+	CreateExercise(ctx context.Context, exercise *models.Exercise) error
+	SearchExercises(ctx context.Context, filter ExerciseFilter) ([]models.Exercise, error)
+	GetExerciseByID(ctx context.Context, id int64) (*models.Exercise, error)
+	ResolveExerciseIDs(ctx context.Context, names []string) (map[string]int64, error)
+}
+
+type RecordStore interface {
+	ForOrganization(organizationID *int64) RecordStore
+	GetCurrentRecords(ctx context.Context, userID int64) ([]models.PersonalRecord, error)
+	GetRecordHistory(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) ([]models.PersonalRecord, error)
+	GetBestEstimated1RM(ctx context.Context, userID int64, exerciseKey string, exerciseID *int64) (float64, error)
+}
+
+type TemplateStore interface {
+	ForOrganization(organizationID *int64) TemplateStore
+	CreateTemplate(ctx context.Context, template *models.WorkoutTemplate) error
+	GetTemplateByID(ctx context.Context, id, userID int64) (*models.WorkoutTemplate, error)
+	ListTemplates(ctx context.Context, userID int64) ([]*models.WorkoutTemplate, error)
+	UpdateTemplate(ctx context.Context, template *models.WorkoutTemplate) error
+	DeleteTemplateByID(ctx context.Context, id, userID int64) error
+}
+
+type ProgramStore interface {
+	CreateProgram(ctx context.Context, program *models.Program) error
+	GetProgramByID(ctx context.Context, id, viewerID int64) (*models.Program, error)
+	ListPrograms(ctx context.Context, userID int64) ([]*models.Program, error)
+	DeleteProgramByID(ctx context.Context, id, userID int64) error
+	CreateEnrollment(ctx context.Context, enrollment *models.ProgramEnrollment) error
+	GetEnrollmentByID(ctx context.Context, id, userID int64) (*models.ProgramEnrollment, error)
+	ListEnrollments(ctx context.Context, userID int64) ([]*models.ProgramEnrollment, error)
+	CancelEnrollment(ctx context.Context, id, userID int64) error
 }
 
 type TokenStore interface {
-	Insert(token *tokens.Token) error
-	CreateNewToken(userID int64, ttl time.Duration, scope string) (*tokens.Token, error)
-	DeleteAllTokensForUser(userID int64, scope string) error
+	Insert(ctx context.Context, token *tokens.Token) error
+	CreateNewToken(ctx context.Context, userID int64, scope string) (*tokens.Token, error)
+	DeleteAllTokensForUser(ctx context.Context, userID int64, scope string) error
+	CreateTokenPair(ctx context.Context, userID int64, userAgent, ip string) (*tokens.Pair, error)
+	RotateRefreshToken(ctx context.Context, plaintextToken, userAgent, ip string) (*tokens.Pair, error)
+	DeleteToken(ctx context.Context, scope, plaintextToken string) error
+	ListSessions(ctx context.Context, userID int64, currentToken string) ([]tokens.Session, error)
+	TouchToken(ctx context.Context, plaintextToken string, interval time.Duration) error
+	DeleteExpiredTokens(ctx context.Context) (int64, error)
+}
+
+type CoachStore interface {
+	CreateInvitation(ctx context.Context, link *models.CoachLink) error
+	ListAthletes(ctx context.Context, coachID int64) ([]*models.CoachLink, error)
+	ListCoaches(ctx context.Context, athleteID int64) ([]*models.CoachLink, error)
+	AcceptInvitation(ctx context.Context, id, athleteID int64) (*models.CoachLink, error)
+	DeleteLink(ctx context.Context, id, userID int64) error
+	IsCoach(ctx context.Context, coachID, athleteID int64) (bool, error)
+}
+
+type AdminStore interface {
+	RecordAction(ctx context.Context, action *models.AdminAction) error
+	ListActions(ctx context.Context, limit, offset int) ([]*models.AdminAction, error)
+	DeleteWorkout(ctx context.Context, id int64) error
+}
+
+type OrganizationStore interface {
+	CreateOrganization(ctx context.Context, organization *models.Organization, ownerID int64) error
+	GetMembership(ctx context.Context, slug string, userID int64) (*models.Membership, error)
+	ListMemberships(ctx context.Context, userID int64) ([]*models.Membership, error)
+	ListMembers(ctx context.Context, organizationID int64) ([]models.OrganizationMember, error)
+	AddMember(ctx context.Context, member *models.OrganizationMember) error
+	RemoveMember(ctx context.Context, organizationID, userID int64) error
 }
@@ -0,0 +1,496 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+// tenant is an organization, or the personal space when id is nil.
+type tenant struct {
+	name string
+	id   *int64
+}
+
+// newTenants creates two organizations owned by ownerID and returns them
+// after the personal space.
+func newTenants(t *testing.T, s Stores, ownerID int64) []tenant {
+	t.Helper()
+
+	tenants := []tenant{{name: "personal"}}
+	for _, name := range []string{"A", "B"} {
+		n := counter.Add(1)
+		organization := &models.Organization{
+			Name: "Organization " + name,
+			Slug: fmt.Sprintf("st%x-%d", runID, n),
+		}
+		if err := s.Organizations.CreateOrganization(context.Background(), organization, ownerID); err != nil {
+			t.Fatalf("create organization: %v", err)
+		}
+		tenants = append(tenants, tenant{name: name, id: &organization.ID})
+	}
+	return tenants
+}
+
+// newTemplate creates a template of userID with a single entry.
+func newTemplate(t *testing.T, templates store.TemplateStore, userID int64) *models.WorkoutTemplate {
+	t.Helper()
+
+	template := &models.WorkoutTemplate{
+		UserID: userID,
+		Name:   "Leg day",
+		Entries: []models.WorkoutTemplateEntry{
+			{ExerciseName: "Squat", TargetSets: 3, TargetReps: ptr(5), TargetWeight: ptr(100.0)},
+		},
+	}
+	if err := templates.CreateTemplate(context.Background(), template); err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+	return template
+}
+
+// testWorkoutIsolation gives one user workouts in two organizations and the
+// personal space, and checks that no workout store call crosses from one
+// tenant into another.
+func testWorkoutIsolation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	follower := newUser(t, s)
+	if err := s.Follows.Follow(ctx, follower.ID, user.ID); err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+	tenants := newTenants(t, s, user.ID)
+
+	// programs are personal, but the workouts logged for them are not
+	program := &models.Program{
+		UserID:           user.ID,
+		Name:             "Isolation",
+		Weeks:            4,
+		DeloadPercentage: 60,
+		Sessions:         []models.ProgramSession{{Week: 1, Day: 1, TemplateID: newTemplate(t, s.Templates, user.ID).ID}},
+	}
+	if err := s.Programs.CreateProgram(ctx, program); err != nil {
+		t.Fatalf("CreateProgram: %v", err)
+	}
+	enrollment := &models.ProgramEnrollment{UserID: user.ID, ProgramID: program.ID, StartDate: time.Now()}
+	if err := s.Programs.CreateEnrollment(ctx, enrollment); err != nil {
+		t.Fatalf("CreateEnrollment: %v", err)
+	}
+
+	type data struct {
+		template *models.WorkoutTemplate
+		workout  *models.Workout
+		unlisted *models.Workout
+	}
+	byTenant := make([]data, len(tenants))
+	for i, tn := range tenants {
+		scoped := s
+		scoped.Workouts = s.Workouts.ForOrganization(tn.id)
+		template := newTemplate(t, s.Templates.ForOrganization(tn.id), user.ID)
+		byTenant[i] = data{
+			template: template,
+			workout: newWorkout(t, scoped, user.ID, func(w *models.Workout) {
+				w.Title = "Workout " + tn.name
+				w.Visibility = models.VisibilityPublic
+				w.TemplateID = &template.ID
+				w.EnrollmentID = &enrollment.ID
+				w.ProgramSessionID = &program.Sessions[0].ID
+			}),
+			unlisted: newWorkout(t, scoped, user.ID, func(w *models.Workout) {
+				w.Visibility = models.VisibilityUnlisted
+			}),
+		}
+		if byTenant[i].unlisted.ShareToken == "" {
+			t.Fatal("CreateWorkout didn't give the unlisted workout a share token")
+		}
+	}
+
+	for i, in := range tenants {
+		workouts := s.Workouts.ForOrganization(in.id)
+
+		for j, from := range tenants {
+			d := byTenant[j]
+			same := i == j
+			where := fmt.Sprintf("workout of %s read in %s", from.name, in.name)
+
+			got, err := workouts.GetWorkoutByID(ctx, d.workout.ID, user.ID)
+			checkIsolated(t, "GetWorkoutByID", where, same, err)
+			if same && err == nil && got.Title != d.workout.Title {
+				t.Errorf("GetWorkoutByID of %s = %q", where, got.Title)
+			}
+
+			_, err = workouts.GetVisibleWorkoutOwner(ctx, d.workout.ID, user.ID)
+			checkIsolated(t, "GetVisibleWorkoutOwner", where, same, err)
+
+			_, err = workouts.GetWorkoutByShareToken(ctx, d.unlisted.ShareToken)
+			checkIsolated(t, "GetWorkoutByShareToken", where, same, err)
+
+			last, err := workouts.GetLastCompletedFromTemplate(ctx, user.ID, d.template.ID)
+			checkIsolated(t, "GetLastCompletedFromTemplate", where, same, err)
+			if same && err == nil && last.ID != d.workout.ID {
+				t.Errorf("GetLastCompletedFromTemplate of %s = workout %d, want %d", where, last.ID, d.workout.ID)
+			}
+
+			if same {
+				continue
+			}
+			changed := *d.workout
+			changed.Title = "Changed in " + in.name
+			if err := workouts.UpdateWorkoutByID(ctx, &changed); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("UpdateWorkoutByID of %s = %v, want sql.ErrNoRows", where, err)
+			}
+			if err := workouts.DeleteWorkoutByID(ctx, d.workout.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("DeleteWorkoutByID of %s = %v, want sql.ErrNoRows", where, err)
+			}
+		}
+
+		own := byTenant[i]
+		listed, _, err := workouts.ListWorkouts(ctx, store.WorkoutFilter{UserID: user.ID, Sort: store.WorkoutSortNewest, Limit: 10})
+		if err != nil {
+			t.Fatalf("ListWorkouts: %v", err)
+		}
+		if ids := workoutIDs(listed); !sameIDs(ids, own.workout.ID, own.unlisted.ID) {
+			t.Errorf("ListWorkouts in %s = %v, want %d and %d", in.name, ids, own.workout.ID, own.unlisted.ID)
+		}
+
+		feed, _, err := workouts.ListFeed(ctx, follower.ID, "", 10)
+		if err != nil {
+			t.Fatalf("ListFeed: %v", err)
+		}
+		if ids := workoutIDs(feed); !sameIDs(ids, own.workout.ID) {
+			t.Errorf("ListFeed in %s = %v, want %d", in.name, ids, own.workout.ID)
+		}
+
+		sessions, err := workouts.ListEnrollmentWorkouts(ctx, enrollment.ID)
+		if err != nil {
+			t.Fatalf("ListEnrollmentWorkouts: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].WorkoutID != own.workout.ID {
+			t.Errorf("ListEnrollmentWorkouts in %s = %+v, want only workout %d", in.name, sessions, own.workout.ID)
+		}
+	}
+
+	// the updates and deletes from other tenants must not have touched them
+	for i, tn := range tenants {
+		got, err := s.Workouts.ForOrganization(tn.id).GetWorkoutByID(ctx, byTenant[i].workout.ID, user.ID)
+		if err != nil {
+			t.Fatalf("GetWorkoutByID in %s after the other tenants wrote: %v", tn.name, err)
+		}
+		if got.Title != byTenant[i].workout.Title {
+			t.Errorf("workout of %s was renamed to %q from another tenant", tn.name, got.Title)
+		}
+	}
+
+	// profiles are public, so only the personal space's workouts count
+	profile, err := s.Users.GetPublicProfile(ctx, user.Username)
+	if err != nil {
+		t.Fatalf("GetPublicProfile: %v", err)
+	}
+	if profile.PublicWorkoutCount != 1 {
+		t.Errorf("GetPublicProfile counted %d public workouts, want only the personal one", profile.PublicWorkoutCount)
+	}
+	following, err := s.Follows.ListFollowing(ctx, follower.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListFollowing: %v", err)
+	}
+	if len(following) != 1 || following[0].PublicWorkoutCount != 1 {
+		t.Errorf("ListFollowing = %+v, want the user with 1 public workout", following)
+	}
+
+	// admins delete workouts in every tenant
+	for i, tn := range tenants {
+		id := byTenant[i].workout.ID
+		if err := s.Admin.DeleteWorkout(ctx, id); err != nil {
+			t.Errorf("admin DeleteWorkout of the workout of %s: %v", tn.name, err)
+		}
+		if _, err := s.Workouts.ForOrganization(tn.id).GetWorkoutByID(ctx, id, user.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("GetWorkoutByID after admin DeleteWorkout in %s = %v, want sql.ErrNoRows", tn.name, err)
+		}
+	}
+	if err := s.Admin.DeleteWorkout(ctx, byTenant[0].workout.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("admin DeleteWorkout of a deleted workout = %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testTemplateIsolation checks that templates are only seen and changed in
+// the tenant they were created in.
+func testTemplateIsolation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	tenants := newTenants(t, s, user.ID)
+
+	byTenant := make([]*models.WorkoutTemplate, len(tenants))
+	for i, tn := range tenants {
+		byTenant[i] = newTemplate(t, s.Templates.ForOrganization(tn.id), user.ID)
+		if !equalIDs(byTenant[i].OrganizationID, tn.id) {
+			t.Errorf("template of %s has organization %v", tn.name, byTenant[i].OrganizationID)
+		}
+	}
+
+	for i, in := range tenants {
+		templates := s.Templates.ForOrganization(in.id)
+
+		for j, from := range tenants {
+			template := byTenant[j]
+			same := i == j
+			where := fmt.Sprintf("template of %s read in %s", from.name, in.name)
+
+			_, err := templates.GetTemplateByID(ctx, template.ID, user.ID)
+			checkIsolated(t, "GetTemplateByID", where, same, err)
+
+			if same {
+				continue
+			}
+			changed := *template
+			changed.Name = "Changed in " + in.name
+			if err := templates.UpdateTemplate(ctx, &changed); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("UpdateTemplate of %s = %v, want sql.ErrNoRows", where, err)
+			}
+			if err := templates.DeleteTemplateByID(ctx, template.ID, user.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("DeleteTemplateByID of %s = %v, want sql.ErrNoRows", where, err)
+			}
+		}
+
+		listed, err := templates.ListTemplates(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("ListTemplates: %v", err)
+		}
+		if len(listed) != 1 || listed[0].ID != byTenant[i].ID || listed[0].Name != byTenant[i].Name {
+			t.Errorf("ListTemplates in %s returned %d templates, want only %d unchanged", in.name, len(listed), byTenant[i].ID)
+		}
+	}
+}
+
+// testExerciseIsolation checks that an organization's own exercises are
+// hidden from other organizations and the personal space, which only see
+// the shared catalog.
+func testExerciseIsolation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	tenants := newTenants(t, s, user.ID)
+	name := fmt.Sprintf("Sled Push %x", runID)
+
+	if err := s.Exercises.ForOrganization(nil).CreateExercise(ctx, &models.Exercise{Name: name}); err == nil {
+		t.Error("CreateExercise in the personal space succeeded")
+	}
+
+	// both organizations may use the same name
+	byTenant := make([]*models.Exercise, len(tenants))
+	for i, tn := range tenants[1:] {
+		exercise := &models.Exercise{Name: name, PrimaryMuscles: []string{"quads"}, SecondaryMuscles: []string{}}
+		if err := s.Exercises.ForOrganization(tn.id).CreateExercise(ctx, exercise); err != nil {
+			t.Fatalf("CreateExercise in %s: %v", tn.name, err)
+		}
+		byTenant[i+1] = exercise
+	}
+
+	for i, in := range tenants {
+		exercises := s.Exercises.ForOrganization(in.id)
+
+		for j, from := range tenants[1:] {
+			exercise := byTenant[j+1]
+			where := fmt.Sprintf("exercise of %s read in %s", from.name, in.name)
+			_, err := exercises.GetExerciseByID(ctx, exercise.ID)
+			checkIsolated(t, "GetExerciseByID", where, i == j+1, err)
+		}
+
+		var want []int64
+		if byTenant[i] != nil {
+			want = []int64{byTenant[i].ID}
+		}
+
+		found, err := exercises.SearchExercises(ctx, store.ExerciseFilter{Query: name, Limit: 10})
+		if err != nil {
+			t.Fatalf("SearchExercises: %v", err)
+		}
+		ids := []int64{}
+		for _, e := range found {
+			ids = append(ids, e.ID)
+		}
+		if !sameIDs(ids, want...) {
+			t.Errorf("SearchExercises in %s = %v, want %v", in.name, ids, want)
+		}
+
+		resolved, err := exercises.ResolveExerciseIDs(ctx, []string{name})
+		if err != nil {
+			t.Fatalf("ResolveExerciseIDs: %v", err)
+		}
+		ids = []int64{}
+		for _, id := range resolved {
+			ids = append(ids, id)
+		}
+		if !sameIDs(ids, want...) {
+			t.Errorf("ResolveExerciseIDs in %s = %v, want %v", in.name, ids, want)
+		}
+	}
+}
+
+// newBenchWorkout creates a completed workout of userID in the tenant with
+// two sets of bench press at weight.
+func newBenchWorkout(t *testing.T, s Stores, tn tenant, userID int64, weight float64) *models.Workout {
+	t.Helper()
+
+	scoped := s
+	scoped.Workouts = s.Workouts.ForOrganization(tn.id)
+	return newWorkout(t, scoped, userID, func(w *models.Workout) {
+		w.Title = "Workout " + tn.name
+		w.Entries[0].Weight = ptr(weight)
+		w.Entries[0].Sets = []models.WorkoutSet{
+			{Reps: ptr(5), Weight: ptr(weight)},
+			{Reps: ptr(5), Weight: ptr(weight)},
+		}
+	})
+}
+
+// testRecordIsolation checks that personal records are only set against, and
+// read from, the records of the same tenant.
+func testRecordIsolation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	tenants := newTenants(t, s, user.ID)
+
+	// lighter in every later tenant, so a record compared across tenants
+	// would not be set
+	byTenant := make([]*models.Workout, len(tenants))
+	for i, tn := range tenants {
+		weight := 120 - 10*float64(i)
+		byTenant[i] = newBenchWorkout(t, s, tn, user.ID, weight)
+
+		var record *models.PersonalRecord
+		for j := range byTenant[i].NewRecords {
+			if byTenant[i].NewRecords[j].RecordType == records.TypeMaxWeight {
+				record = &byTenant[i].NewRecords[j]
+			}
+		}
+		if record == nil || record.Value != weight || record.PreviousValue != nil {
+			t.Errorf("max weight record of the workout of %s = %+v, want a first record of %v", tn.name, record, weight)
+		}
+	}
+
+	for i, in := range tenants {
+		scoped := s.Records.ForOrganization(in.id)
+		own := byTenant[i]
+
+		current, err := scoped.GetCurrentRecords(ctx, user.ID)
+		if err != nil {
+			t.Fatalf("GetCurrentRecords: %v", err)
+		}
+		history, err := scoped.GetRecordHistory(ctx, user.ID, "bench press", nil)
+		if err != nil {
+			t.Fatalf("GetRecordHistory: %v", err)
+		}
+		if len(current) == 0 || len(history) == 0 {
+			t.Errorf("records in %s: %d current, %d in the history, want some of each", in.name, len(current), len(history))
+		}
+		for call, found := range map[string][]models.PersonalRecord{"GetCurrentRecords": current, "GetRecordHistory": history} {
+			for _, record := range found {
+				if record.WorkoutID != own.ID {
+					t.Errorf("%s in %s returned a record of workout %d, want only workout %d", call, in.name, record.WorkoutID, own.ID)
+				}
+			}
+		}
+
+		weight := *own.Entries[0].Sets[0].Weight
+		best, err := scoped.GetBestEstimated1RM(ctx, user.ID, "bench press", nil)
+		if err != nil {
+			t.Fatalf("GetBestEstimated1RM: %v", err)
+		}
+		if want := weight * (1 + 5.0/30); math.Abs(best-want) > 0.01 {
+			t.Errorf("GetBestEstimated1RM in %s = %v, want %v", in.name, best, want)
+		}
+	}
+}
+
+// testStatisticsIsolation checks that the statistics of a tenant only count
+// its own workouts.
+func testStatisticsIsolation(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	tenants := newTenants(t, s, user.ID)
+
+	weights := make([]float64, len(tenants))
+	for i, tn := range tenants {
+		weights[i] = 100 + 10*float64(i)
+		newBenchWorkout(t, s, tn, user.ID, weights[i])
+	}
+
+	for i, in := range tenants {
+		stats := s.Analytics.ForOrganization(in.id)
+		volume := 10 * weights[i]
+
+		summary, err := stats.Summary(ctx, user.ID, "UTC")
+		if err != nil {
+			t.Fatalf("Summary: %v", err)
+		}
+		if summary.TotalWorkouts != 1 || summary.TotalVolume != volume {
+			t.Errorf("Summary in %s = %d workouts and %v volume, want 1 and %v", in.name, summary.TotalWorkouts, summary.TotalVolume, volume)
+		}
+
+		buckets, err := stats.Volume(ctx, analytics.VolumeQuery{
+			UserID:   user.ID,
+			Period:   analytics.PeriodWeek,
+			GroupBy:  analytics.GroupByExercise,
+			Timezone: "UTC",
+		})
+		if err != nil {
+			t.Fatalf("Volume: %v", err)
+		}
+		if len(buckets) != 1 || buckets[0].Volume != volume || buckets[0].Sets != 2 {
+			t.Errorf("Volume in %s = %+v, want a single bucket of %v over 2 sets", in.name, buckets, volume)
+		}
+
+		points, err := stats.ExerciseProgression(ctx, user.ID, "bench press", nil, analytics.PeriodWeek, "UTC")
+		if err != nil {
+			t.Fatalf("ExerciseProgression: %v", err)
+		}
+		if len(points) != 1 || points[0].MaxWeight != weights[i] {
+			t.Errorf("ExerciseProgression in %s = %+v, want a single point at %v", in.name, points, weights[i])
+		}
+	}
+}
+
+// checkIsolated expects call to succeed within the same tenant and to report
+// sql.ErrNoRows from any other.
+func checkIsolated(t *testing.T, call, where string, same bool, err error) {
+	t.Helper()
+
+	switch {
+	case same && err != nil:
+		t.Errorf("%s of %s: %v", call, where, err)
+	case !same && !errors.Is(err, sql.ErrNoRows):
+		t.Errorf("%s of %s = %v, want sql.ErrNoRows", call, where, err)
+	}
+}
+
+func workoutIDs(workouts []*models.Workout) []int64 {
+	ids := []int64{}
+	for _, w := range workouts {
+		ids = append(ids, w.ID)
+	}
+	return ids
+}
+
+// sameIDs reports whether ids holds exactly want, in any order.
+func sameIDs(ids []int64, want ...int64) bool {
+	a, b := slices.Clone(ids), slices.Clone(want)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+func equalIDs(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
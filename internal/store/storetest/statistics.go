@@ -0,0 +1,44 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/models"
+)
+
+// testStatisticsSpellings logs an exercise under two spellings and checks
+// that volume and progression count them as the same exercise.
+func testStatisticsSpellings(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	for _, name := range []string{"Bench Press", " bench  press "} {
+		newWorkout(t, s, user.ID, func(w *models.Workout) {
+			w.Entries[0].ExerciseName = name
+			w.Entries[0].Sets = []models.WorkoutSet{{Reps: ptr(5), Weight: ptr(100.0)}}
+		})
+	}
+
+	buckets, err := s.Analytics.Volume(ctx, analytics.VolumeQuery{
+		UserID:   user.ID,
+		Period:   analytics.PeriodMonth,
+		GroupBy:  analytics.GroupByExercise,
+		Timezone: "UTC",
+	})
+	if err != nil {
+		t.Fatalf("Volume: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Group != "bench press" || buckets[0].Volume != 1000 || buckets[0].Sets != 2 {
+		t.Errorf("Volume = %+v, want a single bench press bucket of 1000 over 2 sets", buckets)
+	}
+
+	points, err := s.Analytics.ExerciseProgression(ctx, user.ID, "bench press", nil, analytics.PeriodMonth, "UTC")
+	if err != nil {
+		t.Fatalf("ExerciseProgression: %v", err)
+	}
+	if len(points) != 1 || points[0].Volume != 1000 || points[0].Sets != 2 {
+		t.Errorf("ExerciseProgression = %+v, want a single point of 1000 over 2 sets", points)
+	}
+}
@@ -0,0 +1,169 @@
+// Package storetest is a conformance suite for the store backends. Every
+// backend must pass it, so that handlers behave the same whichever one the
+// server runs on. A backend's test calls Run with a function opening its
+// stores:
+//
+//	func TestMemoryStores(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) storetest.Stores {
+//			db := store.NewMemoryDB()
+//			return storetest.Stores{
+//				Users:    store.NewMemoryUserStore(db),
+//				Workouts: store.NewMemoryWorkoutStore(db),
+//				Tokens:   store.NewMemoryTokenStore(db, storetest.TTLs),
+//				...
+//			}
+//		})
+//	}
+//
+// The stores may share a database with earlier runs; every test creates its
+// own users with unique names.
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/analytics"
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+// Stores are the stores under test, all backed by the same database.
+type Stores struct {
+	Users         store.UserStore
+	Workouts      store.WorkoutStore
+	Tokens        store.TokenStore
+	Templates     store.TemplateStore
+	Exercises     store.ExerciseStore
+	Programs      store.ProgramStore
+	Follows       store.FollowStore
+	Coaches       store.CoachStore
+	Organizations store.OrganizationStore
+	Admin         store.AdminStore
+	Records       store.RecordStore
+	Analytics     analytics.Store
+}
+
+// TTLs are the token lifetimes the suite expects the token store to use.
+var TTLs = tokens.TTLs{
+	Access:        15 * time.Minute,
+	Refresh:       24 * time.Hour,
+	PasswordReset: 45 * time.Minute,
+	Activation:    time.Hour,
+}
+
+// Run runs every conformance test against the stores returned by open,
+// which is called once per test.
+func Run(t *testing.T, open func(t *testing.T) Stores) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s Stores)
+	}{
+		{"CreateUser", testCreateUser},
+		{"CreateUserDuplicates", testCreateUserDuplicates},
+		{"GetUserNotFound", testGetUserNotFound},
+		{"UpdateUser", testUpdateUser},
+		{"ChangePassword", testChangePassword},
+		{"ResetPassword", testResetPassword},
+		{"DeleteUser", testDeleteUser},
+		{"DeleteUserCascades", testDeleteUserCascades},
+		{"CreateWorkout", testCreateWorkout},
+		{"WorkoutEntryCheck", testWorkoutEntryCheck},
+		{"ConcurrentWorkouts", testConcurrentWorkouts},
+		{"UpdateWorkout", testUpdateWorkout},
+		{"DeleteWorkout", testDeleteWorkout},
+		{"WorkoutVisibility", testWorkoutVisibility},
+		{"ShareToken", testShareToken},
+		{"CoachKeepsShareToken", testCoachKeepsShareToken},
+		{"ListWorkouts", testListWorkouts},
+		{"PersonalRecords", testPersonalRecords},
+		{"EditPastRecord", testEditPastRecord},
+		{"TokenLookup", testTokenLookup},
+		{"TokenExpiry", testTokenExpiry},
+		{"DeleteTokens", testDeleteTokens},
+		{"RotateRefreshToken", testRotateRefreshToken},
+		{"Sessions", testSessions},
+		{"WorkoutIsolation", testWorkoutIsolation},
+		{"TemplateIsolation", testTemplateIsolation},
+		{"ExerciseIsolation", testExerciseIsolation},
+		{"RecordIsolation", testRecordIsolation},
+		{"StatisticsSpellings", testStatisticsSpellings},
+		{"StatisticsIsolation", testStatisticsIsolation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, open(t))
+		})
+	}
+}
+
+var (
+	runID   = time.Now().UnixNano()
+	counter atomic.Int64
+)
+
+// fakeHash fills the password hash column, which must not be NULL.
+var fakeHash = models.Password{Hash: []byte("not a real hash")}
+
+// newUser creates a user whose username and email no other test uses.
+func newUser(t *testing.T, s Stores) *models.User {
+	t.Helper()
+
+	n := counter.Add(1)
+	user := &models.User{
+		Username: fmt.Sprintf("st%x_%d", runID, n),
+		Email:    fmt.Sprintf("st%x_%d@example.com", runID, n),
+		Bio:      "conformance",
+		Timezone: "UTC",
+		// the hash isn't checked, so there's no need to pay for bcrypt
+		PasswordHash: fakeHash,
+	}
+	if err := s.Users.CreateUser(context.Background(), user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user
+}
+
+// newWorkout creates a completed workout of userID with one entry of three
+// sets.
+func newWorkout(t *testing.T, s Stores, userID int64, mutate func(w *models.Workout)) *models.Workout {
+	t.Helper()
+
+	workout := &models.Workout{
+		UserID:          userID,
+		Title:           "Push day",
+		Description:     "conformance",
+		DurationMinutes: 60,
+		CaloriesBurned:  400,
+		Visibility:      models.VisibilityPrivate,
+		Status:          models.StatusCompleted,
+		Entries: []models.WorkoutEntry{
+			{
+				ExerciseName: "Bench Press",
+				Reps:         ptr(5),
+				Weight:       ptr(80.0),
+				OrderIndex:   0,
+				Sets: []models.WorkoutSet{
+					{Reps: ptr(5), Weight: ptr(80.0)},
+					{Reps: ptr(5), Weight: ptr(80.0)},
+					{Reps: ptr(3), Weight: ptr(85.0), Failed: true},
+				},
+			},
+		},
+	}
+	if mutate != nil {
+		mutate(workout)
+	}
+	if err := s.Workouts.CreateWorkout(context.Background(), workout); err != nil {
+		t.Fatalf("create workout: %v", err)
+	}
+	return workout
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,200 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+func testTokenLookup(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	token, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopeActivation)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+	if want := time.Now().Add(TTLs.Activation); token.Expiry.Before(want.Add(-time.Minute)) || token.Expiry.After(want.Add(time.Minute)) {
+		t.Errorf("token expires at %v, want about %v", token.Expiry, want)
+	}
+
+	got, err := s.Users.GetUserByToken(ctx, tokens.ScopeActivation, token.PlainText)
+	if err != nil || got.ID != user.ID {
+		t.Fatalf("GetUserByToken = %v, %v; want user %d", got, err, user.ID)
+	}
+
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, token.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByToken with the wrong scope = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeActivation, token.PlainText+"x"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByToken of an unknown token = %v, want sql.ErrNoRows", err)
+	}
+
+	if _, err := s.Tokens.CreateNewToken(ctx, -1, tokens.ScopeAuth); err == nil {
+		t.Error("CreateNewToken for a missing user succeeded")
+	}
+}
+
+func testTokenExpiry(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	expired, err := tokens.GenerateToken(user.ID, -time.Hour, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if err := s.Tokens.Insert(ctx, expired); err != nil {
+		t.Fatalf("Insert: %v", err)
+	}
+	valid, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, expired.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByToken of an expired token = %v, want sql.ErrNoRows", err)
+	}
+
+	deleted, err := s.Tokens.DeleteExpiredTokens(ctx)
+	if err != nil {
+		t.Fatalf("DeleteExpiredTokens: %v", err)
+	}
+	if deleted < 1 {
+		t.Errorf("DeleteExpiredTokens deleted %d tokens, want at least 1", deleted)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, valid.PlainText); err != nil {
+		t.Errorf("DeleteExpiredTokens removed a valid token: %v", err)
+	}
+
+	// the expired token is gone, so its hash can be inserted again
+	if err := s.Tokens.Insert(ctx, expired); err != nil {
+		t.Errorf("inserting a deleted token again = %v", err)
+	}
+}
+
+func testDeleteTokens(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	auth, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+	reset, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopePasswordReset)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+
+	if err := s.Tokens.DeleteAllTokensForUser(ctx, user.ID, tokens.ScopePasswordReset); err != nil {
+		t.Fatalf("DeleteAllTokensForUser: %v", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopePasswordReset, reset.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("password reset token after deleting them all = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, auth.PlainText); err != nil {
+		t.Errorf("DeleteAllTokensForUser removed a token of another scope: %v", err)
+	}
+
+	if err := s.Tokens.DeleteToken(ctx, tokens.ScopeRefresh, auth.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("DeleteToken with the wrong scope = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Tokens.DeleteToken(ctx, tokens.ScopeAuth, auth.PlainText); err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, auth.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByToken of a deleted token = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Tokens.DeleteToken(ctx, tokens.ScopeAuth, auth.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a token twice = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testRotateRefreshToken(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	pair, err := s.Tokens.CreateTokenPair(ctx, user.ID, "agent", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+
+	rotated, err := s.Tokens.RotateRefreshToken(ctx, pair.Refresh.PlainText, "agent", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if rotated.Refresh.PlainText == pair.Refresh.PlainText {
+		t.Error("rotation handed out the same refresh token")
+	}
+	got, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, rotated.Access.PlainText)
+	if err != nil || got.ID != user.ID {
+		t.Fatalf("GetUserByToken of a rotated access token = %v, %v; want user %d", got, err, user.ID)
+	}
+
+	if _, err := s.Tokens.RotateRefreshToken(ctx, pair.Access.PlainText, "agent", "192.0.2.1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("rotating an access token = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Tokens.RotateRefreshToken(ctx, "unknown", "agent", "192.0.2.1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("rotating an unknown token = %v, want sql.ErrNoRows", err)
+	}
+
+	// presenting the used refresh token again revokes the whole family
+	if _, err := s.Tokens.RotateRefreshToken(ctx, pair.Refresh.PlainText, "agent", "192.0.2.1"); !errors.Is(err, store.ErrRefreshTokenReused) {
+		t.Fatalf("reusing a refresh token = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, rotated.Access.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("access token of a revoked family = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Tokens.RotateRefreshToken(ctx, rotated.Refresh.PlainText, "agent", "192.0.2.1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("rotating the refresh token of a revoked family = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testSessions(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	phone, err := s.Tokens.CreateTokenPair(ctx, user.ID, "phone", "192.0.2.1")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+	laptop, err := s.Tokens.CreateTokenPair(ctx, user.ID, "laptop", "192.0.2.2")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+	if _, err := s.Tokens.RotateRefreshToken(ctx, phone.Refresh.PlainText, "phone v2", "192.0.2.3"); err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+
+	sessions, err := s.Tokens.ListSessions(ctx, user.ID, laptop.Access.PlainText)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("ListSessions returned %d sessions, want one per login", len(sessions))
+	}
+	// the laptop logged in last, and the phone session is described by its
+	// newest token
+	if sessions[0].UserAgent != "laptop" || !sessions[0].Current {
+		t.Errorf("first session = %+v, want the current laptop login", sessions[0])
+	}
+	if sessions[1].UserAgent != "phone v2" || sessions[1].IP != "192.0.2.3" || sessions[1].Current {
+		t.Errorf("second session = %+v, want the phone's latest token", sessions[1])
+	}
+
+	// logging out of one device ends only its session
+	if err := s.Tokens.DeleteToken(ctx, tokens.ScopeAuth, laptop.Access.PlainText); err != nil {
+		t.Fatalf("DeleteToken: %v", err)
+	}
+	sessions, err = s.Tokens.ListSessions(ctx, user.ID, laptop.Access.PlainText)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].UserAgent != "phone v2" {
+		t.Errorf("sessions after logging out the laptop = %+v, want only the phone", sessions)
+	}
+}
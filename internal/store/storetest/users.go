@@ -0,0 +1,293 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/store"
+	"github.com/agkmw/workout-service/internal/tokens"
+)
+
+func testCreateUser(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	if user.ID == 0 {
+		t.Fatal("CreateUser didn't set the id")
+	}
+	if user.Role != models.RoleUser || user.Activated || user.Disabled {
+		t.Errorf("new user has role %q, activated %v, disabled %v; want %q, false, false", user.Role, user.Activated, user.Disabled, models.RoleUser)
+	}
+	if user.CreatedAt.IsZero() || user.UpdatedAt.IsZero() {
+		t.Error("CreateUser didn't set the timestamps")
+	}
+
+	got, err := s.Users.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if got.Username != user.Username || got.Email != user.Email || got.Bio != user.Bio || got.Timezone != user.Timezone {
+		t.Errorf("GetUserByID = %+v, want %+v", got, user)
+	}
+	if string(got.PasswordHash.Hash) != string(user.PasswordHash.Hash) {
+		t.Error("GetUserByID didn't return the password hash")
+	}
+	if !got.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("created at = %v, want %v", got.CreatedAt, user.CreatedAt)
+	}
+
+	got, err = s.Users.GetUserByUsername(ctx, user.Username)
+	if err != nil || got.ID != user.ID {
+		t.Errorf("GetUserByUsername = %v, %v; want user %d", got, err, user.ID)
+	}
+
+	// emails are matched case-insensitively
+	got, err = s.Users.GetUserByEmail(ctx, strings.ToUpper(user.Email))
+	if err != nil || got.ID != user.ID {
+		t.Errorf("GetUserByEmail = %v, %v; want user %d", got, err, user.ID)
+	}
+
+	// the returned user is a copy
+	got.Bio = "changed"
+	again, err := s.Users.GetUserByID(ctx, user.ID)
+	if err != nil || again.Bio != user.Bio {
+		t.Errorf("changing a returned user changed the stored one: %v, %v", again, err)
+	}
+}
+
+func testCreateUserDuplicates(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	other := newUser(t, s)
+
+	err := s.Users.CreateUser(ctx, &models.User{Username: user.Username, Email: "x" + other.Email, PasswordHash: fakeHash})
+	if !errors.Is(err, store.ErrDuplicateUsername) {
+		t.Errorf("CreateUser with a taken username = %v, want ErrDuplicateUsername", err)
+	}
+
+	err = s.Users.CreateUser(ctx, &models.User{Username: "x" + other.Username, Email: user.Email, PasswordHash: fakeHash})
+	if !errors.Is(err, store.ErrDuplicateEmail) {
+		t.Errorf("CreateUser with a taken email = %v, want ErrDuplicateEmail", err)
+	}
+
+	// emails are looked up case-insensitively, so they are unique that way
+	err = s.Users.CreateUser(ctx, &models.User{Username: "x" + other.Username, Email: strings.ToUpper(user.Email), PasswordHash: fakeHash})
+	if !errors.Is(err, store.ErrDuplicateEmail) {
+		t.Errorf("CreateUser with a taken email in other case = %v, want ErrDuplicateEmail", err)
+	}
+	other.Email = strings.ToUpper(user.Email)
+	if err := s.Users.UpdateUser(ctx, other); !errors.Is(err, store.ErrDuplicateEmail) {
+		t.Errorf("UpdateUser to a taken email in other case = %v, want ErrDuplicateEmail", err)
+	}
+}
+
+func testChangePassword(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	current, err := s.Tokens.CreateTokenPair(ctx, user.ID, "agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+	otherSession, err := s.Tokens.CreateTokenPair(ctx, user.ID, "agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+	legacy, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+	reset, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopePasswordReset)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+
+	user.PasswordHash = models.Password{Hash: []byte("changed hash")}
+	if err := s.Users.ChangePassword(ctx, user, current.Access.PlainText); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	got, err := s.Users.GetUserByID(ctx, user.ID)
+	if err != nil || string(got.PasswordHash.Hash) != "changed hash" {
+		t.Errorf("password hash after change = %v, %v; want the new hash", got, err)
+	}
+
+	for _, token := range []*tokens.Token{current.Access, current.Refresh} {
+		if _, err := s.Users.GetUserByToken(ctx, token.Scope, token.PlainText); err != nil {
+			t.Errorf("ChangePassword revoked the current session's %s token: %v", token.Scope, err)
+		}
+	}
+	for _, token := range []*tokens.Token{otherSession.Access, otherSession.Refresh, legacy, reset} {
+		if _, err := s.Users.GetUserByToken(ctx, token.Scope, token.PlainText); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s token of another session after change = %v, want sql.ErrNoRows", token.Scope, err)
+		}
+	}
+}
+
+func testResetPassword(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	other := newUser(t, s)
+
+	pair, err := s.Tokens.CreateTokenPair(ctx, user.ID, "agent", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("CreateTokenPair: %v", err)
+	}
+	otherAuth, err := s.Tokens.CreateNewToken(ctx, other.ID, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+	reset, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopePasswordReset)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+
+	// another user's reset token doesn't work
+	other.PasswordHash = models.Password{Hash: []byte("other hash")}
+	if err := s.Users.ResetPassword(ctx, other, reset.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ResetPassword with someone else's token = %v, want sql.ErrNoRows", err)
+	}
+
+	user.PasswordHash = models.Password{Hash: []byte("new hash")}
+	if err := s.Users.ResetPassword(ctx, user, reset.PlainText); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	got, err := s.Users.GetUserByID(ctx, user.ID)
+	if err != nil || string(got.PasswordHash.Hash) != "new hash" {
+		t.Errorf("password hash after reset = %v, %v; want the new hash", got, err)
+	}
+	for _, token := range []*tokens.Token{pair.Access, pair.Refresh, reset} {
+		if _, err := s.Users.GetUserByToken(ctx, token.Scope, token.PlainText); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("%s token after reset = %v, want sql.ErrNoRows", token.Scope, err)
+		}
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, otherAuth.PlainText); err != nil {
+		t.Errorf("ResetPassword revoked another user's token: %v", err)
+	}
+
+	// the token works once
+	if err := s.Users.ResetPassword(ctx, user, reset.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("ResetPassword with a used token = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testGetUserNotFound(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	if _, err := s.Users.GetUserByID(ctx, -1); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByID of a missing user = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetUserByUsername(ctx, user.Username+"_missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByUsername of a missing user = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetUserByEmail(ctx, "missing_"+user.Email); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByEmail of a missing user = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetPublicProfile(ctx, user.Username+"_missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetPublicProfile of a missing user = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testUpdateUser(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	other := newUser(t, s)
+
+	user.Bio = "updated"
+	user.Timezone = "Europe/Berlin"
+	user.Activated = true
+	if err := s.Users.UpdateUser(ctx, user); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	got, err := s.Users.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if got.Bio != "updated" || got.Timezone != "Europe/Berlin" || !got.Activated {
+		t.Errorf("updated user = %+v", got)
+	}
+
+	taken := *user
+	taken.Username = other.Username
+	if err := s.Users.UpdateUser(ctx, &taken); !errors.Is(err, store.ErrDuplicateUsername) {
+		t.Errorf("UpdateUser to a taken username = %v, want ErrDuplicateUsername", err)
+	}
+	taken = *user
+	taken.Email = other.Email
+	if err := s.Users.UpdateUser(ctx, &taken); !errors.Is(err, store.ErrDuplicateEmail) {
+		t.Errorf("UpdateUser to a taken email = %v, want ErrDuplicateEmail", err)
+	}
+
+	missing := *user
+	missing.ID = -1
+	missing.Username += "_missing"
+	missing.Email = "missing_" + missing.Email
+	if err := s.Users.UpdateUser(ctx, &missing); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpdateUser of a missing user = %v, want sql.ErrNoRows", err)
+	}
+
+	user.Role = models.RoleAdmin
+	if err := s.Users.UpdateRole(ctx, user); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	user.Disabled = true
+	if err := s.Users.UpdateDisabled(ctx, user); err != nil {
+		t.Fatalf("UpdateDisabled: %v", err)
+	}
+	user.PasswordHash.Hash = []byte("another hash")
+	if err := s.Users.UpdatePassword(ctx, user); err != nil {
+		t.Fatalf("UpdatePassword: %v", err)
+	}
+	got, err = s.Users.GetUserByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if got.Role != models.RoleAdmin || !got.Disabled || string(got.PasswordHash.Hash) != "another hash" {
+		t.Errorf("user after role, disabled and password updates = %+v", got)
+	}
+}
+
+func testDeleteUser(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	if err := s.Users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.Users.GetUserByID(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetUserByID of a deleted user = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Users.DeleteUser(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a user twice = %v, want sql.ErrNoRows", err)
+	}
+
+	// the username and email are free again
+	again := &models.User{Username: user.Username, Email: user.Email, PasswordHash: fakeHash}
+	if err := s.Users.CreateUser(ctx, again); err != nil {
+		t.Errorf("CreateUser with a deleted user's name = %v", err)
+	}
+}
+
+func testDeleteUserCascades(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	workout := newWorkout(t, s, user.ID, nil)
+	token, err := s.Tokens.CreateNewToken(ctx, user.ID, tokens.ScopeAuth)
+	if err != nil {
+		t.Fatalf("CreateNewToken: %v", err)
+	}
+
+	if err := s.Users.DeleteUser(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("workout of a deleted user = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := s.Users.GetUserByToken(ctx, tokens.ScopeAuth, token.PlainText); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("token of a deleted user = %v, want sql.ErrNoRows", err)
+	}
+}
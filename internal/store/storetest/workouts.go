@@ -0,0 +1,504 @@
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/records"
+	"github.com/agkmw/workout-service/internal/store"
+)
+
+func testCreateWorkout(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	workout := newWorkout(t, s, user.ID, nil)
+
+	if workout.ID == 0 || workout.CreatedAt.IsZero() {
+		t.Fatal("CreateWorkout didn't set the id and timestamps")
+	}
+	entry := workout.Entries[0]
+	if entry.ID == 0 || entry.WorkoutID != workout.ID {
+		t.Errorf("entry has id %d and workout id %d", entry.ID, entry.WorkoutID)
+	}
+	for i, set := range entry.Sets {
+		if set.ID == 0 || set.WorkoutEntryID != entry.ID || set.SetNumber != i+1 {
+			t.Errorf("set %d has id %d, entry id %d and number %d", i, set.ID, set.WorkoutEntryID, set.SetNumber)
+		}
+	}
+
+	got, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, user.ID)
+	if err != nil {
+		t.Fatalf("GetWorkoutByID: %v", err)
+	}
+	if got.Title != workout.Title || got.DurationMinutes != workout.DurationMinutes ||
+		got.CaloriesBurned != workout.CaloriesBurned || got.Status != workout.Status || got.UserID != user.ID {
+		t.Errorf("GetWorkoutByID = %+v, want %+v", got, workout)
+	}
+	if len(got.Entries) != 1 || len(got.Entries[0].Sets) != 3 {
+		t.Fatalf("GetWorkoutByID returned %d entries, want 1 with 3 sets", len(got.Entries))
+	}
+	set := got.Entries[0].Sets[2]
+	if !set.Failed || set.Reps == nil || *set.Reps != 3 || set.Weight == nil || *set.Weight != 85 {
+		t.Errorf("third set = %+v, want a failed 3x85", set)
+	}
+	if got.ReactionCounts == nil {
+		t.Error("ReactionCounts is nil, want an empty map")
+	}
+
+	if _, err := s.Workouts.GetWorkoutByID(ctx, -1, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetWorkoutByID of a missing workout = %v, want sql.ErrNoRows", err)
+	}
+
+	orphan := &models.Workout{UserID: -1, Title: "orphan", Visibility: models.VisibilityPrivate, Status: models.StatusCompleted}
+	if err := s.Workouts.CreateWorkout(ctx, orphan); err == nil {
+		t.Error("CreateWorkout for a missing user succeeded")
+	}
+}
+
+// testWorkoutEntryCheck covers valid_workout_entry: an entry counts either
+// reps or a duration.
+func testWorkoutEntryCheck(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	valid := map[string]bool{"reps": true, "duration": true, "sets": true}
+	for name, entry := range map[string]models.WorkoutEntry{
+		"neither":  {ExerciseName: "Plank"},
+		"both":     {ExerciseName: "Plank", Reps: ptr(10), DurationSeconds: ptr(60)},
+		"reps":     {ExerciseName: "Squat", Reps: ptr(10)},
+		"duration": {ExerciseName: "Plank", DurationSeconds: ptr(60)},
+		// entries logged set by set need no value of their own
+		"sets": {ExerciseName: "Row", Sets: []models.WorkoutSet{{DistanceMeters: ptr(500.0)}}},
+		"sets and both": {
+			ExerciseName:    "Row",
+			Reps:            ptr(10),
+			DurationSeconds: ptr(60),
+			Sets:            []models.WorkoutSet{{DistanceMeters: ptr(500.0)}},
+		},
+		"empty set": {ExerciseName: "Row", Sets: []models.WorkoutSet{{Weight: ptr(20.0)}}},
+	} {
+		workout := &models.Workout{
+			UserID:     user.ID,
+			Title:      name,
+			Visibility: models.VisibilityPrivate,
+			Status:     models.StatusCompleted,
+			Entries:    []models.WorkoutEntry{entry},
+		}
+		err := s.Workouts.CreateWorkout(ctx, workout)
+		if valid[name] && err != nil {
+			t.Errorf("CreateWorkout with %s = %v", name, err)
+		}
+		if !valid[name] && err == nil {
+			t.Errorf("CreateWorkout with %s succeeded", name)
+		}
+	}
+
+	// rejected workouts leave nothing behind
+	workouts, _, err := s.Workouts.ListWorkouts(ctx, store.WorkoutFilter{UserID: user.ID, Sort: store.WorkoutSortNewest, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListWorkouts: %v", err)
+	}
+	if len(workouts) != len(valid) {
+		t.Errorf("ListWorkouts returned %d workouts, want the %d valid ones", len(workouts), len(valid))
+	}
+}
+
+// testConcurrentWorkouts creates and reads workouts of several users at the
+// same time. Run it with -race to catch unsynchronized access in a store.
+func testConcurrentWorkouts(t *testing.T, s Stores) {
+	for i := range 8 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			user := newUser(t, s)
+
+			for range 3 {
+				workout := newWorkout(t, s, user.ID, nil)
+				got, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, user.ID)
+				if err != nil {
+					t.Fatalf("GetWorkoutByID: %v", err)
+				}
+				if got.UserID != user.ID || len(got.Entries) != 1 || len(got.Entries[0].Sets) != 3 {
+					t.Errorf("GetWorkoutByID = %+v, want the workout just created", got)
+				}
+			}
+
+			workouts, _, err := s.Workouts.ListWorkouts(ctx, store.WorkoutFilter{UserID: user.ID, Sort: store.WorkoutSortNewest, Limit: 10})
+			if err != nil {
+				t.Fatalf("ListWorkouts: %v", err)
+			}
+			if len(workouts) != 3 {
+				t.Errorf("ListWorkouts returned %d workouts, want 3", len(workouts))
+			}
+		})
+	}
+}
+
+func testUpdateWorkout(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	workout := newWorkout(t, s, user.ID, nil)
+
+	workout.Title = "Leg day"
+	workout.DurationMinutes = 75
+	workout.Entries = []models.WorkoutEntry{
+		{ExerciseName: "Squat", Reps: ptr(5), Weight: ptr(100.0), OrderIndex: 1},
+		{ExerciseName: "Lunge", Reps: ptr(10), OrderIndex: 0},
+	}
+	if err := s.Workouts.UpdateWorkoutByID(ctx, workout); err != nil {
+		t.Fatalf("UpdateWorkoutByID: %v", err)
+	}
+
+	got, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, user.ID)
+	if err != nil {
+		t.Fatalf("GetWorkoutByID: %v", err)
+	}
+	if got.Title != "Leg day" || got.DurationMinutes != 75 {
+		t.Errorf("updated workout = %+v", got)
+	}
+	if len(got.Entries) != 2 || got.Entries[0].ExerciseName != "Lunge" || got.Entries[1].ExerciseName != "Squat" {
+		t.Errorf("updated entries = %+v, want Lunge then Squat", got.Entries)
+	}
+
+	missing := *workout
+	missing.ID = -1
+	if err := s.Workouts.UpdateWorkoutByID(ctx, &missing); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("UpdateWorkoutByID of a missing workout = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testDeleteWorkout(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	workout := newWorkout(t, s, user.ID, nil)
+
+	if err := s.Workouts.DeleteWorkoutByID(ctx, workout.ID); err != nil {
+		t.Fatalf("DeleteWorkoutByID: %v", err)
+	}
+	if _, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetWorkoutByID of a deleted workout = %v, want sql.ErrNoRows", err)
+	}
+	if err := s.Workouts.DeleteWorkoutByID(ctx, workout.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleting a workout twice = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testWorkoutVisibility(t *testing.T, s Stores) {
+	ctx := context.Background()
+	owner := newUser(t, s)
+	viewer := newUser(t, s)
+
+	for _, visibility := range []string{
+		models.VisibilityPrivate,
+		models.VisibilityFollowers,
+		models.VisibilityPublic,
+		models.VisibilityUnlisted,
+	} {
+		workout := newWorkout(t, s, owner.ID, func(w *models.Workout) {
+			w.Visibility = visibility
+		})
+
+		if _, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, owner.ID); err != nil {
+			t.Errorf("owner can't see their %s workout: %v", visibility, err)
+		}
+
+		got, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, viewer.ID)
+		ownerID, ownerErr := s.Workouts.GetVisibleWorkoutOwner(ctx, workout.ID, viewer.ID)
+		if visibility == models.VisibilityPublic {
+			if err != nil || ownerErr != nil || ownerID != owner.ID {
+				t.Errorf("public workout: GetWorkoutByID = %v, GetVisibleWorkoutOwner = %d, %v", err, ownerID, ownerErr)
+			}
+			if got != nil && got.ShareToken != "" {
+				t.Error("a public workout has a share token")
+			}
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) || !errors.Is(ownerErr, sql.ErrNoRows) {
+			t.Errorf("%s workout seen by a stranger: GetWorkoutByID = %v, GetVisibleWorkoutOwner = %v", visibility, err, ownerErr)
+		}
+	}
+
+	if _, err := s.Workouts.GetVisibleWorkoutOwner(ctx, -1, owner.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetVisibleWorkoutOwner of a missing workout = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testShareToken(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	workout := newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.Visibility = models.VisibilityUnlisted
+	})
+	if workout.ShareToken == "" {
+		t.Fatal("an unlisted workout has no share token")
+	}
+
+	got, err := s.Workouts.GetWorkoutByShareToken(ctx, workout.ShareToken)
+	if err != nil || got.ID != workout.ID {
+		t.Fatalf("GetWorkoutByShareToken = %v, %v; want workout %d", got, err, workout.ID)
+	}
+	if _, err := s.Workouts.GetWorkoutByShareToken(ctx, workout.ShareToken+"x"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetWorkoutByShareToken of an unknown token = %v, want sql.ErrNoRows", err)
+	}
+
+	// the token is kept across updates and dropped when the workout stops
+	// being unlisted
+	token := workout.ShareToken
+	workout.Title = "renamed"
+	if err := s.Workouts.UpdateWorkoutByID(ctx, workout); err != nil {
+		t.Fatalf("UpdateWorkoutByID: %v", err)
+	}
+	if workout.ShareToken != token {
+		t.Error("updating an unlisted workout changed its share token")
+	}
+	workout.Visibility = models.VisibilityPrivate
+	if err := s.Workouts.UpdateWorkoutByID(ctx, workout); err != nil {
+		t.Fatalf("UpdateWorkoutByID: %v", err)
+	}
+	if _, err := s.Workouts.GetWorkoutByShareToken(ctx, token); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("share token of a private workout = %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testCoachKeepsShareToken updates an unlisted workout the way a coach
+// does: from a copy without the share token, which only the owner sees.
+func testCoachKeepsShareToken(t *testing.T, s Stores) {
+	ctx := context.Background()
+	athlete := newUser(t, s)
+	coach := newUser(t, s)
+
+	link := &models.CoachLink{CoachID: coach.ID, AthleteID: athlete.ID}
+	if err := s.Coaches.CreateInvitation(ctx, link); err != nil {
+		t.Fatalf("CreateInvitation: %v", err)
+	}
+	if _, err := s.Coaches.AcceptInvitation(ctx, link.ID, athlete.ID); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+
+	workout := newWorkout(t, s, athlete.ID, func(w *models.Workout) {
+		w.Visibility = models.VisibilityUnlisted
+	})
+	token := workout.ShareToken
+
+	edited, err := s.Workouts.GetWorkoutByID(ctx, workout.ID, coach.ID)
+	if err != nil {
+		t.Fatalf("GetWorkoutByID as coach: %v", err)
+	}
+	if edited.ShareToken != "" {
+		t.Fatal("the coach sees the athlete's share token")
+	}
+	edited.Title = "coached"
+	if err := s.Workouts.UpdateWorkoutByID(ctx, edited); err != nil {
+		t.Fatalf("UpdateWorkoutByID as coach: %v", err)
+	}
+
+	got, err := s.Workouts.GetWorkoutByShareToken(ctx, token)
+	if err != nil {
+		t.Errorf("GetWorkoutByShareToken after the coach's edit = %v, want the workout", err)
+	}
+	got, err = s.Workouts.GetWorkoutByID(ctx, workout.ID, athlete.ID)
+	if err != nil {
+		t.Fatalf("GetWorkoutByID as athlete: %v", err)
+	}
+	if got.ShareToken != token {
+		t.Errorf("share token after the coach's edit = %q, want %q", got.ShareToken, token)
+	}
+}
+
+func testListWorkouts(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+	other := newUser(t, s)
+	for _, minutes := range []int{30, 90, 60} {
+		newWorkout(t, s, user.ID, func(w *models.Workout) {
+			w.DurationMinutes = minutes
+		})
+	}
+	newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.DurationMinutes = 45
+		w.Status = models.StatusPlanned
+		w.Entries = nil
+	})
+	newWorkout(t, s, other.ID, nil)
+
+	filter := store.WorkoutFilter{UserID: user.ID, Sort: store.WorkoutSortLongest, Limit: 3}
+	first, cursor, err := s.Workouts.ListWorkouts(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListWorkouts: %v", err)
+	}
+	if got := durations(first); !equal(got, []int{90, 60, 45}) || cursor == "" {
+		t.Fatalf("first page = %v with cursor %q, want [90 60 45] and a cursor", got, cursor)
+	}
+
+	filter.Cursor = cursor
+	second, cursor, err := s.Workouts.ListWorkouts(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListWorkouts: %v", err)
+	}
+	if got := durations(second); !equal(got, []int{30}) || cursor != "" {
+		t.Errorf("second page = %v with cursor %q, want [30] and no cursor", got, cursor)
+	}
+
+	planned, _, err := s.Workouts.ListWorkouts(ctx, store.WorkoutFilter{
+		UserID: user.ID, Sort: store.WorkoutSortNewest, Status: models.StatusPlanned, Limit: 10,
+	})
+	if err != nil {
+		t.Fatalf("ListWorkouts: %v", err)
+	}
+	if got := durations(planned); !equal(got, []int{45}) {
+		t.Errorf("planned workouts = %v, want [45]", got)
+	}
+
+	filter.Cursor = "not a cursor"
+	if _, _, err := s.Workouts.ListWorkouts(ctx, filter); !errors.Is(err, store.ErrInvalidCursor) {
+		t.Errorf("ListWorkouts with a bad cursor = %v, want ErrInvalidCursor", err)
+	}
+	filter.Cursor = ""
+	filter.Sort = "shortest"
+	if _, _, err := s.Workouts.ListWorkouts(ctx, filter); err == nil {
+		t.Error("ListWorkouts with an unknown sort succeeded")
+	}
+}
+
+func testPersonalRecords(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	first := newWorkout(t, s, user.ID, nil)
+	if !hasRecord(first.NewRecords, records.TypeMaxWeight, 80) {
+		t.Errorf("first workout records = %+v, want a max weight of 80", first.NewRecords)
+	}
+
+	same := newWorkout(t, s, user.ID, nil)
+	if hasRecord(same.NewRecords, records.TypeMaxWeight, 80) {
+		t.Errorf("repeating a workout set a max weight record again: %+v", same.NewRecords)
+	}
+
+	planned := newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.Status = models.StatusPlanned
+		w.Entries[0].Sets[0].Weight = ptr(200.0)
+	})
+	if len(planned.NewRecords) != 0 {
+		t.Errorf("a planned workout set records: %+v", planned.NewRecords)
+	}
+
+	heavier := newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.Entries[0].Sets[0].Weight = ptr(90.0)
+	})
+	var record *models.PersonalRecord
+	for i := range heavier.NewRecords {
+		if heavier.NewRecords[i].RecordType == records.TypeMaxWeight {
+			record = &heavier.NewRecords[i]
+		}
+	}
+	if record == nil || record.Value != 90 || record.PreviousValue == nil || *record.PreviousValue != 80 {
+		t.Errorf("heavier workout max weight record = %+v, want 90 up from 80", record)
+	}
+
+	// deleting the workout takes its records with it
+	if err := s.Workouts.DeleteWorkoutByID(ctx, heavier.ID); err != nil {
+		t.Fatalf("DeleteWorkoutByID: %v", err)
+	}
+	again := newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.Entries[0].Sets[0].Weight = ptr(90.0)
+	})
+	if !hasRecord(again.NewRecords, records.TypeMaxWeight, 90) {
+		t.Errorf("records after deleting the record workout = %+v, want a max weight of 90", again.NewRecords)
+	}
+}
+
+// testEditPastRecord edits the weight of a workout logged before another and
+// checks that the records of both follow the order they were logged in.
+func testEditPastRecord(t *testing.T, s Stores) {
+	ctx := context.Background()
+	user := newUser(t, s)
+
+	past := newWorkout(t, s, user.ID, nil)
+	later := newWorkout(t, s, user.ID, func(w *models.Workout) {
+		w.Entries[0].Sets[0].Weight = ptr(90.0)
+	})
+
+	maxWeights := func() []models.PersonalRecord {
+		t.Helper()
+		history, err := s.Records.GetRecordHistory(ctx, user.ID, "bench press", nil)
+		if err != nil {
+			t.Fatalf("GetRecordHistory: %v", err)
+		}
+		found := []models.PersonalRecord{}
+		for _, record := range history {
+			if record.RecordType == records.TypeMaxWeight {
+				found = append(found, record)
+			}
+		}
+		return found
+	}
+	edit := func(weight float64) *models.Workout {
+		t.Helper()
+		changed := *past
+		changed.Entries = []models.WorkoutEntry{past.Entries[0]}
+		changed.Entries[0].Sets = []models.WorkoutSet{{Reps: ptr(5), Weight: ptr(weight)}}
+		if err := s.Workouts.UpdateWorkoutByID(ctx, &changed); err != nil {
+			t.Fatalf("UpdateWorkoutByID: %v", err)
+		}
+		return &changed
+	}
+
+	// heavier than the later workout, which no longer set a record
+	heavier := edit(100)
+	if !hasRecord(heavier.NewRecords, records.TypeMaxWeight, 100) {
+		t.Errorf("records of the past workout edited to 100 = %+v, want a max weight of 100", heavier.NewRecords)
+	}
+	got := maxWeights()
+	if len(got) != 1 || got[0].WorkoutID != past.ID || got[0].Value != 100 {
+		t.Errorf("max weight records after editing the past workout to 100 = %+v, want only 100 by workout %d", got, past.ID)
+	}
+
+	// lighter again, so the later workout takes its record back
+	lighter := edit(70)
+	for _, record := range lighter.NewRecords {
+		if record.RecordType == records.TypeMaxWeight && (record.Value != 70 || record.PreviousValue != nil) {
+			t.Errorf("max weight record of the past workout edited to 70 = %+v, want a first record of 70", record)
+		}
+	}
+	if !hasRecord(lighter.NewRecords, records.TypeMaxWeight, 70) {
+		t.Errorf("records of the past workout edited to 70 = %+v, want a max weight of 70", lighter.NewRecords)
+	}
+	got = maxWeights()
+	if len(got) != 2 || got[0].WorkoutID != past.ID || got[0].Value != 70 ||
+		got[1].WorkoutID != later.ID || got[1].Value != 90 {
+		t.Errorf("max weight records after editing the past workout to 70 = %+v, want 70 by workout %d, then 90 by workout %d", got, past.ID, later.ID)
+	}
+}
+
+func hasRecord(records []models.PersonalRecord, recordType string, value float64) bool {
+	for _, r := range records {
+		if r.RecordType == recordType && r.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func durations(workouts []*models.Workout) []int {
+	minutes := []int{}
+	for _, w := range workouts {
+		minutes = append(minutes, w.DurationMinutes)
+	}
+	return minutes
+}
+
+func equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
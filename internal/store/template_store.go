@@ -0,0 +1,316 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/agkmw/workout-service/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrTemplateInUse is returned when deleting a template that a program
+// still schedules.
+var ErrTemplateInUse = errors.New("template is used by a program")
+
+const pgForeignKeyViolation = "23503"
+
+type PostgresTemplateStore struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+	// organizationID is the tenant every query is restricted to, nil being
+	// the personal space.
+	organizationID *int64
+}
+
+func NewPostgresTemplateStore(db *sql.DB, queryTimeout time.Duration) *PostgresTemplateStore {
+	return &PostgresTemplateStore{
+		db:           db,
+		queryTimeout: queryTimeout,
+	}
+}
+
+// ForOrganization returns a store restricted to the organization's
+// templates, or to personal templates when organizationID is nil.
+func (pg *PostgresTemplateStore) ForOrganization(organizationID *int64) TemplateStore {
+	return &PostgresTemplateStore{
+		db:             pg.db,
+		queryTimeout:   pg.queryTimeout,
+		organizationID: organizationID,
+	}
+}
+
+func (pg *PostgresTemplateStore) CreateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO workout_templates (user_id, organization_id, name, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	template.OrganizationID = pg.organizationID
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		template.UserID,
+		template.OrganizationID,
+		template.Name,
+		template.Description,
+	).Scan(
+		&template.ID,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	if err := insertTemplateEntries(ctx, tx, template); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetTemplateByID returns the template if it belongs to userID and
+// sql.ErrNoRows otherwise. Templates are always private.
+func (pg *PostgresTemplateStore) GetTemplateByID(ctx context.Context, id, userID int64) (*models.WorkoutTemplate, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT t.id, t.user_id, t.organization_id, t.name, t.description, t.created_at, t.updated_at
+		FROM workout_templates t
+		WHERE t.id = $1 AND t.user_id = $2 AND ` + inOrganization("t", "$3") + `
+	`
+	template := &models.WorkoutTemplate{}
+	if err := pg.db.QueryRowContext(ctx, query, id, userID, pg.organizationID).Scan(
+		&template.ID,
+		&template.UserID,
+		&template.OrganizationID,
+		&template.Name,
+		&template.Description,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := pg.loadTemplateEntries(ctx, []*models.WorkoutTemplate{template}); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (pg *PostgresTemplateStore) ListTemplates(ctx context.Context, userID int64) ([]*models.WorkoutTemplate, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT t.id, t.user_id, t.organization_id, t.name, t.description, t.created_at, t.updated_at
+		FROM workout_templates t
+		WHERE t.user_id = $1 AND ` + inOrganization("t", "$2") + `
+		ORDER BY t.name, t.id
+	`
+	rows, err := pg.db.QueryContext(ctx, query, userID, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []*models.WorkoutTemplate{}
+	for rows.Next() {
+		template := &models.WorkoutTemplate{}
+		if err := rows.Scan(
+			&template.ID,
+			&template.UserID,
+			&template.OrganizationID,
+			&template.Name,
+			&template.Description,
+			&template.CreatedAt,
+			&template.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := pg.loadTemplateEntries(ctx, templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (pg *PostgresTemplateStore) UpdateTemplate(ctx context.Context, template *models.WorkoutTemplate) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE workout_templates
+		SET name = $1, description = $2, updated_at = now()
+		WHERE id = $3 AND user_id = $4 AND organization_id IS NOT DISTINCT FROM $5
+		RETURNING updated_at
+	`
+	if err := tx.QueryRowContext(
+		ctx,
+		query,
+		template.Name,
+		template.Description,
+		template.ID,
+		template.UserID,
+		pg.organizationID,
+	).Scan(&template.UpdatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM workout_template_entries WHERE template_id = $1`, template.ID); err != nil {
+		return err
+	}
+	if err := insertTemplateEntries(ctx, tx, template); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (pg *PostgresTemplateStore) DeleteTemplateByID(ctx context.Context, id, userID int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(
+		ctx,
+		`DELETE FROM workout_templates t WHERE t.id = $1 AND t.user_id = $2 AND `+inOrganization("t", "$3"),
+		id,
+		userID,
+		pg.organizationID,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+			return ErrTemplateInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func insertTemplateEntries(ctx context.Context, tx *sql.Tx, template *models.WorkoutTemplate) error {
+	query := `
+		INSERT INTO workout_template_entries
+		(
+			template_id, exercise_id, exercise_name, target_sets, target_reps,
+			target_weight, target_duration_seconds, rest_seconds, weight_increment,
+			notes, order_index
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at, updated_at
+	`
+	for i := range template.Entries {
+		entry := &template.Entries[i]
+		entry.TemplateID = template.ID
+		if err := tx.QueryRowContext(
+			ctx,
+			query,
+			entry.TemplateID,
+			entry.ExerciseID,
+			entry.ExerciseName,
+			entry.TargetSets,
+			entry.TargetReps,
+			entry.TargetWeight,
+			entry.TargetDurationSeconds,
+			entry.RestSeconds,
+			entry.WeightIncrement,
+			entry.Notes,
+			entry.OrderIndex,
+		).Scan(
+			&entry.ID,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTemplateEntries fetches the entries of all given templates in a single
+// query.
+func (pg *PostgresTemplateStore) loadTemplateEntries(ctx context.Context, templates []*models.WorkoutTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(templates))
+	byID := make(map[int64]*models.WorkoutTemplate, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.ID)
+		byID[t.ID] = t
+	}
+
+	query := `
+		SELECT
+			id, template_id, exercise_id, exercise_name, target_sets, target_reps,
+			target_weight, target_duration_seconds, rest_seconds, weight_increment,
+			notes, order_index, created_at, updated_at
+		FROM workout_template_entries
+		WHERE template_id = ANY($1)
+		ORDER BY template_id, order_index
+	`
+	rows, err := pg.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := models.WorkoutTemplateEntry{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.TemplateID,
+			&e.ExerciseID,
+			&e.ExerciseName,
+			&e.TargetSets,
+			&e.TargetReps,
+			&e.TargetWeight,
+			&e.TargetDurationSeconds,
+			&e.RestSeconds,
+			&e.WeightIncrement,
+			&e.Notes,
+			&e.OrderIndex,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		template := byID[e.TemplateID]
+		template.Entries = append(template.Entries, e)
+	}
+
+	return rows.Err()
+}
@@ -1,55 +1,292 @@
 package store
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/agkmw/workout-service/internal/tokens"
 )
 
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again. Its whole family has been revoked by then.
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 type PostgresTokenStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
+	ttls         tokens.TTLs
 }
 
-func NewPostgresTokenStore(db *sql.DB) *PostgresTokenStore {
+func NewPostgresTokenStore(db *sql.DB, queryTimeout time.Duration, ttls tokens.TTLs) *PostgresTokenStore {
 	return &PostgresTokenStore{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
+		ttls:         ttls,
 	}
 }
 
-func (t *PostgresTokenStore) CreateNewToken(userID int64, ttl time.Duration, scope string) (*tokens.Token, error) {
-	token, err := tokens.GenerateToken(userID, ttl, scope)
+func (t *PostgresTokenStore) CreateNewToken(ctx context.Context, userID int64, scope string) (*tokens.Token, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	token, err := tokens.GenerateToken(userID, t.ttls.For(scope), scope)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := t.Insert(token); err != nil {
+	if err := t.Insert(ctx, token); err != nil {
 		return nil, err
 	}
 
 	return token, nil
 }
 
-func (t *PostgresTokenStore) Insert(token *tokens.Token) error {
+func (t *PostgresTokenStore) Insert(ctx context.Context, token *tokens.Token) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	return insertToken(ctx, t.db, token)
+}
+
+func insertToken(ctx context.Context, db execer, token *tokens.Token) error {
 	query := `
-		INSERT INTO tokens (hash, user_id, expiry, scope)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO tokens (hash, user_id, expiry, scope, user_agent, ip, family_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+	`
+	_, err := db.ExecContext(
+		ctx,
+		query,
+		token.Hash,
+		token.UserID,
+		token.Expiry,
+		token.Scope,
+		token.UserAgent,
+		token.IP,
+		token.FamilyID,
+	)
+	return err
+}
+
+// CreateTokenPair starts a new token family for a login.
+func (t *PostgresTokenStore) CreateTokenPair(ctx context.Context, userID int64, userAgent, ip string) (*tokens.Pair, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	familyID, err := tokens.NewFamilyID()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pair, err := t.insertPair(ctx, tx, userID, familyID, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+// RotateRefreshToken trades a refresh token for a new pair in the same
+// family. Every refresh token works once: presenting a used one revokes the
+// family and returns ErrRefreshTokenReused, since either the client or an
+// attacker is holding a stolen copy. Unknown or expired tokens give
+// sql.ErrNoRows.
+func (t *PostgresTokenStore) RotateRefreshToken(ctx context.Context, plaintextToken, userAgent, ip string) (*tokens.Pair, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	hash := tokens.Hash(plaintextToken)
+	now := time.Now()
+
+	var (
+		userID   int64
+		familyID sql.NullString
+		usedAt   sql.NullTime
+	)
+	lookup := `
+		SELECT user_id, family_id, used_at
+		FROM tokens
+		WHERE hash = $1 AND scope = $2 AND expiry > $3
+		FOR UPDATE
 	`
-	_, err := t.db.Exec(query, token.Hash, token.UserID, token.Expiry, token.Scope)
+	if err := tx.QueryRowContext(ctx, lookup, hash, tokens.ScopeRefresh, now).Scan(&userID, &familyID, &usedAt); err != nil {
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM tokens WHERE family_id = $1`, familyID.String); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE tokens SET used_at = $2, last_used_at = $2 WHERE hash = $1`, hash, now); err != nil {
+		return nil, err
+	}
+
+	pair, err := t.insertPair(ctx, tx, userID, familyID.String, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, tx.Commit()
+}
+
+func (t *PostgresTokenStore) insertPair(ctx context.Context, tx *sql.Tx, userID int64, familyID, userAgent, ip string) (*tokens.Pair, error) {
+	pair, err := tokens.GeneratePair(userID, familyID, t.ttls)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, token := range []*tokens.Token{pair.Access, pair.Refresh} {
+		token.UserAgent = userAgent
+		token.IP = ip
+		if err := insertToken(ctx, tx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return pair, nil
+}
+
+func (t *PostgresTokenStore) DeleteAllTokensForUser(ctx context.Context, userID int64, scope string) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		DELETE FROM tokens
+		WHERE scope = $1 AND user_id = $2
+	`
+	_, err := t.db.ExecContext(ctx, query, scope, userID)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (t *PostgresTokenStore) DeleteAllTokensForUser(userID int64, scope string) error {
+// DeleteToken revokes a token together with the rest of its family, so
+// logging out also kills the refresh token. It returns sql.ErrNoRows if the
+// token doesn't exist.
+func (t *PostgresTokenStore) DeleteToken(ctx context.Context, scope, plaintextToken string) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
 	query := `
 		DELETE FROM tokens
-		WHERE scope = $1 AND user_id = $2
+		WHERE (scope = $1 AND hash = $2)
+		OR family_id = (SELECT family_id FROM tokens WHERE scope = $1 AND hash = $2)
 	`
-	_, err := t.db.Exec(query, scope, userID)
+	result, err := t.db.ExecContext(ctx, query, scope, tokens.Hash(plaintextToken))
 	if err != nil {
 		return err
 	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
+
+// ListSessions returns the user's active logins, most recently used first.
+// A login is a token family, so the access and refresh tokens rotated within
+// it show up as one session; tokens issued before refresh tokens existed
+// form a session of their own. The session holding currentToken is marked
+// current.
+func (t *PostgresTokenStore) ListSessions(ctx context.Context, userID int64, currentToken string) ([]tokens.Session, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			MIN(id),
+			MIN(created_at),
+			MAX(last_used_at),
+			MAX(expiry),
+			(array_agg(user_agent ORDER BY id DESC))[1],
+			(array_agg(ip ORDER BY id DESC))[1],
+			bool_or(hash = $2)
+		FROM tokens
+		WHERE user_id = $1 AND expiry > $3 AND used_at IS NULL
+		GROUP BY COALESCE(family_id, encode(hash, 'hex'))
+		ORDER BY COALESCE(MAX(last_used_at), MIN(created_at)) DESC, MIN(id) DESC
+	`
+	rows, err := t.db.QueryContext(ctx, query, userID, tokens.Hash(currentToken), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []tokens.Session{}
+	for rows.Next() {
+		session := tokens.Session{}
+		if err := rows.Scan(
+			&session.ID,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.Expiry,
+			&session.UserAgent,
+			&session.IP,
+			&session.Current,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchToken records that the token was just used. To keep authenticated
+// requests from writing on every call, last_used_at only moves once it is
+// older than interval.
+func (t *PostgresTokenStore) TouchToken(ctx context.Context, plaintextToken string, interval time.Duration) error {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE tokens
+		SET last_used_at = $2
+		WHERE hash = $1 AND (last_used_at IS NULL OR last_used_at < $3)
+	`
+	now := time.Now()
+	_, err := t.db.ExecContext(ctx, query, tokens.Hash(plaintextToken), now, now.Add(-interval))
+	return err
+}
+
+// DeleteExpiredTokens removes tokens of every scope whose expiry has passed
+// and returns how many there were.
+func (t *PostgresTokenStore) DeleteExpiredTokens(ctx context.Context) (int64, error) {
+	ctx, cancel := WithTimeout(ctx, t.queryTimeout)
+	defer cancel()
+
+	result, err := t.db.ExecContext(ctx, `DELETE FROM tokens WHERE expiry < $1`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
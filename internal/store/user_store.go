@@ -1,65 +1,92 @@
 package store
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/agkmw/workout-service/internal/models"
+	"github.com/agkmw/workout-service/internal/tokens"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+var (
+	ErrDuplicateUsername = errors.New("username is already taken")
+	ErrDuplicateEmail    = errors.New("email is already in use")
+)
+
+const pgUniqueViolation = "23505"
+
 type PostgresUserStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func NewPostgresUserStore(db *sql.DB) *PostgresUserStore {
+func NewPostgresUserStore(db *sql.DB, queryTimeout time.Duration) *PostgresUserStore {
 	return &PostgresUserStore{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
 	}
 }
 
-func (pg *PostgresUserStore) CreateUser(user *models.User) error {
+func (pg *PostgresUserStore) CreateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
 	query := `
 		INSERT INTO users 
-		(username, email, password_hash, bio)
+		(username, email, password_hash, bio, timezone)
 		VALUES 
-		($1, $2, $3, $4)
-		RETURNING id, created_at, updated_at
+		($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'UTC'))
+		RETURNING id, timezone, activated, role, disabled, created_at, updated_at
 	`
-	if err := pg.db.QueryRow(
+	if err := pg.db.QueryRowContext(
+		ctx,
 		query,
 		user.Username,
 		user.Email,
 		user.PasswordHash.Hash,
 		user.Bio,
+		user.Timezone,
 	).Scan(
 		&user.ID,
+		&user.Timezone,
+		&user.Activated,
+		&user.Role,
+		&user.Disabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	); err != nil {
-		return err
+		return uniqueUserError(err)
 	}
 
 	return nil
 }
 
-func (pg *PostgresUserStore) GetUserByUsername(username string) (*models.User, error) {
+// userColumns is the select list scanUser expects, with the users table
+// aliased as u.
+const userColumns = `
+	u.id, u.username, u.email, u.password_hash, u.bio, u.timezone,
+	u.activated, u.role, u.disabled, u.created_at, u.updated_at
+`
+
+func scanUser(row rowScanner) (*models.User, error) {
 	user := &models.User{
 		PasswordHash: models.Password{},
 	}
-	query := `
-		SELECT
-			id, username, email, password_hash, 
-			bio, created_at, updated_at
-		FROM users
-		WHERE username = $1
-	`
-	if err := pg.db.QueryRow(query, username).Scan(
+	if err := row.Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash.Hash,
 		&user.Bio,
+		&user.Timezone,
+		&user.Activated,
+		&user.Role,
+		&user.Disabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	); err != nil {
@@ -68,97 +95,369 @@ func (pg *PostgresUserStore) GetUserByUsername(username string) (*models.User, e
 	return user, nil
 }
 
-func (pg *PostgresUserStore) SearchUsersByUsername(username string) ([]models.User, error) {
-	users := []models.User{}
+func (pg *PostgresUserStore) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE u.id = $1
+	`
+	return scanUser(pg.db.QueryRowContext(ctx, query, id))
+}
+
+func (pg *PostgresUserStore) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
 
 	query := `
-		SELECT
-			id, username, email, password_hash, 
-			bio, created_at, updated_at
-		FROM users
-		WHERE username ILIKE $1
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE u.username = $1
 	`
-	rows, err := pg.db.Query(query, username)
+	return scanUser(pg.db.QueryRowContext(ctx, query, username))
+}
+
+// GetUserByEmail matches the address case-insensitively.
+func (pg *PostgresUserStore) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		WHERE lower(u.email) = lower($1)
+	`
+	return scanUser(pg.db.QueryRowContext(ctx, query, email))
+}
+
+// ListUsers returns every account, oldest first, for the admin API.
+func (pg *PostgresUserStore) ListUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		ORDER BY u.id
+		LIMIT $1 OFFSET $2
+	`
+	rows, err := pg.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	users := []*models.User{}
 	for rows.Next() {
-		user := models.User{
-			PasswordHash: models.Password{},
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
 		}
+		users = append(users, user)
+	}
 
-		err = rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.Email,
-			&user.PasswordHash.Hash,
-			&user.Bio,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
+	return users, rows.Err()
+}
+
+// publicProfileColumns is the select list scanPublicProfile expects, with
+// the users table aliased as u. Only completed public workouts of the
+// personal space are counted; profiles are shared by every organization.
+const publicProfileColumns = `
+	u.id, u.username, COALESCE(u.bio, ''), u.created_at,
+	(
+		SELECT COUNT(*) FROM workouts w
+		WHERE w.user_id = u.id AND w.visibility = 'public' AND w.status = 'completed'
+		AND w.organization_id IS NULL
+	),
+	(SELECT COUNT(*) FROM follows WHERE followee_id = u.id),
+	(SELECT COUNT(*) FROM follows WHERE follower_id = u.id)
+`
+
+func scanPublicProfile(row rowScanner) (models.PublicProfile, error) {
+	profile := models.PublicProfile{}
+	err := row.Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.Bio,
+		&profile.JoinedAt,
+		&profile.PublicWorkoutCount,
+		&profile.FollowerCount,
+		&profile.FollowingCount,
+	)
+	return profile, err
+}
+
+// SearchUsersByUsername finds users whose username starts with query or is
+// similar to it. Prefix matches come first, the rest are ranked by trigram
+// similarity.
+func (pg *PostgresUserStore) SearchUsersByUsername(ctx context.Context, query string, limit, offset int) ([]models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	search := `
+		SELECT ` + publicProfileColumns + `
+		FROM users u
+		WHERE lower(u.username) LIKE $2 || '%' OR lower(u.username) % $1
+		ORDER BY
+			lower(u.username) LIKE $2 || '%' DESC,
+			similarity(lower(u.username), $1) DESC,
+			u.username
+		LIMIT $3 OFFSET $4
+	`
+	q := strings.ToLower(query)
+	rows, err := pg.db.QueryContext(ctx, search, q, escapeLike(q), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.PublicProfile{}
+	for rows.Next() {
+		profile, err := scanPublicProfile(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		users = append(users, user)
+		profiles = append(profiles, profile)
 	}
 
-	return users, nil
+	return profiles, rows.Err()
 }
 
-func (pg *PostgresUserStore) UpdateUser(user *models.User) error {
+func (pg *PostgresUserStore) GetPublicProfile(ctx context.Context, username string) (*models.PublicProfile, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + publicProfileColumns + `
+		FROM users u
+		WHERE u.username = $1
+	`
+	profile, err := scanPublicProfile(pg.db.QueryRowContext(ctx, query, username))
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (pg *PostgresUserStore) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
 	query := `
 		UPDATE users 
 		SET
-			username = $1, email = $2, bio = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4
+			username = $1, email = $2, bio = $3, timezone = $4, activated = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
 		RETURNING updated_at
 	`
-	err := pg.db.QueryRow(
+	err := pg.db.QueryRowContext(
+		ctx,
 		query,
 		user.Username,
 		user.Email,
 		user.Bio,
+		user.Timezone,
+		user.Activated,
 		user.ID,
 	).Scan(
 		&user.UpdatedAt,
 	)
+	if err != nil {
+		return uniqueUserError(err)
+	}
+
+	return nil
+}
+
+// DeleteUser removes the account. Tokens, workouts, templates and everything
+// else the user owns go with it through ON DELETE CASCADE.
+func (pg *PostgresUserStore) DeleteUser(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
 
-func (pg *PostgresUserStore) GetUserByToken(scope, plaintextToken string) (*models.User, error) {
-	tokenHash := sha256.Sum256([]byte(plaintextToken))
+// uniqueUserError maps violations of the unique username and email
+// constraints to ErrDuplicateUsername and ErrDuplicateEmail.
+func uniqueUserError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgUniqueViolation {
+		return err
+	}
 
-	user := &models.User{
-		PasswordHash: models.Password{},
+	switch pgErr.ConstraintName {
+	case "users_username_key":
+		return ErrDuplicateUsername
+	case "users_email_key", "users_email_lower_key":
+		return ErrDuplicateEmail
+	}
+	return err
+}
+
+func (pg *PostgresUserStore) UpdatePassword(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`
+	return pg.db.QueryRowContext(ctx, query, user.PasswordHash.Hash, user.ID).Scan(&user.UpdatedAt)
+}
+
+// resetRevokedScopes are the tokens a password reset revokes: every session
+// and any other reset link.
+var resetRevokedScopes = []string{tokens.ScopeAuth, tokens.ScopeRefresh, tokens.ScopePasswordReset}
+
+// ResetPassword consumes the password reset token, stores the user's new
+// password hash and logs out every session, all or nothing. It returns
+// sql.ErrNoRows if the token was already used.
+func (pg *PostgresUserStore) ResetPassword(ctx context.Context, user *models.User, plaintextToken string) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM tokens WHERE scope = $1 AND hash = $2 AND user_id = $3`,
+		tokens.ScopePasswordReset,
+		tokens.Hash(plaintextToken),
+		user.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
 	}
 
 	query := `
-		SELECT u.id, u.username, u.email, u.password_hash, u.bio, u.created_at, u.updated_at
-		FROM users u
-		INNER JOIN tokens t ON t.user_id = u.id
-		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > $3
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
 	`
-	if err := pg.db.QueryRow(query, tokenHash[:], scope, time.Now()).Scan(
-		&user.ID,
-		&user.Username,
-		&user.Email,
-		&user.PasswordHash.Hash,
-		&user.Bio,
-		&user.CreatedAt,
-		&user.UpdatedAt,
+	if err := tx.QueryRowContext(ctx, query, user.PasswordHash.Hash, user.ID).Scan(&user.UpdatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM tokens WHERE user_id = $1 AND scope = ANY($2)`,
+		user.ID,
+		resetRevokedScopes,
 	); err != nil {
-		return nil, err
+		return err
 	}
 
-	return user, nil
+	return tx.Commit()
+}
+
+// ChangePassword stores the user's new password hash and logs out every
+// session but the one currentToken belongs to, together with any reset
+// links, all or nothing.
+func (pg *PostgresUserStore) ChangePassword(ctx context.Context, user *models.User, currentToken string) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`
+	if err := tx.QueryRowContext(ctx, query, user.PasswordHash.Hash, user.ID).Scan(&user.UpdatedAt); err != nil {
+		return err
+	}
+
+	// tokens without a family are sessions of their own
+	revoke := `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND scope = ANY($2) AND hash <> $3
+		AND NOT COALESCE(family_id = (SELECT family_id FROM tokens WHERE hash = $3), FALSE)
+	`
+	if _, err := tx.ExecContext(ctx, revoke, user.ID, resetRevokedScopes, tokens.Hash(currentToken)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateRole changes the user's role. It returns sql.ErrNoRows if the user
+// doesn't exist.
+func (pg *PostgresUserStore) UpdateRole(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET role = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`
+	return pg.db.QueryRowContext(ctx, query, user.Role, user.ID).Scan(&user.UpdatedAt)
+}
+
+// UpdateDisabled enables or disables the account. It returns sql.ErrNoRows
+// if the user doesn't exist.
+func (pg *PostgresUserStore) UpdateDisabled(ctx context.Context, user *models.User) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE users
+		SET disabled = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING updated_at
+	`
+	return pg.db.QueryRowContext(ctx, query, user.Disabled, user.ID).Scan(&user.UpdatedAt)
+}
+
+func (pg *PostgresUserStore) GetUserByToken(ctx context.Context, scope, plaintextToken string) (*models.User, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tokenHash := sha256.Sum256([]byte(plaintextToken))
+
+	query := `
+		SELECT ` + userColumns + `
+		FROM users u
+		INNER JOIN tokens t ON t.user_id = u.id
+		WHERE t.hash = $1 AND t.scope = $2 AND t.expiry > $3
+	`
+	return scanUser(pg.db.QueryRowContext(ctx, query, tokenHash[:], scope, time.Now()))
 }
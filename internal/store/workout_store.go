@@ -1,100 +1,249 @@
 package store
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/agkmw/workout-service/internal/models"
 )
 
+const (
+	WorkoutSortNewest   = "newest"
+	WorkoutSortLongest  = "longest"
+	WorkoutSortCalories = "calories"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// WorkoutFilter narrows down the workouts returned by ListWorkouts. Zero
+// values are ignored, so an empty filter (apart from UserID) lists everything.
+type WorkoutFilter struct {
+	UserID      int64
+	From        *time.Time // inclusive
+	To          *time.Time // exclusive
+	Title       string
+	MinDuration *int
+	MaxDuration *int
+	Exercise    string
+	Status      string
+	Visibility  string
+	Sort        string
+	Cursor      string
+	Limit       int
+}
+
 type PostgresWorkoutStore struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
+	// organizationID is the tenant every query is restricted to. nil is the
+	// personal space of workouts logged outside any organization.
+	organizationID *int64
 }
 
-func NewPostgresWorkoutStore(db *sql.DB) *PostgresWorkoutStore {
+func NewPostgresWorkoutStore(db *sql.DB, queryTimeout time.Duration) *PostgresWorkoutStore {
 	return &PostgresWorkoutStore{
-		db: db,
+		db:           db,
+		queryTimeout: queryTimeout,
 	}
 }
 
-func (pg *PostgresWorkoutStore) GetWorkoutByID(id int64) (*models.Workout, error) {
-	queryWorkout := `
-		SELECT id, title, description, duration_minutes, calories_burned
-		FROM workouts
-		WHERE id = $1
-	`
-	workout := &models.Workout{}
-	err := pg.db.QueryRow(queryWorkout, id).Scan(
-		&workout.ID,
-		&workout.Title,
-		&workout.Description,
-		&workout.DurationMinutes,
-		&workout.CaloriesBurned,
-	)
-	if err == sql.ErrNoRows {
-		return nil, nil
+// ForOrganization returns a store that only reads and writes the workouts of
+// the organization, or the personal space when organizationID is nil.
+func (pg *PostgresWorkoutStore) ForOrganization(organizationID *int64) WorkoutStore {
+	return &PostgresWorkoutStore{
+		db:             pg.db,
+		queryTimeout:   pg.queryTimeout,
+		organizationID: organizationID,
 	}
+}
+
+// inOrganization is the condition restricting the table alias to the
+// organization bound to the placeholder, where NULL means the personal space.
+func inOrganization(alias, placeholder string) string {
+	return alias + ".organization_id IS NOT DISTINCT FROM " + placeholder
+}
+
+// GetWorkoutByID returns the workout only if viewerID is allowed to see it.
+// Workouts hidden from the viewer are reported as sql.ErrNoRows so callers
+// can't tell them apart from workouts that don't exist.
+func (pg *PostgresWorkoutStore) GetWorkoutByID(ctx context.Context, id, viewerID int64) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + workoutColumns + `
+		FROM workouts w
+		WHERE w.id = $1 AND ` + visibleTo("$2") + ` AND ` + inOrganization("w", "$3") + `
+	`
+	workout, err := pg.getWorkout(ctx, query, id, viewerID, pg.organizationID)
 	if err != nil {
 		return nil, err
 	}
 
-	// fetch entries
-	queryEntry := `
-		SELECT 
-			id, workout_id, exercise_name, sets, reps, duration_seconds, 
-			weight, notes, order_index, created_at, updated_at
-		FROM workout_entries
-		WHERE workout_id = $1
-		ORDER BY order_index
+	if workout.UserID != viewerID {
+		workout.ShareToken = ""
+	}
+	return workout, nil
+}
+
+// visibleTo is the condition under which the viewer given by the
+// placeholder may see workout w: they own it, it is public, it is shared
+// with followers and they follow its owner, or they actively coach its owner.
+func visibleTo(viewer string) string {
+	return `(
+		w.user_id = ` + viewer + ` OR w.visibility = 'public' OR (
+			w.visibility = 'followers' AND EXISTS (
+				SELECT 1 FROM follows f
+				WHERE f.follower_id = ` + viewer + ` AND f.followee_id = w.user_id
+			)
+		) OR EXISTS (
+			SELECT 1 FROM coach_links cl
+			WHERE cl.coach_id = ` + viewer + ` AND cl.athlete_id = w.user_id AND cl.status = 'active'
+		)
+	)`
+}
+
+func (pg *PostgresWorkoutStore) GetWorkoutByShareToken(ctx context.Context, token string) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + workoutColumns + `
+		FROM workouts w
+		WHERE w.share_token = $1 AND w.visibility = 'unlisted' AND ` + inOrganization("w", "$2") + `
 	`
-	rows, err := pg.db.Query(queryEntry, workout.ID)
+	return pg.getWorkout(ctx, query, token, pg.organizationID)
+}
+
+func (pg *PostgresWorkoutStore) getWorkout(ctx context.Context, query string, args ...any) (*models.Workout, error) {
+	workout, err := scanWorkout(pg.db.QueryRowContext(ctx, query, args...))
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		e := models.WorkoutEntry{}
-		if err := rows.Scan(
-			&e.ID,
-			&e.WorkoutID,
-			&e.ExerciseName,
-			&e.Sets,
-			&e.Reps,
-			&e.DurationSeconds,
-			&e.Weight,
-			&e.Notes,
-			&e.OrderIndex,
-			&e.CreatedAt,
-			&e.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		workout.Entries = append(workout.Entries, e)
+	if err := pg.loadEntries(ctx, []*models.Workout{workout}); err != nil {
+		return nil, err
 	}
+	return workout, nil
+}
+
+// workoutColumns is the select list scanWorkout expects, with the workouts
+// table aliased as w.
+const workoutColumns = `
+	w.id, w.user_id, w.organization_id, w.template_id, w.enrollment_id, w.program_session_id,
+	w.title, w.description, w.duration_minutes,
+	COALESCE(w.calories_burned, 0), w.visibility, COALESCE(w.share_token, ''),
+	w.status, w.created_at, w.updated_at,
+	(SELECT COUNT(*) FROM workout_comments c WHERE c.workout_id = w.id),
+	(
+		SELECT COALESCE(jsonb_object_agg(kind, n), '{}')::text
+		FROM (
+			SELECT kind, COUNT(*) AS n FROM workout_reactions r
+			WHERE r.workout_id = w.id GROUP BY kind
+		) counts
+	)
+`
 
+func scanWorkout(row rowScanner) (*models.Workout, error) {
+	workout := &models.Workout{}
+	var reactionCounts []byte
+	if err := row.Scan(
+		&workout.ID,
+		&workout.UserID,
+		&workout.OrganizationID,
+		&workout.TemplateID,
+		&workout.EnrollmentID,
+		&workout.ProgramSessionID,
+		&workout.Title,
+		&workout.Description,
+		&workout.DurationMinutes,
+		&workout.CaloriesBurned,
+		&workout.Visibility,
+		&workout.ShareToken,
+		&workout.Status,
+		&workout.CreatedAt,
+		&workout.UpdatedAt,
+		&workout.CommentCount,
+		&reactionCounts,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(reactionCounts, &workout.ReactionCounts); err != nil {
+		return nil, err
+	}
 	return workout, nil
 }
 
-func (pg *PostgresWorkoutStore) CreateWorkout(workout *models.Workout) error {
-	tx, err := pg.db.Begin()
+// GetLastCompletedFromTemplate returns the user's most recent completed
+// workout that was started from the template.
+func (pg *PostgresWorkoutStore) GetLastCompletedFromTemplate(ctx context.Context, userID, templateID int64) (*models.Workout, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT ` + workoutColumns + `
+		FROM workouts w
+		WHERE w.user_id = $1 AND w.template_id = $2 AND w.status = 'completed'
+		AND ` + inOrganization("w", "$3") + `
+		ORDER BY w.created_at DESC
+		LIMIT 1
+	`
+	return pg.getWorkout(ctx, query, userID, templateID, pg.organizationID)
+}
+
+func (pg *PostgresWorkoutStore) CreateWorkout(ctx context.Context, workout *models.Workout) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	workout.OrganizationID = pg.organizationID
+	workout.CommentCount = 0
+	workout.ReactionCounts = map[string]int{}
+
+	// never trust a share token coming from the client
+	workout.ShareToken = ""
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
 	insertWorkout := `
 		INSERT INTO workouts
-		(user_id, title, description, duration_minutes, calories_burned)
-		VALUES ($1, $2, $3, $4, $5)
+		(
+			user_id, organization_id, template_id, enrollment_id,
+			program_session_id, title, description, duration_minutes,
+			calories_burned, visibility, share_token, status
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
 		RETURNING id, created_at, updated_at
 	`
-	if err := tx.QueryRow(
+	if err := tx.QueryRowContext(
+		ctx,
 		insertWorkout,
 		workout.UserID,
+		workout.OrganizationID,
+		workout.TemplateID,
+		workout.EnrollmentID,
+		workout.ProgramSessionID,
 		workout.Title,
 		workout.Description,
 		workout.DurationMinutes,
 		workout.CaloriesBurned,
+		workout.Visibility,
+		workout.ShareToken,
+		workout.Status,
 	).Scan(
 		&workout.ID,
 		&workout.CreatedAt,
@@ -103,34 +252,13 @@ func (pg *PostgresWorkoutStore) CreateWorkout(workout *models.Workout) error {
 		return err
 	}
 
-	// insert entries
-	insertEntry := `
-		INSERT INTO workout_entries
-		(
-			workout_id, exercise_name, sets, reps,
-			duration_seconds, weight, notes, order_index
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, created_at, updated_at
-	`
-	for i := range workout.Entries {
-		entry := &workout.Entries[i]
-		if err := tx.QueryRow(insertEntry,
-			workout.ID,
-			entry.ExerciseName,
-			entry.Sets,
-			entry.Reps,
-			entry.DurationSeconds,
-			entry.Weight,
-			entry.Notes,
-			entry.OrderIndex,
-		).Scan(
-			&entry.ID,
-			&entry.CreatedAt,
-			&entry.UpdatedAt,
-		); err != nil {
-			return err
-		}
+	if err := insertEntries(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	workout.NewRecords, err = detectRecords(ctx, tx, workout, catalogNames)
+	if err != nil {
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -139,13 +267,22 @@ func (pg *PostgresWorkoutStore) CreateWorkout(workout *models.Workout) error {
 	return nil
 }
 
-func (pg *PostgresWorkoutStore) UpdateWorkoutByID(workout *models.Workout) error {
-	tx, err := pg.db.Begin()
+func (pg *PostgresWorkoutStore) UpdateWorkoutByID(ctx context.Context, workout *models.Workout) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	tx, err := pg.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	if err := setShareToken(workout); err != nil {
+		return err
+	}
+
+	// a workout that stays unlisted keeps its stored link; callers who
+	// can't see the token, like coaches, would otherwise replace it
 	updateWorkout := `
 		UPDATE workouts 
 		SET 
@@ -153,45 +290,83 @@ func (pg *PostgresWorkoutStore) UpdateWorkoutByID(workout *models.Workout) error
 		description = $2, 
 		duration_minutes = $3, 
 		calories_burned = $4,
+		visibility = $5,
+		share_token = CASE WHEN $5 = 'unlisted' THEN COALESCE(share_token, NULLIF($6, '')) END,
+		status = $7,
 		updated_at = now()
-		WHERE id = $5
-		RETURNING updated_at
+		WHERE id = $8 AND organization_id IS NOT DISTINCT FROM $9
+		RETURNING updated_at, COALESCE(share_token, '')
 	`
-	err = tx.QueryRow(
+	err = tx.QueryRowContext(
+		ctx,
 		updateWorkout,
 		workout.Title,
 		workout.Description,
 		workout.DurationMinutes,
 		workout.CaloriesBurned,
+		workout.Visibility,
+		workout.ShareToken,
+		workout.Status,
 		workout.ID,
+		pg.organizationID,
 	).Scan(
 		&workout.UpdatedAt,
+		&workout.ShareToken,
 	)
 	if err != nil {
 		return err
 	}
 
 	// TODO: modify updating entries to use upsert
-	_, err = tx.Exec("DELETE FROM workout_entries WHERE workout_id = $1", workout.ID)
+	_, err = tx.ExecContext(ctx, "DELETE FROM workout_entries WHERE workout_id = $1", workout.ID)
 	if err != nil {
 		return err
 	}
 
+	if err := insertEntries(ctx, tx, workout); err != nil {
+		return err
+	}
+
+	workout.OrganizationID = pg.organizationID
+	workout.NewRecords, err = rebuildRecords(ctx, tx, workout, catalogNames)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// insertEntries writes the entries of the workout together with their sets.
+func insertEntries(ctx context.Context, tx *sql.Tx, workout *models.Workout) error {
 	insertEntry := `
 		INSERT INTO workout_entries
 		(
-			workout_id, exercise_name, sets, reps,
+			workout_id, exercise_id, exercise_name, sets, reps,
 			duration_seconds, weight, notes, order_index
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`
+	insertSet := `
+		INSERT INTO workout_sets
+		(
+			workout_entry_id, set_number, reps, weight, duration_seconds,
+			distance_meters, rpe, rest_seconds, failed
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at, updated_at
 	`
 	for i := range workout.Entries {
 		entry := &workout.Entries[i]
-		err = tx.QueryRow(insertEntry,
+		entry.WorkoutID = workout.ID
+		if err := tx.QueryRowContext(ctx, insertEntry,
 			workout.ID,
+			entry.ExerciseID,
 			entry.ExerciseName,
-			entry.Sets,
+			len(entry.Sets),
 			entry.Reps,
 			entry.DurationSeconds,
 			entry.Weight,
@@ -201,20 +376,78 @@ func (pg *PostgresWorkoutStore) UpdateWorkoutByID(workout *models.Workout) error
 			&entry.ID,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
-		)
-		if err != nil {
+		); err != nil {
 			return err
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return err
+		for j := range entry.Sets {
+			set := &entry.Sets[j]
+			set.WorkoutEntryID = entry.ID
+			set.SetNumber = j + 1
+			if err := tx.QueryRowContext(ctx, insertSet,
+				set.WorkoutEntryID,
+				set.SetNumber,
+				set.Reps,
+				set.Weight,
+				set.DurationSeconds,
+				set.DistanceMeters,
+				set.RPE,
+				set.RestSeconds,
+				set.Failed,
+			).Scan(
+				&set.ID,
+				&set.CreatedAt,
+				&set.UpdatedAt,
+			); err != nil {
+				return err
+			}
+		}
 	}
+
 	return nil
 }
 
-func (pg *PostgresWorkoutStore) DeleteWorkoutByID(id int64) error {
-	result, err := pg.db.Exec(`DELETE FROM workouts WHERE id = $1`, id)
+// ListEnrollmentWorkouts returns the workouts logged for the sessions of a
+// program enrollment.
+func (pg *PostgresWorkoutStore) ListEnrollmentWorkouts(ctx context.Context, enrollmentID int64) ([]models.SessionWorkout, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT w.id, w.program_session_id, w.status, w.created_at
+		FROM workouts w
+		WHERE w.enrollment_id = $1 AND w.program_session_id IS NOT NULL
+		AND ` + inOrganization("w", "$2") + `
+		ORDER BY w.created_at
+	`
+	rows, err := pg.db.QueryContext(ctx, query, enrollmentID, pg.organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workouts := []models.SessionWorkout{}
+	for rows.Next() {
+		w := models.SessionWorkout{}
+		if err := rows.Scan(&w.WorkoutID, &w.ProgramSessionID, &w.Status, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		workouts = append(workouts, w)
+	}
+
+	return workouts, rows.Err()
+}
+
+func (pg *PostgresWorkoutStore) DeleteWorkoutByID(ctx context.Context, id int64) error {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	result, err := pg.db.ExecContext(
+		ctx,
+		`DELETE FROM workouts w WHERE w.id = $1 AND `+inOrganization("w", "$2"),
+		id,
+		pg.organizationID,
+	)
 	if err != nil {
 		return err
 	}
@@ -230,17 +463,378 @@ func (pg *PostgresWorkoutStore) DeleteWorkoutByID(id int64) error {
 	return nil
 }
 
-func (pg *PostgresWorkoutStore) GetWorkoutOwner(workoutID int64) (int64, error) {
+// GetVisibleWorkoutOwner returns the owner of the workout if the viewer may
+// see it, and sql.ErrNoRows otherwise.
+func (pg *PostgresWorkoutStore) GetVisibleWorkoutOwner(ctx context.Context, workoutID, viewerID int64) (int64, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
 	var userID int64
 
 	query := `
-		SELECT user_id
-		FROM workouts
-		WHERE id = $1
+		SELECT w.user_id
+		FROM workouts w
+		WHERE w.id = $1 AND ` + visibleTo("$2") + ` AND ` + inOrganization("w", "$3") + `
 	`
-	if err := pg.db.QueryRow(query, workoutID).Scan(&userID); err != nil {
+	if err := pg.db.QueryRowContext(ctx, query, workoutID, viewerID, pg.organizationID).Scan(&userID); err != nil {
 		return 0, err
 	}
 
 	return userID, nil
 }
+
+func (pg *PostgresWorkoutStore) ListWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, string, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	sortExpr, err := workoutSortExpr(filter.Sort)
+	if err != nil {
+		return nil, "", err
+	}
+
+	conditions := []string{"w.user_id = $1", inOrganization("w", "$2")}
+	args := []any{filter.UserID, pg.organizationID}
+	addCondition := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if filter.From != nil {
+		addCondition("w.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("w.created_at < $%d", *filter.To)
+	}
+	if filter.Title != "" {
+		addCondition("w.title ILIKE '%%' || $%d || '%%'", escapeLike(filter.Title))
+	}
+	if filter.MinDuration != nil {
+		addCondition("w.duration_minutes >= $%d", *filter.MinDuration)
+	}
+	if filter.MaxDuration != nil {
+		addCondition("w.duration_minutes <= $%d", *filter.MaxDuration)
+	}
+	if filter.Exercise != "" {
+		addCondition(`EXISTS (
+			SELECT 1 FROM workout_entries e
+			WHERE e.workout_id = w.id AND e.exercise_name ILIKE '%%' || $%d || '%%'
+		)`, escapeLike(filter.Exercise))
+	}
+	if filter.Status != "" {
+		addCondition("w.status = $%d", filter.Status)
+	}
+	if filter.Visibility != "" {
+		addCondition("w.visibility = $%d", filter.Visibility)
+	}
+	if filter.Cursor != "" {
+		key, id, err := decodeWorkoutCursor(filter.Sort, filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, key, id)
+		conditions = append(conditions, fmt.Sprintf("(%s, w.id) < ($%d, $%d)", sortExpr, len(args)-1, len(args)))
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	args = append(args, filter.Limit+1)
+	query := fmt.Sprintf(`
+		SELECT `+workoutColumns+`
+		FROM workouts w
+		WHERE %s
+		ORDER BY %s DESC, w.id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), sortExpr, len(args))
+
+	rows, err := pg.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	workouts := []*models.Workout{}
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(workouts) > filter.Limit {
+		workouts = workouts[:filter.Limit]
+		nextCursor = encodeWorkoutCursor(filter.Sort, workouts[len(workouts)-1])
+	}
+
+	if err := pg.loadEntries(ctx, workouts); err != nil {
+		return nil, "", err
+	}
+
+	return workouts, nextCursor, nil
+}
+
+// ListFeed returns completed workouts of the users the viewer follows,
+// newest first. Each followed user contributes at most a page worth of
+// workouts through the feed index before the results are merged, which keeps
+// the cost bounded when following thousands of accounts.
+func (pg *PostgresWorkoutStore) ListFeed(ctx context.Context, viewerID int64, cursor string, limit int) ([]*models.Workout, string, error) {
+	ctx, cancel := WithTimeout(ctx, pg.queryTimeout)
+	defer cancel()
+
+	var (
+		before   *time.Time
+		beforeID int64
+	)
+	if cursor != "" {
+		key, id, err := decodeWorkoutCursor(WorkoutSortNewest, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		createdAt := key.(time.Time)
+		before, beforeID = &createdAt, id
+	}
+
+	// fetch one extra row to find out whether there is a next page
+	query := `
+		SELECT ` + workoutColumns + `
+		FROM follows f
+		CROSS JOIN LATERAL (
+			SELECT x.id
+			FROM workouts x
+			WHERE x.user_id = f.followee_id
+			AND x.status = 'completed' AND x.visibility IN ('public', 'followers')
+			AND ` + inOrganization("x", "$5") + `
+			AND ($2::timestamptz IS NULL OR (x.created_at, x.id) < ($2, $3))
+			ORDER BY x.created_at DESC, x.id DESC
+			LIMIT $4
+		) recent
+		INNER JOIN workouts w ON w.id = recent.id
+		WHERE f.follower_id = $1
+		ORDER BY w.created_at DESC, w.id DESC
+		LIMIT $4
+	`
+	rows, err := pg.db.QueryContext(ctx, query, viewerID, before, beforeID, limit+1, pg.organizationID)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	workouts := []*models.Workout{}
+	for rows.Next() {
+		workout, err := scanWorkout(rows)
+		if err != nil {
+			return nil, "", err
+		}
+		workout.ShareToken = ""
+		workouts = append(workouts, workout)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+		nextCursor = encodeWorkoutCursor(WorkoutSortNewest, workouts[len(workouts)-1])
+	}
+
+	if err := pg.loadEntries(ctx, workouts); err != nil {
+		return nil, "", err
+	}
+
+	return workouts, nextCursor, nil
+}
+
+// loadEntries fetches the entries of all given workouts in a single query.
+func (pg *PostgresWorkoutStore) loadEntries(ctx context.Context, workouts []*models.Workout) error {
+	if len(workouts) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(workouts))
+	byID := make(map[int64]*models.Workout, len(workouts))
+	for _, w := range workouts {
+		ids = append(ids, w.ID)
+		byID[w.ID] = w
+	}
+
+	query := `
+		SELECT 
+			id, workout_id, exercise_id, exercise_name, reps, duration_seconds, 
+			weight, notes, order_index, created_at, updated_at
+		FROM workout_entries
+		WHERE workout_id = ANY($1)
+		ORDER BY workout_id, order_index
+	`
+	rows, err := pg.db.QueryContext(ctx, query, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := models.WorkoutEntry{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.WorkoutID,
+			&e.ExerciseID,
+			&e.ExerciseName,
+			&e.Reps,
+			&e.DurationSeconds,
+			&e.Weight,
+			&e.Notes,
+			&e.OrderIndex,
+			&e.CreatedAt,
+			&e.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		workout := byID[e.WorkoutID]
+		workout.Entries = append(workout.Entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return pg.loadSets(ctx, ids, workouts)
+}
+
+// loadSets fetches the sets of every entry of the given workouts in a single
+// query. It expects the entries to be loaded already.
+func (pg *PostgresWorkoutStore) loadSets(ctx context.Context, workoutIDs []int64, workouts []*models.Workout) error {
+	entries := map[int64]*models.WorkoutEntry{}
+	for _, w := range workouts {
+		for i := range w.Entries {
+			entries[w.Entries[i].ID] = &w.Entries[i]
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT
+			s.id, s.workout_entry_id, s.set_number, s.reps, s.weight, s.duration_seconds,
+			s.distance_meters, s.rpe, s.rest_seconds, s.failed, s.created_at, s.updated_at
+		FROM workout_sets s
+		INNER JOIN workout_entries e ON e.id = s.workout_entry_id
+		WHERE e.workout_id = ANY($1)
+		ORDER BY s.workout_entry_id, s.set_number
+	`
+	rows, err := pg.db.QueryContext(ctx, query, workoutIDs)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		set := models.WorkoutSet{}
+		if err := rows.Scan(
+			&set.ID,
+			&set.WorkoutEntryID,
+			&set.SetNumber,
+			&set.Reps,
+			&set.Weight,
+			&set.DurationSeconds,
+			&set.DistanceMeters,
+			&set.RPE,
+			&set.RestSeconds,
+			&set.Failed,
+			&set.CreatedAt,
+			&set.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		entry := entries[set.WorkoutEntryID]
+		entry.Sets = append(entry.Sets, set)
+	}
+
+	return rows.Err()
+}
+
+// setShareToken gives unlisted workouts a share link and revokes the link of
+// workouts that are no longer unlisted.
+func setShareToken(workout *models.Workout) error {
+	if workout.Visibility != models.VisibilityUnlisted {
+		workout.ShareToken = ""
+		return nil
+	}
+	if workout.ShareToken != "" {
+		return nil
+	}
+
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return err
+	}
+	workout.ShareToken = base64.RawURLEncoding.EncodeToString(randomBytes)
+	return nil
+}
+
+func workoutSortExpr(sort string) (string, error) {
+	switch sort {
+	case WorkoutSortNewest:
+		return "w.created_at", nil
+	case WorkoutSortLongest:
+		return "w.duration_minutes", nil
+	case WorkoutSortCalories:
+		return "COALESCE(w.calories_burned, 0)", nil
+	default:
+		return "", fmt.Errorf("unknown workout sort %q", sort)
+	}
+}
+
+// A cursor is the sort key and id of the last workout on a page. The sort
+// name is included so a cursor can't be replayed against a different order.
+func encodeWorkoutCursor(sort string, w *models.Workout) string {
+	var key string
+	switch sort {
+	case WorkoutSortNewest:
+		key = w.CreatedAt.Format(time.RFC3339Nano)
+	case WorkoutSortLongest:
+		key = strconv.Itoa(w.DurationMinutes)
+	case WorkoutSortCalories:
+		key = strconv.Itoa(w.CaloriesBurned)
+	}
+
+	raw := fmt.Sprintf("%s|%s|%d", sort, key, w.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeWorkoutCursor(sort, cursor string) (any, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != sort {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	if sort == WorkoutSortNewest {
+		createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return createdAt, id, nil
+	}
+
+	key, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return key, id, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -4,19 +4,75 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base32"
+	"encoding/hex"
 	"time"
 )
 
 const (
-	ScopeAuth = "authentication"
+	ScopeAuth          = "authentication"
+	ScopeRefresh       = "refresh"
+	ScopePasswordReset = "password-reset"
+	ScopeActivation    = "activation"
 )
 
+// TTLs is how long each kind of token stays valid.
+type TTLs struct {
+	Access        time.Duration
+	Refresh       time.Duration
+	PasswordReset time.Duration
+	Activation    time.Duration
+}
+
+// For returns the lifetime of tokens with the given scope.
+func (t TTLs) For(scope string) time.Duration {
+	switch scope {
+	case ScopeAuth:
+		return t.Access
+	case ScopeRefresh:
+		return t.Refresh
+	case ScopePasswordReset:
+		return t.PasswordReset
+	case ScopeActivation:
+		return t.Activation
+	}
+	return 0
+}
+
 type Token struct {
 	PlainText string    `json:"token"`
 	Hash      []byte    `json:"-"`
 	UserID    int64     `json:"-"`
 	Expiry    time.Time `json:"expiry"`
 	Scope     string    `json:"-"`
+	UserAgent string    `json:"-"`
+	IP        string    `json:"-"`
+	// FamilyID ties an access token to the chain of refresh tokens it was
+	// issued with, so the whole chain can be revoked at once.
+	FamilyID string `json:"-"`
+}
+
+// Pair is what a login or refresh hands out.
+type Pair struct {
+	Access  *Token
+	Refresh *Token
+}
+
+// Session describes an issued token without revealing it. Current marks the
+// token the request was authenticated with.
+type Session struct {
+	ID         int64      `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Expiry     time.Time  `json:"expiry"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	Current    bool       `json:"current"`
+}
+
+// Hash returns the digest tokens are stored and looked up by.
+func Hash(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
 }
 
 func GenerateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
@@ -32,7 +88,31 @@ func GenerateToken(userID int64, ttl time.Duration, scope string) (*Token, error
 	}
 
 	token.PlainText = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(emptyBytes)
-	hash := sha256.Sum256([]byte(token.PlainText))
-	token.Hash = hash[:]
+	token.Hash = Hash(token.PlainText)
 	return token, nil
 }
+
+// GeneratePair creates an access and a refresh token in the given family.
+func GeneratePair(userID int64, familyID string, ttls TTLs) (*Pair, error) {
+	access, err := GenerateToken(userID, ttls.Access, ScopeAuth)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := GenerateToken(userID, ttls.Refresh, ScopeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	access.FamilyID = familyID
+	refresh.FamilyID = familyID
+	return &Pair{Access: access, Refresh: refresh}, nil
+}
+
+// NewFamilyID returns a random identifier for a new token family.
+func NewFamilyID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
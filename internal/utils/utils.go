@@ -1,8 +1,10 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"strconv"
 
@@ -41,3 +43,22 @@ func ReadIDParam(r *http.Request) (int64, error) {
 
 	return id, nil
 }
+
+// ClientIP returns the address of the connecting client. Forwarding headers
+// are ignored since they can't be trusted without a known proxy.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ServerErrorStatus returns the status for a request that failed with err:
+// 504 when the database didn't answer in time, 500 for anything else.
+func ServerErrorStatus(err error) int {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
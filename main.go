@@ -1,37 +1,93 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/agkmw/workout-service/internal/app"
+	"github.com/agkmw/workout-service/internal/config"
 	"github.com/agkmw/workout-service/internal/routes"
 )
 
+// exit codes, so that supervisors can tell a bad deploy from a crash
+const (
+	exitOK        = 0
+	exitRuntime   = 1
+	exitConfig    = 2
+	exitMigration = 3
+)
+
 func main() {
-	var port int
-	flag.IntVar(&port, "port", 8080, "the port to listen to requests")
-	flag.Parse()
+	os.Exit(run())
+}
 
-	app, err := app.New()
+func run() int {
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
 	if err != nil {
-		panic(err)
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return exitConfig
+	}
+
+	application, err := app.New(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, app.ErrMigration) {
+			return exitMigration
+		}
+		return exitRuntime
 	}
-	defer app.DB.Close()
 
 	server := http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      routes.SetupRoutes(app),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		Addr:         fmt.Sprintf(":%d", cfg.Port),
+		Handler:      routes.SetupRoutes(application),
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
 	}
 
-	app.Logger.Info("server running", "port", port)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	application.Lifecycle.Start()
 
-	if err := server.ListenAndServe(); err != nil {
-		app.Logger.Error("server failed", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	application.Logger.Info("server running", "port", cfg.Port)
+
+	code := exitOK
+	select {
+	case err := <-serverErr:
+		application.Logger.Error("server failed", "error", err)
+		code = exitRuntime
+	case <-ctx.Done():
+		application.Logger.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	}
+	// a second signal kills the process right away
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		application.Logger.Error("failed to drain in-flight requests", "error", err)
+		code = exitRuntime
+	}
+	if err := application.Close(shutdownCtx); err != nil {
+		application.Logger.Error("failed to stop cleanly", "error", err)
+		code = exitRuntime
 	}
+
+	application.Logger.Info("server stopped")
+	return code
 }
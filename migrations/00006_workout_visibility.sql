@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE workouts
+ADD COLUMN visibility VARCHAR (20) NOT NULL DEFAULT 'private',
+ADD COLUMN share_token VARCHAR (64) UNIQUE,
+ADD CONSTRAINT valid_workout_visibility CHECK (
+  visibility IN ('private', 'followers', 'public', 'unlisted')
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workouts
+DROP CONSTRAINT valid_workout_visibility,
+DROP COLUMN share_token,
+DROP COLUMN visibility;
+-- +goose StatementEnd
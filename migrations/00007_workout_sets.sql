@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_sets (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  workout_entry_id BIGINT NOT NULL REFERENCES workout_entries (id) ON DELETE CASCADE,
+  set_number INTEGER NOT NULL,
+  reps INTEGER,
+  weight DECIMAL(6, 2),
+  duration_seconds INTEGER,
+  distance_meters DECIMAL(10, 2),
+  rpe DECIMAL(3, 1),
+  rest_seconds INTEGER,
+  failed BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_workout_set CHECK (
+    reps IS NOT NULL OR duration_seconds IS NOT NULL OR distance_meters IS NOT NULL
+  ),
+  CONSTRAINT valid_workout_set_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_sets_entry ON workout_sets (workout_entry_id, set_number);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- expand the collapsed entries into one row per set
+INSERT INTO workout_sets (workout_entry_id, set_number, reps, weight, duration_seconds)
+SELECT e.id, n, e.reps, e.weight, e.duration_seconds
+FROM workout_entries e, generate_series(1, e.sets) AS n;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_sets;
+-- +goose StatementEnd
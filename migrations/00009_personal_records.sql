@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS personal_records (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  workout_id BIGINT NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  exercise_id BIGINT REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_key VARCHAR (255) NOT NULL, -- normalized exercise name
+  exercise_name VARCHAR (255) NOT NULL,
+  record_type VARCHAR (50) NOT NULL,
+  value DECIMAL(10, 2) NOT NULL,
+  weight DECIMAL(6, 2),
+  reps INTEGER,
+  achieved_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_record_type CHECK (
+    record_type IN (
+      'max_weight', 'max_reps', 'estimated_1rm_epley',
+      'estimated_1rm_brzycki', 'max_duration'
+    )
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_personal_records_lookup
+ON personal_records (user_id, exercise_key, record_type, value DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_personal_records_workout ON personal_records (workout_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_records;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_templates (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name VARCHAR (255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_templates_user ON workout_templates (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_template_entries (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  template_id BIGINT NOT NULL REFERENCES workout_templates (id) ON DELETE CASCADE,
+  exercise_id BIGINT REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_name VARCHAR (255) NOT NULL,
+  target_sets INTEGER NOT NULL,
+  target_reps INTEGER,
+  target_weight DECIMAL(6, 2),
+  target_duration_seconds INTEGER,
+  rest_seconds INTEGER,
+  weight_increment DECIMAL(6, 2),
+  notes TEXT NOT NULL DEFAULT '',
+  order_index INTEGER NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_template_entry CHECK (
+    target_sets > 0 AND
+    (target_reps IS NOT NULL OR target_duration_seconds IS NOT NULL) AND
+    (target_reps IS NULL OR target_duration_seconds IS NULL)
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workouts
+ADD COLUMN template_id BIGINT REFERENCES workout_templates (id) ON DELETE SET NULL,
+ADD COLUMN status VARCHAR (20) NOT NULL DEFAULT 'completed',
+ADD CONSTRAINT valid_workout_status CHECK (status IN ('planned', 'completed'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_template ON workouts (template_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workouts
+DROP CONSTRAINT valid_workout_status,
+DROP COLUMN status,
+DROP COLUMN template_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_template_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_templates;
+-- +goose StatementEnd
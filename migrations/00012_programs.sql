@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS programs (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name VARCHAR (255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  weeks INTEGER NOT NULL,
+  deload_percentage DECIMAL(5, 2) NOT NULL DEFAULT 60,
+  is_public BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_program CHECK (
+    weeks > 0 AND deload_percentage > 0 AND deload_percentage <= 100
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_deload_weeks (
+  program_id BIGINT NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  week_number INTEGER NOT NULL,
+  PRIMARY KEY (program_id, week_number)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_sessions (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  program_id BIGINT NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  week_number INTEGER NOT NULL,
+  day_number INTEGER NOT NULL,
+  -- templates used by a program can't be deleted out from under it
+  template_id BIGINT NOT NULL REFERENCES workout_templates (id) ON DELETE RESTRICT,
+  intensity_percentage DECIMAL(5, 2), -- of the estimated 1RM
+  UNIQUE (program_id, week_number, day_number),
+  CONSTRAINT valid_program_session CHECK (
+    week_number > 0 AND day_number BETWEEN 1 AND 7 AND
+    (intensity_percentage IS NULL OR (intensity_percentage > 0 AND intensity_percentage <= 100))
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_enrollments (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  program_id BIGINT NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  start_date DATE NOT NULL,
+  status VARCHAR (20) NOT NULL DEFAULT 'active',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT valid_enrollment_status CHECK (status IN ('active', 'cancelled'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_program_enrollments_user ON program_enrollments (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workouts
+ADD COLUMN enrollment_id BIGINT REFERENCES program_enrollments (id) ON DELETE SET NULL,
+ADD COLUMN program_session_id BIGINT REFERENCES program_sessions (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_enrollment ON workouts (enrollment_id, program_session_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workouts
+DROP COLUMN program_session_id,
+DROP COLUMN enrollment_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_enrollments;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_sessions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_deload_weeks;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS programs;
+-- +goose StatementEnd
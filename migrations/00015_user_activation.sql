@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- accounts that existed before activation was introduced stay usable
+ALTER TABLE users
+ADD COLUMN activated BOOLEAN NOT NULL DEFAULT TRUE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users
+ALTER COLUMN activated SET DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN activated;
+-- +goose StatementEnd
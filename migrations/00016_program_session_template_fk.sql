@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- RESTRICT is checked before cascades run, which made deleting a user who
+-- owns both a program and the templates it schedules fail. NO ACTION still
+-- protects templates on their own but is checked after the cascade.
+ALTER TABLE program_sessions
+DROP CONSTRAINT program_sessions_template_id_fkey,
+ADD CONSTRAINT program_sessions_template_id_fkey
+  FOREIGN KEY (template_id) REFERENCES workout_templates (id) ON DELETE NO ACTION;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE program_sessions
+DROP CONSTRAINT program_sessions_template_id_fkey,
+ADD CONSTRAINT program_sessions_template_id_fkey
+  FOREIGN KEY (template_id) REFERENCES workout_templates (id) ON DELETE RESTRICT;
+-- +goose StatementEnd
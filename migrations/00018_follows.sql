@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS follows (
+  follower_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  followee_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (follower_id, followee_id),
+  CONSTRAINT no_self_follow CHECK (follower_id <> followee_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows (followee_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- the feed reads the newest few workouts of every followed user from here
+CREATE INDEX IF NOT EXISTS idx_workouts_feed ON workouts (user_id, created_at DESC, id DESC)
+WHERE status = 'completed' AND visibility IN ('public', 'followers');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_workouts_feed;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS follows;
+-- +goose StatementEnd
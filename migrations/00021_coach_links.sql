@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS coach_links (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  coach_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  athlete_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  accepted_at TIMESTAMP WITH TIME ZONE,
+  CONSTRAINT coach_links_pair_key UNIQUE (coach_id, athlete_id),
+  CONSTRAINT no_self_coaching CHECK (coach_id <> athlete_id),
+  CONSTRAINT valid_coach_link_status CHECK (status IN ('pending', 'active'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_coach_links_athlete ON coach_links (athlete_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS coach_links;
+-- +goose StatementEnd
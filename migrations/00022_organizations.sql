@@ -0,0 +1,102 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations (
+  id BIGINT PRIMARY KEY GENERATED ALWAYS AS IDENTITY,
+  name VARCHAR (255) NOT NULL,
+  slug VARCHAR (50) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT organizations_slug_key UNIQUE (slug)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id BIGINT NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role VARCHAR (20) NOT NULL DEFAULT 'member',
+  created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (organization_id, user_id),
+  CONSTRAINT valid_member_role CHECK (role IN ('owner', 'admin', 'coach', 'member'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- rows without an organization belong to the personal space of their owner
+ALTER TABLE workouts
+ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_organization ON workouts (organization_id, user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workout_templates
+ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_templates_organization ON workout_templates (organization_id, user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- the shared catalog has no organization; organizations add their own
+-- exercises on top, and names only need to be unique within each
+ALTER TABLE exercises
+ADD COLUMN IF NOT EXISTS organization_id BIGINT REFERENCES organizations (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exercises_name;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_name ON exercises (lower(name)) WHERE organization_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_organization_name ON exercises (organization_id, lower(name))
+WHERE organization_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exercises_organization_name;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM exercises WHERE organization_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_exercises_name;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_name ON exercises (lower(name));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE exercises DROP COLUMN IF EXISTS organization_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workout_templates DROP COLUMN IF EXISTS organization_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workouts DROP COLUMN IF EXISTS organization_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organization_members;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
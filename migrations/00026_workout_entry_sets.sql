@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- entries logged set by set carry their reps, durations and distances on
+-- the sets, so they no longer need a value of their own. Entries without
+-- sets still need reps or a duration, and none may have both.
+ALTER TABLE workout_entries
+DROP CONSTRAINT valid_workout_entry,
+ADD CONSTRAINT valid_workout_entry CHECK (
+  (sets > 0 OR reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+  (reps IS NULL OR duration_seconds IS NULL)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE workout_entries
+DROP CONSTRAINT valid_workout_entry,
+ADD CONSTRAINT valid_workout_entry CHECK (
+  (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+  (reps IS NULL OR duration_seconds IS NULL)
+);
+-- +goose StatementEnd
@@ -0,0 +1,538 @@
+-- The schema the Postgres migrations 00001 to 00024 build, translated for
+-- SQLite:
+--   * identity columns are INTEGER PRIMARY KEY AUTOINCREMENT, which never
+--     reuses the id of a deleted row either
+--   * timestamps are UTC text with six fractional digits, the format the
+--     store binds times in, so they compare correctly as strings
+--   * token and password hashes are BLOBs
+--   * arrays and JSONB are JSON text
+--   * CHECK constraints keep their Postgres names
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  username VARCHAR (255) UNIQUE NOT NULL,
+  email VARCHAR (255) UNIQUE NOT NULL,
+  password_hash BLOB NOT NULL,
+  bio TEXT,
+  timezone VARCHAR (64) NOT NULL DEFAULT 'UTC',
+  activated BOOLEAN NOT NULL DEFAULT FALSE,
+  role VARCHAR (20) NOT NULL DEFAULT 'user',
+  disabled BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_user_role CHECK (role IN ('user', 'coach', 'admin'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organizations (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR (255) NOT NULL,
+  slug VARCHAR (50) NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT organizations_slug_key UNIQUE (slug)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS organization_members (
+  organization_id INTEGER NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role VARCHAR (20) NOT NULL DEFAULT 'member',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  PRIMARY KEY (organization_id, user_id),
+  CONSTRAINT valid_member_role CHECK (role IN ('owner', 'admin', 'coach', 'member'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_organization_members_user ON organization_members (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS exercises (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  name VARCHAR (255) NOT NULL,
+  primary_muscles TEXT NOT NULL DEFAULT '[]',
+  secondary_muscles TEXT NOT NULL DEFAULT '[]',
+  equipment VARCHAR (100),
+  movement_type VARCHAR (100),
+  organization_id INTEGER REFERENCES organizations (id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_name ON exercises (lower(name)) WHERE organization_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_organization_name ON exercises (organization_id, lower(name))
+WHERE organization_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS exercise_aliases (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  exercise_id INTEGER NOT NULL REFERENCES exercises (id) ON DELETE CASCADE,
+  alias VARCHAR (255) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercise_aliases_alias ON exercise_aliases (lower(alias));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_templates (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  organization_id INTEGER REFERENCES organizations (id) ON DELETE CASCADE,
+  name VARCHAR (255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_templates_user ON workout_templates (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_templates_organization ON workout_templates (organization_id, user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_template_entries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  template_id INTEGER NOT NULL REFERENCES workout_templates (id) ON DELETE CASCADE,
+  exercise_id INTEGER REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_name VARCHAR (255) NOT NULL,
+  target_sets INTEGER NOT NULL,
+  target_reps INTEGER,
+  target_weight REAL,
+  target_duration_seconds INTEGER,
+  rest_seconds INTEGER,
+  weight_increment REAL,
+  notes TEXT NOT NULL DEFAULT '',
+  order_index INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_template_entry CHECK (
+    target_sets > 0 AND
+    (target_reps IS NOT NULL OR target_duration_seconds IS NOT NULL) AND
+    (target_reps IS NULL OR target_duration_seconds IS NULL)
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS programs (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name VARCHAR (255) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  weeks INTEGER NOT NULL,
+  deload_percentage REAL NOT NULL DEFAULT 60,
+  is_public BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_program CHECK (
+    weeks > 0 AND deload_percentage > 0 AND deload_percentage <= 100
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_deload_weeks (
+  program_id INTEGER NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  week_number INTEGER NOT NULL,
+  PRIMARY KEY (program_id, week_number)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_sessions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  program_id INTEGER NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  week_number INTEGER NOT NULL,
+  day_number INTEGER NOT NULL,
+  -- NO ACTION is checked after cascades, so deleting a user who owns both
+  -- the program and its templates works, but a template still can't be
+  -- deleted out from under a program on its own
+  template_id INTEGER NOT NULL REFERENCES workout_templates (id) ON DELETE NO ACTION,
+  intensity_percentage REAL, -- of the estimated 1RM
+  UNIQUE (program_id, week_number, day_number),
+  CONSTRAINT valid_program_session CHECK (
+    week_number > 0 AND day_number BETWEEN 1 AND 7 AND
+    (intensity_percentage IS NULL OR (intensity_percentage > 0 AND intensity_percentage <= 100))
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS program_enrollments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  program_id INTEGER NOT NULL REFERENCES programs (id) ON DELETE CASCADE,
+  start_date DATE NOT NULL,
+  status VARCHAR (20) NOT NULL DEFAULT 'active',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_enrollment_status CHECK (status IN ('active', 'cancelled'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_program_enrollments_user ON program_enrollments (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workouts (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  -- rows without an organization belong to the personal space of their owner
+  organization_id INTEGER REFERENCES organizations (id) ON DELETE CASCADE,
+  title VARCHAR (255) NOT NULL,
+  description TEXT,
+  duration_minutes INTEGER NOT NULL,
+  calories_burned INTEGER,
+  visibility VARCHAR (20) NOT NULL DEFAULT 'private',
+  share_token VARCHAR (64) UNIQUE,
+  status VARCHAR (20) NOT NULL DEFAULT 'completed',
+  template_id INTEGER REFERENCES workout_templates (id) ON DELETE SET NULL,
+  enrollment_id INTEGER REFERENCES program_enrollments (id) ON DELETE SET NULL,
+  program_session_id INTEGER REFERENCES program_sessions (id) ON DELETE SET NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_visibility CHECK (
+    visibility IN ('private', 'followers', 'public', 'unlisted')
+  ),
+  CONSTRAINT valid_workout_status CHECK (status IN ('planned', 'completed'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_template ON workouts (template_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_enrollment ON workouts (enrollment_id, program_session_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_user_visibility ON workouts (user_id, visibility, status);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- the feed reads the newest few workouts of every followed user from here
+CREATE INDEX IF NOT EXISTS idx_workouts_feed ON workouts (user_id, created_at DESC, id DESC)
+WHERE status = 'completed' AND visibility IN ('public', 'followers');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workouts_organization ON workouts (organization_id, user_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_entries (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_name VARCHAR (255) NOT NULL,
+  sets INTEGER NOT NULL,
+  reps INTEGER,
+  duration_seconds INTEGER,
+  weight REAL,
+  notes TEXT,
+  order_index INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_entry CHECK (
+    (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+    (reps IS NULL OR duration_seconds IS NULL)
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_entries_exercise ON workout_entries (exercise_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_sets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_entry_id INTEGER NOT NULL REFERENCES workout_entries (id) ON DELETE CASCADE,
+  set_number INTEGER NOT NULL,
+  reps INTEGER,
+  weight REAL,
+  duration_seconds INTEGER,
+  distance_meters REAL,
+  rpe REAL,
+  rest_seconds INTEGER,
+  failed BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_set CHECK (
+    reps IS NOT NULL OR duration_seconds IS NOT NULL OR distance_meters IS NOT NULL
+  ),
+  CONSTRAINT valid_workout_set_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_sets_entry ON workout_sets (workout_entry_id, set_number);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS personal_records (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_key VARCHAR (255) NOT NULL, -- normalized exercise name
+  exercise_name VARCHAR (255) NOT NULL,
+  record_type VARCHAR (50) NOT NULL,
+  value REAL NOT NULL,
+  weight REAL,
+  reps INTEGER,
+  achieved_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_record_type CHECK (
+    record_type IN (
+      'max_weight', 'max_reps', 'estimated_1rm_epley',
+      'estimated_1rm_brzycki', 'max_duration'
+    )
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_personal_records_lookup
+ON personal_records (user_id, exercise_key, record_type, value DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_personal_records_workout ON personal_records (workout_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS tokens (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  hash BLOB UNIQUE NOT NULL,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  expiry TIMESTAMP NOT NULL, -- whole seconds, like Postgres' TIMESTAMP (0)
+  scope TEXT NOT NULL,
+  family_id VARCHAR (64),
+  user_agent TEXT NOT NULL DEFAULT '',
+  ip VARCHAR (45) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  last_used_at TIMESTAMP,
+  used_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_tokens_user_scope ON tokens (user_id, scope);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_tokens_family ON tokens (family_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_tokens_expiry ON tokens (expiry);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS follows (
+  follower_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  followee_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  PRIMARY KEY (follower_id, followee_id),
+  CONSTRAINT no_self_follow CHECK (follower_id <> followee_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows (followee_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_comments (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  body TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_workout_comments_workout ON workout_comments (workout_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workout_reactions (
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  kind VARCHAR (20) NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  PRIMARY KEY (workout_id, user_id, kind),
+  CONSTRAINT valid_reaction_kind CHECK (kind IN ('like', 'fire', 'strong', 'clap'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS admin_actions (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  admin_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+  action VARCHAR (50) NOT NULL,
+  target_type VARCHAR (50) NOT NULL,
+  target_id INTEGER NOT NULL,
+  details TEXT NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_admin_actions_created ON admin_actions (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS coach_links (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  coach_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  athlete_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  status VARCHAR (20) NOT NULL DEFAULT 'pending',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  accepted_at TIMESTAMP,
+  CONSTRAINT coach_links_pair_key UNIQUE (coach_id, athlete_id),
+  CONSTRAINT no_self_coaching CHECK (coach_id <> athlete_id),
+  CONSTRAINT valid_coach_link_status CHECK (status IN ('pending', 'active'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_coach_links_athlete ON coach_links (athlete_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_events (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  actor_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+  action VARCHAR (50) NOT NULL,
+  target_type VARCHAR (50) NOT NULL,
+  target_id INTEGER,
+  ip VARCHAR (45) NOT NULL DEFAULT '',
+  user_agent TEXT NOT NULL DEFAULT '',
+  changes TEXT NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id, created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_type, target_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events (created_at DESC, id DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS audit_events;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS coach_links;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS admin_actions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_reactions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_comments;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS follows;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS tokens;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS personal_records;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_sets;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workouts;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_enrollments;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_sessions;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS program_deload_weeks;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS programs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_template_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workout_templates;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exercise_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS exercises;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organization_members;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS organizations;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
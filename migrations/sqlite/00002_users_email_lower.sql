@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- emails are looked up case-insensitively, so they must be unique that way
+-- too. Fails if existing accounts differ only in the case of their email.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (lower(email));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_email_lower_key;
+-- +goose StatementEnd
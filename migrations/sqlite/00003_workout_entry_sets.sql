@@ -0,0 +1,164 @@
+-- Entries logged set by set carry their reps, durations and distances on
+-- the sets, so they no longer need a value of their own. Entries without
+-- sets still need reps or a duration, and none may have both.
+--
+-- SQLite can't change a CHECK constraint, so workout_entries is rebuilt.
+-- Dropping it would cascade to workout_sets, which is set aside first and
+-- restored afterwards. Nothing else references either table.
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE workout_sets_copy AS SELECT * FROM workout_sets;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_sets;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE workout_entries_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_name VARCHAR (255) NOT NULL,
+  sets INTEGER NOT NULL,
+  reps INTEGER,
+  duration_seconds INTEGER,
+  weight REAL,
+  notes TEXT,
+  order_index INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_entry CHECK (
+    (sets > 0 OR reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+    (reps IS NULL OR duration_seconds IS NULL)
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO workout_entries_new SELECT * FROM workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workout_entries_new RENAME TO workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_workout_entries_exercise ON workout_entries (exercise_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE workout_sets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_entry_id INTEGER NOT NULL REFERENCES workout_entries (id) ON DELETE CASCADE,
+  set_number INTEGER NOT NULL,
+  reps INTEGER,
+  weight REAL,
+  duration_seconds INTEGER,
+  distance_meters REAL,
+  rpe REAL,
+  rest_seconds INTEGER,
+  failed BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_set CHECK (
+    reps IS NOT NULL OR duration_seconds IS NOT NULL OR distance_meters IS NOT NULL
+  ),
+  CONSTRAINT valid_workout_set_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO workout_sets SELECT * FROM workout_sets_copy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_sets_copy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_workout_sets_entry ON workout_sets (workout_entry_id, set_number);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE workout_sets_copy AS SELECT * FROM workout_sets;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_sets;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE workout_entries_new (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_id INTEGER NOT NULL REFERENCES workouts (id) ON DELETE CASCADE,
+  exercise_id INTEGER REFERENCES exercises (id) ON DELETE SET NULL,
+  exercise_name VARCHAR (255) NOT NULL,
+  sets INTEGER NOT NULL,
+  reps INTEGER,
+  duration_seconds INTEGER,
+  weight REAL,
+  notes TEXT,
+  order_index INTEGER NOT NULL,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_entry CHECK (
+    (reps IS NOT NULL OR duration_seconds IS NOT NULL) AND
+    (reps IS NULL OR duration_seconds IS NULL)
+  )
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO workout_entries_new SELECT * FROM workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE workout_entries_new RENAME TO workout_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_workout_entries_exercise ON workout_entries (exercise_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE workout_sets (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  workout_entry_id INTEGER NOT NULL REFERENCES workout_entries (id) ON DELETE CASCADE,
+  set_number INTEGER NOT NULL,
+  reps INTEGER,
+  weight REAL,
+  duration_seconds INTEGER,
+  distance_meters REAL,
+  rpe REAL,
+  rest_seconds INTEGER,
+  failed BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  updated_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f000+00:00', 'now')),
+  CONSTRAINT valid_workout_set CHECK (
+    reps IS NOT NULL OR duration_seconds IS NOT NULL OR distance_meters IS NOT NULL
+  ),
+  CONSTRAINT valid_workout_set_rpe CHECK (rpe IS NULL OR (rpe >= 1 AND rpe <= 10))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO workout_sets SELECT * FROM workout_sets_copy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE workout_sets_copy;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idx_workout_sets_entry ON workout_sets (workout_entry_id, set_number);
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+// Package sqlite holds the schema of the SQLite store. It mirrors the
+// Postgres migrations in the parent directory, so every change to the schema
+// needs a migration in both sets.
+package sqlite
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS